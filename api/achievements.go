@@ -0,0 +1,31 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"fitness-dev/backend"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AchievementsHandler lists the badges ?athlete= has earned and the ones
+// still locked, with weight thresholds in the request's unit.
+func AchievementsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		achievements, err := backend.GetAchievements(db, queryAthlete(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		achievements.Earned = badgesInUnits(achievements.Earned, units)
+		achievements.Locked = badgesInUnits(achievements.Locked, units)
+		c.JSON(http.StatusOK, achievements)
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+
+	"fitness-dev/backend"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestActor identifies the caller for the audit log. Clients name
+// themselves with the X-User header.
+func requestActor(c *gin.Context) backend.Actor {
+	name := c.GetHeader("X-User")
+	if name == "" {
+		name = "anonymous"
+	}
+	return backend.Actor{Name: name, Source: backend.SourceAPI}
+}
+
+// RequireAdmin guards admin endpoints with the token in FITNESS_ADMIN_TOKEN,
+// sent by clients as "Authorization: Bearer <token>". When no token is
+// configured the endpoints are left open, which is only suitable for development.
+func RequireAdmin() gin.HandlerFunc {
+	token := os.Getenv("FITNESS_ADMIN_TOKEN")
+	if token == "" {
+		log.Println("FITNESS_ADMIN_TOKEN is not set; admin endpoints are unprotected")
+	}
+
+	return func(c *gin.Context) {
+		if token == "" {
+			return
+		}
+		given := c.GetHeader("Authorization")
+		if subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+token)) != 1 {
+			writeProblem(c, http.StatusUnauthorized, "a valid admin token is required", nil)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"fitness-dev/backend"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdherenceHandler compares what ?athlete= planned from ?from= to ?to= with
+// what they did. Tonnages are in the request's unit.
+func AdherenceHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		a, err := backend.GetAdherence(db, queryAthlete(c), c.Query("from"), c.Query("to"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, adherenceInUnits(a, units))
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitness-dev/backend"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttendanceHandler returns the streaks, session durations and common
+// training times of ?athlete=, with sessions in each of the last ?weeks=.
+func AttendanceHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		weeks, err := strconv.Atoi(c.DefaultQuery("weeks", fmt.Sprint(backend.DefaultAttendanceWeeks)))
+		if err != nil {
+			badRequest(c, "invalid weeks")
+			return
+		}
+
+		attendance, err := backend.GetAttendance(db, queryAthlete(c), weeks)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, attendance)
+	}
+}
+
+// CalendarHandler returns heatmap data for every day of ?year= (default
+// this year) for ?athlete=.
+func CalendarHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		year, err := strconv.Atoi(c.DefaultQuery("year", fmt.Sprint(time.Now().Year())))
+		if err != nil {
+			badRequest(c, "invalid year")
+			return
+		}
+
+		calendar, err := backend.GetCalendar(db, queryAthlete(c), year)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, calendar)
+	}
+}
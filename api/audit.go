@@ -0,0 +1,59 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLogHandler lists audit entries, optionally filtered by workout_id,
+// by entity and key, and by a from/to time range (RFC 3339 or DD/MM/YYYY).
+func GetAuditLogHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var filter backend.AuditFilter
+
+		if s := c.Query("workout_id"); s != "" {
+			id, err := strconv.Atoi(s)
+			if err != nil {
+				badRequest(c, "invalid workout_id")
+				return
+			}
+			filter.WorkoutID = id
+		}
+		filter.Entity, filter.Key = c.Query("entity"), c.Query("key")
+		if filter.Key != "" && filter.Entity == "" {
+			badRequest(c, "key requires entity")
+			return
+		}
+		if s := c.Query("limit"); s != "" {
+			limit, err := strconv.Atoi(s)
+			if err != nil || limit < 0 {
+				badRequest(c, "invalid limit")
+				return
+			}
+			filter.Limit = limit
+		}
+
+		var err error
+		if filter.From, err = backend.ParseAuditTime(c.Query("from"), false); err != nil {
+			badRequest(c, "invalid from: "+err.Error())
+			return
+		}
+		if filter.To, err = backend.ParseAuditTime(c.Query("to"), true); err != nil {
+			badRequest(c, "invalid to: "+err.Error())
+			return
+		}
+
+		entries, err := backend.GetAuditLog(db, filter)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
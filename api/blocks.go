@@ -0,0 +1,225 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func blockIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid block ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// bindBlock reads a block from the body. Blocks belong to the caller unless
+// they name an athlete.
+func bindBlock(c *gin.Context) (models.Block, bool) {
+	var block models.Block
+	if err := c.ShouldBindJSON(&block); err != nil {
+		badRequest(c, err.Error())
+		return models.Block{}, false
+	}
+	if block.Athlete == "" {
+		block.Athlete = requestActor(c).Name
+	}
+	return block, true
+}
+
+// ListBlocksHandler lists the blocks of ?athlete=, by default the caller.
+func ListBlocksHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		blocks, err := backend.GetBlocks(db, queryAthlete(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		for i := range blocks {
+			blocks[i] = blockInUnits(blocks[i], models.UnitKilogram, units)
+		}
+		c.JSON(http.StatusOK, blocks)
+	}
+}
+
+func GetBlockHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := blockIDParam(c)
+		if !ok {
+			return
+		}
+
+		block, err := backend.GetBlock(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, blockInUnits(block, models.UnitKilogram, units))
+	}
+}
+
+func CreateBlockHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		block, ok := bindBlock(c)
+		if !ok {
+			return
+		}
+
+		id, err := backend.CreateBlock(db, blockInUnits(block, units, models.UnitKilogram), requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Block created successfully", "id": id})
+	}
+}
+
+func UpdateBlockHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := blockIDParam(c)
+		if !ok {
+			return
+		}
+
+		block, ok := bindBlock(c)
+		if !ok {
+			return
+		}
+		block.ID = id
+
+		if err := backend.UpdateBlock(db, blockInUnits(block, units, models.UnitKilogram), requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Block updated successfully"})
+	}
+}
+
+func DeleteBlockHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := blockIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.DeleteBlock(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Block deleted successfully"})
+	}
+}
+
+// GetBlockWorkoutsHandler lists the workouts that fall within a block.
+func GetBlockWorkoutsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := blockIDParam(c)
+		if !ok {
+			return
+		}
+
+		workouts, err := backend.GetBlockWorkouts(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, workoutsInUnits(workouts, units))
+	}
+}
+
+// BlockAnalysisHandler returns weekly stats and recommendations for a block.
+func BlockAnalysisHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := blockIDParam(c)
+		if !ok {
+			return
+		}
+
+		analysis, err := backend.AnalyzeBlock(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, analysisInUnits(analysis, units))
+	}
+}
+
+// CurrentBlockAnalysisHandler is BlockAnalysisHandler for the block of
+// ?athlete= (by default the caller) covering today.
+func CurrentBlockAnalysisHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		analysis, err := backend.AnalyzeCurrentBlock(db, queryAthlete(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, analysisInUnits(analysis, units))
+	}
+}
+
+// WeeklyStatsHandler summarises the last ?weeks= (default 4) weeks of
+// ?athlete=, by default the caller.
+func WeeklyStatsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		weeks, err := strconv.Atoi(c.DefaultQuery("weeks", "4"))
+		if err != nil || weeks < 1 || weeks > 52 {
+			badRequest(c, "weeks must be between 1 and 52")
+			return
+		}
+
+		stats, err := backend.WeeklyStats(db, queryAthlete(c), weeks)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, weeksInUnits(stats, units))
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/importer"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// uploadedTrack parses the GPX, TCX or FIT file sent in the "file" form field.
+// On failure a problem response is written and ok is false.
+func uploadedTrack(c *gin.Context) (track importer.Track, ok bool) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		badRequest(c, "a GPX, TCX or FIT file is required in the file field")
+		return importer.Track{}, false
+	}
+	f, err := header.Open()
+	if err != nil {
+		respondError(c, err)
+		return importer.Track{}, false
+	}
+	defer f.Close()
+
+	track, err = importer.Parse(header.Filename, f)
+	if err != nil {
+		verr := &backend.ValidationError{}
+		verr.Add("file", "%v", err)
+		respondError(c, verr)
+		return importer.Track{}, false
+	}
+	return track, true
+}
+
+// ImportWorkoutHandler creates a workout from an uploaded activity file.
+// Form fields: file, and optionally activity (overriding the file's sport),
+// mood_in, mood_out, athlete and session_rpe.
+func ImportWorkoutHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		track, ok := uploadedTrack(c)
+		if !ok {
+			return
+		}
+
+		workout := track.Workout(c.PostForm("activity"))
+		workout.MoodIn = c.PostForm("mood_in")
+		workout.MoodOut = c.PostForm("mood_out")
+		workout.Athlete = c.PostForm("athlete")
+		if rpe := c.PostForm("session_rpe"); rpe != "" {
+			v, err := strconv.ParseFloat(rpe, 64)
+			if err != nil {
+				badRequest(c, "invalid session_rpe")
+				return
+			}
+			workout.SessionRPE = v
+		}
+
+		id, err := backend.InsertWorkout(db, workout, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		created, err := backend.GetWorkoutByID(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, created)
+		c.JSON(http.StatusCreated, backend.WorkoutInUnits(created, units))
+	}
+}
+
+// AddCardioHandler appends a cardio activity, sent as JSON, to a workout.
+func AddCardioHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		var cardio models.Cardio
+		if err := c.ShouldBindJSON(&cardio); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+
+		workout, err := backend.AddCardio(db, id, version, cardio, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusCreated, backend.WorkoutInUnits(workout, units))
+	}
+}
+
+// ImportCardioHandler appends the activity in an uploaded file to a workout.
+// An optional activity form field overrides the file's sport.
+func ImportCardioHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		track, ok := uploadedTrack(c)
+		if !ok {
+			return
+		}
+
+		workout, err := backend.AddCardio(db, id, version, track.Cardio(c.PostForm("activity")), requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusCreated, backend.WorkoutInUnits(workout, units))
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fixture reads a sample activity file of the importer package.
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "importer", "testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// uploadRequest builds a multipart request sending data as filename in the
+// file field, unless filename is empty, alongside fields.
+func uploadRequest(t *testing.T, url, filename string, data []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for k, v := range fields {
+		form.WriteField(k, v)
+	}
+	if filename != "" {
+		part, err := form.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, url, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-User", "sam")
+	return req
+}
+
+func TestImportWorkoutHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t)
+	router := gin.New()
+	router.POST("/workouts/import", ImportWorkoutHandler(db))
+	moods := map[string]string{"mood_in": "Good", "mood_out": "Great"}
+
+	tests := []struct {
+		name     string
+		filename string
+		data     []byte
+		fields   map[string]string
+		status   int
+		activity string
+		distance float64
+		duration int
+		splits   int
+	}{
+		{name: "gpx", filename: "run.gpx", data: fixture(t, "run.gpx"), fields: moods,
+			status: http.StatusCreated, activity: models.ActivityRun, distance: 2.432, duration: 760, splits: 3},
+		{name: "fit with activity", filename: "row.fit", data: fixture(t, "row.fit"),
+			fields: map[string]string{"mood_in": "Good", "mood_out": "Good", "activity": models.ActivityBike},
+			status: http.StatusCreated, activity: models.ActivityBike, distance: 2.268, duration: 540, splits: 3},
+		{name: "no file", fields: moods, status: http.StatusBadRequest},
+		{name: "not an activity", filename: "notes.txt", data: []byte("not an activity"), fields: moods, status: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, uploadRequest(t, "/workouts/import", tt.filename, tt.data, tt.fields))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status != http.StatusCreated {
+				return
+			}
+
+			var workout models.Workout
+			if err := json.Unmarshal(w.Body.Bytes(), &workout); err != nil {
+				t.Fatal(err)
+			}
+			if w.Header().Get("ETag") == "" {
+				t.Error("ETag header is missing")
+			}
+			if workout.Athlete != "sam" {
+				t.Errorf("athlete = %q, want the caller", workout.Athlete)
+			}
+			if len(workout.Cardio) != 1 {
+				t.Fatalf("cardio = %+v, want one activity", workout.Cardio)
+			}
+			c := workout.Cardio[0]
+			if c.Activity != tt.activity || c.Distance != tt.distance || c.Duration != tt.duration || len(c.Splits) != tt.splits {
+				t.Errorf("cardio = %s %.3f km in %ds with %d splits, want %s %.3f km in %ds with %d splits",
+					c.Activity, c.Distance, c.Duration, len(c.Splits), tt.activity, tt.distance, tt.duration, tt.splits)
+			}
+		})
+	}
+}
+
+func TestImportWorkoutHandlerReportsFileField(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t)
+	router := gin.New()
+	router.POST("/workouts/import", ImportWorkoutHandler(db))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, uploadRequest(t, "/workouts/import", "empty.gpx", []byte("<gpx></gpx>"), nil))
+
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != http.StatusUnprocessableEntity || len(problem.Errors) != 1 || problem.Errors[0].Field != "file" {
+		t.Errorf("problem = %+v, want a 422 for the file field", problem)
+	}
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tonnage leaderboards are returned in the request's unit.
+
+func groupIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid group ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func challengeIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid challenge ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// ListGroupsHandler returns every group, or those ?member= belongs to.
+func ListGroupsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		groups, err := backend.GetGroups(db, c.Query("member"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, groups)
+	}
+}
+
+func GetGroupHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := groupIDParam(c)
+		if !ok {
+			return
+		}
+
+		group, err := backend.GetGroup(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, group)
+	}
+}
+
+// CreateGroupHandler creates a group. Without any members the caller is its
+// first member.
+func CreateGroupHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var group models.Group
+		if err := c.ShouldBindJSON(&group); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		if len(group.Members) == 0 {
+			group.Members = []string{requestActor(c).Name}
+		}
+
+		id, err := backend.CreateGroup(db, group, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Group created successfully", "id": id})
+	}
+}
+
+// UpdateGroupHandler renames a group and replaces its members.
+func UpdateGroupHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := groupIDParam(c)
+		if !ok {
+			return
+		}
+		var group models.Group
+		if err := c.ShouldBindJSON(&group); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		group.ID = id
+
+		if err := backend.UpdateGroup(db, group, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Group updated successfully"})
+	}
+}
+
+func DeleteGroupHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := groupIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.DeleteGroup(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Group deleted successfully"})
+	}
+}
+
+// JoinGroupHandler adds the caller to a group.
+func JoinGroupHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := groupIDParam(c)
+		if !ok {
+			return
+		}
+
+		actor := requestActor(c)
+		if err := backend.JoinGroup(db, id, actor.Name, actor); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Joined group successfully"})
+	}
+}
+
+// LeaveGroupHandler removes the caller from a group.
+func LeaveGroupHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := groupIDParam(c)
+		if !ok {
+			return
+		}
+
+		actor := requestActor(c)
+		if err := backend.LeaveGroup(db, id, actor.Name, actor); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Left group successfully"})
+	}
+}
+
+// GroupLeaderboardHandler ranks a group's members on ?metric= (with
+// ?exercise= for relative_e1rm) from ?from= to ?to=, this month by default,
+// scored by ?scoring=.
+func GroupLeaderboardHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := groupIDParam(c)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		challenge := models.Challenge{
+			Metric:    c.Query("metric"),
+			Exercise:  c.Query("exercise"),
+			StartDate: c.DefaultQuery("from", first.Format(models.DateLayout)),
+			EndDate:   c.DefaultQuery("to", first.AddDate(0, 1, -1).Format(models.DateLayout)),
+			Scoring:   c.Query("scoring"),
+		}
+		board, err := backend.GetGroupLeaderboard(db, id, challenge)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, leaderboardInUnits(board, units))
+	}
+}
+
+// ListChallengesHandler returns every challenge, or those of ?group=.
+func ListChallengesHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group, err := strconv.Atoi(c.DefaultQuery("group", "0"))
+		if err != nil {
+			badRequest(c, "invalid group ID")
+			return
+		}
+
+		challenges, err := backend.GetChallenges(db, group)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, challenges)
+	}
+}
+
+func GetChallengeHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := challengeIDParam(c)
+		if !ok {
+			return
+		}
+
+		challenge, err := backend.GetChallenge(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, challenge)
+	}
+}
+
+func CreateChallengeHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var challenge models.Challenge
+		if err := c.ShouldBindJSON(&challenge); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+
+		id, err := backend.CreateChallenge(db, challenge, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Challenge created successfully", "id": id})
+	}
+}
+
+func UpdateChallengeHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := challengeIDParam(c)
+		if !ok {
+			return
+		}
+		var challenge models.Challenge
+		if err := c.ShouldBindJSON(&challenge); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		challenge.ID = id
+
+		if err := backend.UpdateChallenge(db, challenge, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Challenge updated successfully"})
+	}
+}
+
+func DeleteChallengeHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := challengeIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.DeleteChallenge(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Challenge deleted successfully"})
+	}
+}
+
+// ChallengeLeaderboardHandler ranks the members of a challenge's group.
+func ChallengeLeaderboardHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := challengeIDParam(c)
+		if !ok {
+			return
+		}
+
+		board, err := backend.GetLeaderboard(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, leaderboardInUnits(board, units))
+	}
+}
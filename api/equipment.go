@@ -0,0 +1,160 @@
+package api
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Equipment profiles are kept in the unit the kit is marked in. Plate
+// loadings are returned in that unit; target and working weights are sent
+// in the request's unit.
+
+// queryWeight reads ?weight=, which must be a positive number.
+func queryWeight(c *gin.Context) (float64, bool) {
+	weight, err := strconv.ParseFloat(c.Query("weight"), 64)
+	if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) || weight <= 0 {
+		badRequest(c, "invalid weight")
+		return 0, false
+	}
+	return weight, true
+}
+
+// GetEquipmentHandler returns the caller's equipment profile.
+func GetEquipmentHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		eq, err := backend.GetEquipment(db, requestActor(c).Name)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, eq)
+	}
+}
+
+// SaveEquipmentHandler replaces the caller's equipment profile.
+func SaveEquipmentHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var eq models.Equipment
+		if err := c.ShouldBindJSON(&eq); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		eq.User = requestActor(c).Name
+
+		if err := backend.SaveEquipment(db, eq, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+		saved, err := backend.GetEquipment(db, eq.User)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, saved)
+	}
+}
+
+// PlateLoadingHandler returns the plates per side for ?weight=, on ?bar= or
+// the caller's first bar.
+func PlateLoadingHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		weight, ok := queryWeight(c)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		eq, err := backend.GetEquipment(db, requestActor(c).Name)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		loading, err := backend.LoadPlates(eq, c.Query("bar"), backend.ConvertWeight(weight, units, eq.Units))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, loading)
+	}
+}
+
+// WarmUpHandler returns a warm-up ramp for ?weight=, with optional
+// ?exercise=, ?implement= (barbell or dumbbell) and ?bar=.
+func WarmUpHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		weight, ok := queryWeight(c)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		eq, err := backend.GetEquipment(db, requestActor(c).Name)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		req := models.WarmUpRequest{Exercise: c.Query("exercise"), Weight: weight, Implement: c.Query("implement"), Bar: c.Query("bar")}
+		ramp, err := backend.WarmUpRamp(eq, req, units)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, ramp)
+	}
+}
+
+// AddWarmUpHandler inserts a warm-up ramp before the first set of an exercise
+// in a workout.
+func AddWarmUpHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		var req models.WarmUpRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		eq, err := backend.GetEquipment(db, requestActor(c).Name)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		workout, err := backend.AddWarmUp(db, id, version, eq, req, units, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusCreated, backend.WorkoutInUnits(workout, units))
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag identifies a specific version of a workout.
+func etag(workout models.Workout) string {
+	return fmt.Sprintf(`"%d-%d"`, workout.ID, workout.Version)
+}
+
+func setETag(c *gin.Context, workout models.Workout) {
+	c.Header("ETag", etag(workout))
+}
+
+// ifMatchVersion returns the workout version the client expects from the
+// If-Match header, or 0 when the header is absent or "*". If the header names
+// a different workout or cannot be parsed, a 412 is written and ok is false.
+func ifMatchVersion(c *gin.Context, workoutID int) (version int, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	// Only a single entity tag makes sense for one workout
+	tag := strings.TrimPrefix(header, "W/")
+	tag = strings.Trim(tag, `"`)
+	idPart, versionPart, found := strings.Cut(tag, "-")
+	if found {
+		id, idErr := strconv.Atoi(idPart)
+		v, vErr := strconv.Atoi(versionPart)
+		if idErr == nil && vErr == nil && id == workoutID && v > 0 {
+			return v, true
+		}
+	}
+
+	writeProblem(c, http.StatusPreconditionFailed, "If-Match does not match the current workout", nil)
+	return 0, false
+}
+
+// workoutIDParam parses the :id path parameter, writing a 400 on failure.
+func workoutIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid workout ID")
+		return 0, false
+	}
+	return id, true
+}
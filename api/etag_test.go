@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETag(t *testing.T) {
+	if got := etag(models.Workout{ID: 12, Version: 3}); got != `"12-3"` {
+		t.Errorf("etag() = %s, want \"12-3\"", got)
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		header  string
+		version int
+		ok      bool
+	}{
+		{"", 0, true},
+		{"*", 0, true},
+		{`"12-3"`, 3, true},
+		{`W/"12-3"`, 3, true},
+		{` "12-3" `, 3, true},
+		{`12-3`, 3, true},
+		{`"13-3"`, 0, false},
+		{`"12-0"`, 0, false},
+		{`"12"`, 0, false},
+		{`"12-x"`, 0, false},
+		{`"12-3", "12-4"`, 0, false},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPatch, "/workouts/12", nil)
+		if tt.header != "" {
+			c.Request.Header.Set("If-Match", tt.header)
+		}
+
+		version, ok := ifMatchVersion(c, 12)
+		if version != tt.version || ok != tt.ok {
+			t.Errorf("If-Match %s: got version %d, %v, want %d, %v", tt.header, version, ok, tt.version, tt.ok)
+		}
+		if !ok && w.Code != http.StatusPreconditionFailed {
+			t.Errorf("If-Match %s: status = %d, want 412", tt.header, w.Code)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryAthlete returns ?athlete=, defaulting to the X-User caller. An empty
+// ?athlete= selects workouts logged before athletes were recorded.
+func queryAthlete(c *gin.Context) string {
+	athlete, ok := c.GetQuery("athlete")
+	if !ok {
+		athlete = requestActor(c).Name
+	}
+	return athlete
+}
+
+func ListExercisesHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		exercises, err := backend.GetExercises(db)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, exercises)
+	}
+}
+
+// SetExerciseHandler sets the loading type of the exercise named in the path.
+func SetExerciseHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var exercise models.Exercise
+		if err := c.ShouldBindJSON(&exercise); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		exercise.Name = c.Param("name")
+
+		if err := backend.SetExercise(db, exercise, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Exercise updated successfully"})
+	}
+}
+
+// GetRecordsHandler returns the personal records of ?athlete=, using
+// effective load for bodyweight exercises. ?sex= adds Wilks and DOTS scores.
+func GetRecordsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		records, err := backend.GetPersonalRecords(db, queryAthlete(c), c.Query("sex"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, recordsInUnits(records, units))
+	}
+}
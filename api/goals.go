@@ -0,0 +1,140 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Targets, baselines and trends of weight based goals are sent and returned
+// in the request's unit. Frequency goals count sessions per week.
+
+func goalIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid goal ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// bindGoal reads a goal from the body in units, defaulting the athlete to
+// the caller.
+func bindGoal(c *gin.Context, units string) (models.Goal, bool) {
+	var goal models.Goal
+	if err := c.ShouldBindJSON(&goal); err != nil {
+		badRequest(c, err.Error())
+		return models.Goal{}, false
+	}
+	if goal.Athlete == "" {
+		goal.Athlete = requestActor(c).Name
+	}
+	return goalInUnits(goal, units, models.UnitKilogram), true
+}
+
+// ListGoalsHandler reports the progress of every goal of ?athlete=.
+func ListGoalsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		progress, err := backend.GetGoalsProgress(db, queryAthlete(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, progressInUnits(progress, units))
+	}
+}
+
+// GetGoalHandler reports the progress of one goal.
+func GetGoalHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := goalIDParam(c)
+		if !ok {
+			return
+		}
+
+		progress, err := backend.GetGoalProgress(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, progressInUnits([]models.GoalProgress{progress}, units)[0])
+	}
+}
+
+func CreateGoalHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		goal, ok := bindGoal(c, units)
+		if !ok {
+			return
+		}
+
+		id, err := backend.CreateGoal(db, goal, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Goal created successfully", "id": id})
+	}
+}
+
+func UpdateGoalHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := goalIDParam(c)
+		if !ok {
+			return
+		}
+		goal, ok := bindGoal(c, units)
+		if !ok {
+			return
+		}
+		goal.ID = id
+
+		if err := backend.UpdateGoal(db, goal, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Goal updated successfully"})
+	}
+}
+
+func DeleteGoalHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := goalIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.DeleteGoal(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Goal deleted successfully"})
+	}
+}
@@ -3,7 +3,10 @@ package api
 import (
 	"net/http"
 	"database/sql"
+	"encoding/json"
+	"slices"
 	"strconv"
+	"strings"
 
 	"fitness-dev/backend"
 	"fitness-dev/models"
@@ -15,49 +18,99 @@ func CreateWorkoutHandler(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var workout models.Workout
 		if err := c.ShouldBindJSON(&workout); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			badRequest(c, err.Error())
 			return
 		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		if workout.Units == "" {
+			workout.Units = units
+		}
 
-		if err := backend.InsertWorkout(db, workout); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		id, err := backend.InsertWorkout(db, workout, requestActor(c))
+		if err != nil {
+			respondError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Workout created successfully"})
+		response := gin.H{"message": "Workout created successfully", "id": id}
+		// The workout is saved either way; badges are left out if the rules cannot be read
+		if badges, err := backend.GetWorkoutBadges(db, id); err == nil {
+			response["badges"] = badgesInUnits(badges, units)
+		}
+		c.JSON(http.StatusOK, response)
 	}
 }
 
+// GetWorkoutByDayHandler returns the workout on :day, or the workout with
+// that ID when :day is a number. ?format=md or ?format=text renders it.
 func GetWorkoutByDayHandler(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
 		day := c.Param("day")
-		workout, err := backend.GetWorkoutByDay(db, day)
+		var workout models.Workout
+		var err error
+		if id, convErr := strconv.Atoi(day); convErr == nil {
+			workout, err = backend.GetWorkoutByID(db, id)
+		} else {
+			workout, err = backend.GetWorkoutByDay(db, day)
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, workout)
+		setETag(c, workout)
+		if respondRendered(c, db, []models.Workout{workout}, units) {
+			return
+		}
+		c.JSON(http.StatusOK, backend.WorkoutInUnits(workout, units))
 	}
 }
 
+// GetWorkoutsByDateRangeHandler returns the workouts from ?startDate= to
+// ?endDate=, planned ones included. ?status= keeps only workouts with that status.
+// Dates that do not parse, or an end before the start, return 422.
 func GetWorkoutsByDateRangeHandler(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startDate := c.Query("startDate")
 		endDate := c.Query("endDate")
 
 		if startDate == "" || endDate == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "startDate and endDate are required"})
+			badRequest(c, "startDate and endDate are required")
+			return
+		}
+		status := c.Query("status")
+		if status != "" && !slices.Contains(models.Statuses, status) {
+			verr := &backend.ValidationError{}
+			verr.Add("status", "must be one of %s", strings.Join(models.Statuses, ", "))
+			respondError(c, verr)
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
 			return
 		}
 
 		workouts, err := backend.GetWorkoutsByDateRange(db, startDate, endDate)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondError(c, err)
 			return
 		}
+		if status != "" {
+			workouts = slices.DeleteFunc(workouts, func(w models.Workout) bool { return w.Status != status })
+		}
 
-		c.JSON(http.StatusOK, workouts)
+		if respondRendered(c, db, workouts, units) {
+			return
+		}
+		c.JSON(http.StatusOK, workoutsInUnits(workouts, units))
 	}
 }
 
@@ -68,40 +121,96 @@ func UpdateWorkoutHandler(db *sql.DB) gin.HandlerFunc {
 		idStr := c.Param("id")
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workout ID"})
+			badRequest(c, "invalid workout ID")
 			return
 		}
 
 		var workout models.Workout
 		if err := c.ShouldBindJSON(&workout); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			badRequest(c, err.Error())
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		if workout.Units == "" {
+			workout.Units = units
+		}
+
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
 			return
 		}
+		if version != 0 {
+			workout.Version = version
+		}
 
 		workout.ID = id
-		if err := backend.UpdateWorkout(db, workout); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := backend.UpdateWorkout(db, workout, requestActor(c)); err != nil {
+			respondError(c, err)
 			return
 		}
 
+		if updated, err := backend.GetWorkoutByID(db, id); err == nil {
+			setETag(c, updated)
+		}
 		c.JSON(http.StatusOK, gin.H{"message": "Workout updated successfully"})
 	}
 }
 
+// PatchWorkoutHandler applies a JSON Merge Patch (application/merge-patch+json)
+// to a workout and returns the updated workout.
+func PatchWorkoutHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		patch, err := c.GetRawData()
+		if err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		if !json.Valid(patch) {
+			badRequest(c, "request body must be valid JSON")
+			return
+		}
+
+		workout, err := backend.PatchWorkout(db, id, version, patch, units, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusOK, backend.WorkoutInUnits(workout, units))
+	}
+}
+
 func DeleteWorkoutHandler(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		idStr := c.Param("id")
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workout ID"})
+			badRequest(c, "invalid workout ID")
 			return
 		}
 
-		if err := backend.DeleteWorkout(db, id); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := backend.DeleteWorkout(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Workout deleted successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "Workout moved to trash"})
 	}
 }
\ No newline at end of file
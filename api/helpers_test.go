@@ -0,0 +1,21 @@
+package api
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"fitness-dev/backend"
+)
+
+// newTestDB opens a fresh database in a temporary directory, closed when the
+// test ends.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := backend.DbInitAt(filepath.Join(t.TempDir(), "fitness.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
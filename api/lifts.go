@@ -0,0 +1,164 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Lifts are addressed by their zero-based position within the workout,
+// matching the indexes of the lifts/weight/reps/sets arrays. Weights are sent
+// and returned in the request's unit.
+
+func liftIndexParam(c *gin.Context) (int, bool) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 {
+		badRequest(c, "invalid lift index")
+		return 0, false
+	}
+	return index, true
+}
+
+func AddLiftHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		var lift models.Lift
+		if err := c.ShouldBindJSON(&lift); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+
+		workout, err := backend.AddLift(db, id, version, lift, units, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusCreated, backend.WorkoutInUnits(workout, units))
+	}
+}
+
+func ReplaceLiftHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		index, ok := liftIndexParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		var lift models.Lift
+		if err := c.ShouldBindJSON(&lift); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+
+		workout, err := backend.ReplaceLift(db, id, version, index, lift, units, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusOK, backend.WorkoutInUnits(workout, units))
+	}
+}
+
+func PatchLiftHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		index, ok := liftIndexParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		patch, err := c.GetRawData()
+		if err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		if !json.Valid(patch) {
+			badRequest(c, "request body must be valid JSON")
+			return
+		}
+
+		workout, err := backend.PatchLift(db, id, version, index, patch, units, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusOK, backend.WorkoutInUnits(workout, units))
+	}
+}
+
+func DeleteLiftHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		index, ok := liftIndexParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		workout, err := backend.DeleteLift(db, id, version, index, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusOK, backend.WorkoutInUnits(workout, units))
+	}
+}
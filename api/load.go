@@ -0,0 +1,39 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrainingLoadHandler returns the daily training load time series of
+// ?athlete= (default: the X-User caller) with rolling ACWR, monotony and
+// strain. ?method= is tonnage (default) or srpe; ?from= and ?to= default to
+// the last 28 days.
+func GetTrainingLoadHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		athlete := queryAthlete(c)
+		today := time.Now()
+		from := strings.ReplaceAll(c.DefaultQuery("from", today.AddDate(0, 0, -27).Format(models.DateLayout)), "-", "/")
+		to := strings.ReplaceAll(c.DefaultQuery("to", today.Format(models.DateLayout)), "-", "/")
+
+		method := c.DefaultQuery("method", models.LoadTonnage)
+		series, err := backend.GetTrainingLoad(db, athlete, method, from, to)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, loadInUnits(series, method, units))
+	}
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func measurementIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid measurement ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// bindMeasurement reads a measurement from the body, defaulting the athlete
+// to the caller and the date to today.
+func bindMeasurement(c *gin.Context) (models.Measurement, bool) {
+	var m models.Measurement
+	if err := c.ShouldBindJSON(&m); err != nil {
+		badRequest(c, err.Error())
+		return models.Measurement{}, false
+	}
+	if m.Athlete == "" {
+		m.Athlete = requestActor(c).Name
+	}
+	if m.Date == "" {
+		m.Date = time.Now().Format(models.DateLayout)
+	}
+	return m, true
+}
+
+// ListMeasurementsHandler returns the measurements of ?athlete=, optionally
+// narrowed by ?metric=, ?from= and ?to=.
+func ListMeasurementsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		measurements, err := backend.GetMeasurements(db, queryAthlete(c), c.Query("metric"), c.Query("from"), c.Query("to"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, measurements)
+	}
+}
+
+func LogMeasurementHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m, ok := bindMeasurement(c)
+		if !ok {
+			return
+		}
+
+		id, err := backend.LogMeasurement(db, m, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Measurement logged successfully", "id": id})
+	}
+}
+
+func GetMeasurementHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := measurementIDParam(c)
+		if !ok {
+			return
+		}
+
+		m, err := backend.GetMeasurement(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, m)
+	}
+}
+
+func UpdateMeasurementHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := measurementIDParam(c)
+		if !ok {
+			return
+		}
+		m, ok := bindMeasurement(c)
+		if !ok {
+			return
+		}
+		m.ID = id
+
+		if err := backend.UpdateMeasurement(db, m, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Measurement updated successfully"})
+	}
+}
+
+func DeleteMeasurementHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := measurementIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.DeleteMeasurement(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Measurement deleted successfully"})
+	}
+}
+
+// MeasurementTrendHandler returns one metric of ?athlete= with its
+// exponential moving average. ?metric= defaults to bodyweight and ?alpha= to
+// backend.DefaultTrendAlpha.
+func MeasurementTrendHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alpha, err := strconv.ParseFloat(c.DefaultQuery("alpha", fmt.Sprint(backend.DefaultTrendAlpha)), 64)
+		if err != nil || math.IsNaN(alpha) || math.IsInf(alpha, 0) {
+			badRequest(c, "invalid alpha")
+			return
+		}
+
+		series, err := backend.MeasurementTrend(db, queryAthlete(c), c.DefaultQuery("metric", models.MetricBodyweight), alpha)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, series)
+	}
+}
+
+// ListBodyweightHandler returns the bodyweight history of ?athlete=, a
+// shortcut for the bodyweight metric of /measurements.
+func ListBodyweightHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, err := backend.GetMeasurements(db, queryAthlete(c), models.MetricBodyweight, c.Query("from"), c.Query("to"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+// LogBodyweightHandler records a bodyweight, in kg unless the body gives
+// another unit.
+func LogBodyweightHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m, ok := bindMeasurement(c)
+		if !ok {
+			return
+		}
+		m.Metric = models.MetricBodyweight
+
+		id, err := backend.LogMeasurement(db, m, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Bodyweight logged successfully", "id": id})
+	}
+}
+
+// DeleteBodyweightHandler deletes a bodyweight entry; other measurements are
+// reported as not found.
+func DeleteBodyweightHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := measurementIDParam(c)
+		if !ok {
+			return
+		}
+
+		m, err := backend.GetMeasurement(db, id)
+		if err == nil && m.Metric != models.MetricBodyweight {
+			err = &backend.NotFoundError{Resource: "bodyweight", Key: fmt.Sprint(id)}
+		}
+		if err == nil {
+			err = backend.DeleteMeasurement(db, id, requestActor(c))
+		}
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Bodyweight deleted successfully"})
+	}
+}
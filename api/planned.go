@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/ical"
+	"fitness-dev/models"
+	"fitness-dev/render"
+
+	"github.com/gin-gonic/gin"
+)
+
+func plannedIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid planned session ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// ListPlannedHandler returns the planned sessions of ?athlete=, optionally
+// from ?from= to ?to=. ?pending=true leaves out completed sessions.
+func ListPlannedHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pending := c.Query("pending") == "true"
+		sessions, err := backend.GetPlannedSessions(db, queryAthlete(c), c.Query("from"), c.Query("to"), pending)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+
+// CreatePlannedHandler schedules a session for the caller, or for the
+// athlete in the body. A program_id plans the program's next workout.
+func CreatePlannedHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var p models.PlannedSession
+		if err := c.ShouldBindJSON(&p); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		if p.Athlete == "" {
+			p.Athlete = requestActor(c).Name
+		}
+
+		id, err := backend.CreatePlannedSession(db, p, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Planned session created successfully", "id": id})
+	}
+}
+
+func GetPlannedHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := plannedIDParam(c)
+		if !ok {
+			return
+		}
+
+		p, err := backend.GetPlannedSession(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+func DeletePlannedHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := plannedIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.DeletePlannedSession(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Planned session deleted successfully"})
+	}
+}
+
+// CompletePlannedHandler links the workout in the body's workout_id to a
+// planned session.
+func CompletePlannedHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := plannedIDParam(c)
+		if !ok {
+			return
+		}
+		var req struct {
+			WorkoutID int `json:"workout_id" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+
+		p, err := backend.CompletePlannedSession(db, id, req.WorkoutID, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, p)
+	}
+}
+
+// StartPlannedHandler starts a planned session's workout now.
+func StartPlannedHandler(db *sql.DB) gin.HandlerFunc {
+	return plannedWorkoutHandler(db, backend.StartPlannedSession)
+}
+
+// SkipPlannedHandler skips a planned session's workout.
+func SkipPlannedHandler(db *sql.DB) gin.HandlerFunc {
+	return plannedWorkoutHandler(db, backend.SkipPlannedSession)
+}
+
+func plannedWorkoutHandler(db *sql.DB, advance func(db *sql.DB, id int, actor backend.Actor) (models.Workout, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := plannedIDParam(c)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		workout, err := advance(db, id, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusOK, backend.WorkoutInUnits(workout, units))
+	}
+}
+
+// ImportCalendarHandler creates planned sessions from the events of an
+// uploaded iCalendar file. Form fields: file, and optionally athlete.
+func ImportCalendarHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header, err := c.FormFile("file")
+		if err != nil {
+			badRequest(c, "an iCalendar file is required in the file field")
+			return
+		}
+		f, err := header.Open()
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		events, err := ical.Parse(f)
+		f.Close()
+		if err != nil {
+			verr := &backend.ValidationError{}
+			verr.Add("file", "%v", err)
+			respondError(c, verr)
+			return
+		}
+
+		athlete := c.PostForm("athlete")
+		if athlete == "" {
+			athlete = requestActor(c).Name
+		}
+		sessions, skipped := ical.PlannedSessions(events, athlete)
+		result, err := backend.ImportPlannedSessions(db, sessions, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		result.Skipped += skipped
+
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// CalendarFeedHandler serves a user's workouts and planned sessions as an
+// iCalendar feed for calendar apps to subscribe to. Weights are in ?units=
+// or the user's saved unit.
+func CalendarFeedHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := c.Param("user")
+		units, err := backend.ValidateUnits(c.Query("units"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		settings, err := backend.GetSettings(db, user)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		workouts, err := backend.GetAthleteWorkouts(db, user)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		planned, err := backend.GetPlannedSessions(db, user, "", "", true)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		records, err := backend.RecordLifts(db, workouts)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		var body bytes.Buffer
+		opts := render.Options{Units: backend.InUnits(settings, units).Units, Records: records}
+		if err := ical.Write(&body, ical.Feed(user, workouts, planned, opts)); err != nil {
+			respondError(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "text/calendar; charset=utf-8", body.Bytes())
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"fitness-dev/backend"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type     string               `json:"type"`
+	Title    string               `json:"title"`
+	Status   int                  `json:"status"`
+	Detail   string               `json:"detail,omitempty"`
+	Instance string               `json:"instance,omitempty"`
+	Errors   []backend.FieldError `json:"errors,omitempty"`
+}
+
+func writeProblem(c *gin.Context, status int, detail string, fields []backend.FieldError) {
+	c.Header("Content-Type", "application/problem+json")
+	c.AbortWithStatusJSON(status, Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Errors:   fields,
+	})
+}
+
+// badRequest responds with 400 for malformed requests (bad JSON, bad path params).
+func badRequest(c *gin.Context, detail string) {
+	writeProblem(c, http.StatusBadRequest, detail, nil)
+}
+
+// respondError maps backend errors onto problem responses. Unexpected errors
+// are logged and answered with a generic 500 so internals do not leak.
+func respondError(c *gin.Context, err error) {
+	var notFound *backend.NotFoundError
+	var validation *backend.ValidationError
+	var conflict *backend.ConflictError
+	var stale *backend.PreconditionFailedError
+
+	switch {
+	case errors.As(err, &notFound):
+		writeProblem(c, http.StatusNotFound, err.Error(), nil)
+	case errors.As(err, &validation):
+		writeProblem(c, http.StatusUnprocessableEntity, "one or more fields are invalid", validation.Fields)
+	case errors.As(err, &conflict):
+		writeProblem(c, http.StatusConflict, err.Error(), nil)
+	case errors.As(err, &stale):
+		writeProblem(c, http.StatusPreconditionFailed, err.Error(), nil)
+	default:
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		writeProblem(c, http.StatusInternalServerError, "an internal error occurred", nil)
+	}
+}
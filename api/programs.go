@@ -0,0 +1,149 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func programIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid program ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func ListProgramsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		programs, err := backend.GetPrograms(db)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		for i := range programs {
+			programs[i] = programInUnits(programs[i], models.UnitKilogram, units)
+		}
+		c.JSON(http.StatusOK, programs)
+	}
+}
+
+func GetProgramHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := programIDParam(c)
+		if !ok {
+			return
+		}
+
+		program, err := backend.GetProgram(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, programInUnits(program, models.UnitKilogram, units))
+	}
+}
+
+func CreateProgramHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		var program models.Program
+		if err := c.ShouldBindJSON(&program); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+
+		id, err := backend.CreateProgram(db, programInUnits(program, units, models.UnitKilogram), requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Program created successfully", "id": id})
+	}
+}
+
+func ActivateProgramHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := programIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.ActivateProgram(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Program activated successfully"})
+	}
+}
+
+func DeleteProgramHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := programIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.DeleteProgram(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Program deleted successfully"})
+	}
+}
+
+// NextWorkoutHandler returns the next prescribed workout of ?athlete= (by
+// default the caller) in the active program, or in ?program_id= when given.
+// The workout is an unsaved draft with weights rounded to the caller's
+// plates, and so are the weights in the notes.
+func NextWorkoutHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings, ok := requestSettings(c, db)
+		if !ok {
+			return
+		}
+		var (
+			prescription models.Prescription
+			err          error
+		)
+		if param := c.Query("program_id"); param != "" {
+			id, convErr := strconv.Atoi(param)
+			if convErr != nil {
+				badRequest(c, "invalid program_id")
+				return
+			}
+			prescription, err = backend.NextWorkout(db, id, queryAthlete(c), settings)
+		} else {
+			prescription, err = backend.NextActiveWorkout(db, queryAthlete(c), settings)
+		}
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		prescription.Workout = backend.DraftInUnits(prescription.Workout, settings)
+		c.JSON(http.StatusOK, prescription)
+	}
+}
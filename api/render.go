@@ -0,0 +1,54 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"net/http"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+	"fitness-dev/render"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Workout response formats, chosen with ?format=.
+const (
+	formatJSON     = "json"
+	formatMarkdown = "md"
+	formatText     = "text"
+)
+
+// respondRendered writes workouts (in kg) as Markdown or plain text when
+// ?format= asks for it, converting weights to units and marking personal
+// records. It returns false, writing nothing, for JSON; a bad format is a 400.
+func respondRendered(c *gin.Context, db *sql.DB, workouts []models.Workout, units string) bool {
+	format := c.DefaultQuery("format", formatJSON)
+	var write func(io.Writer, []models.Workout, render.Options) error
+	var contentType string
+	switch format {
+	case formatJSON:
+		return false
+	case formatMarkdown:
+		write, contentType = render.Markdown, "text/markdown; charset=utf-8"
+	case formatText:
+		write, contentType = render.Text, "text/plain; charset=utf-8"
+	default:
+		badRequest(c, "format must be json, md or text")
+		return true
+	}
+
+	records, err := backend.RecordLifts(db, workouts)
+	if err != nil {
+		respondError(c, err)
+		return true
+	}
+	var body bytes.Buffer
+	if err := write(&body, workouts, render.Options{Units: units, Records: records}); err != nil {
+		respondError(c, err)
+		return true
+	}
+	c.Data(http.StatusOK, contentType, body.Bytes())
+	return true
+}
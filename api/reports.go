@@ -0,0 +1,61 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"fitness-dev/backend"
+	"fitness-dev/render"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Report formats, chosen with ?format=.
+const (
+	formatHTML = "html"
+	formatPDF  = "pdf"
+)
+
+// MonthlyReportHandler renders the monthly report of ?athlete= for ?month=
+// (MM/YYYY, this month by default) as HTML, or as a PDF with ?format=pdf.
+// Weights are in the request's unit.
+func MonthlyReportHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		format := c.DefaultQuery("format", formatHTML)
+		if format != formatHTML && format != formatPDF {
+			badRequest(c, "format must be html or pdf")
+			return
+		}
+
+		report, err := backend.GetMonthlyReport(db, queryAthlete(c), c.Query("month"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		var body bytes.Buffer
+		opts := render.Options{Units: units}
+		if format == formatPDF {
+			err = render.ReportPDF(&body, report, opts)
+		} else {
+			err = render.ReportHTML(&body, report, opts)
+		}
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		if format == formatPDF {
+			c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, render.ReportFilename(report, formatPDF)))
+			c.Data(http.StatusOK, "application/pdf", body.Bytes())
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+	}
+}
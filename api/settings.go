@@ -0,0 +1,48 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSettingsHandler returns the caller's preferences.
+func GetSettingsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings, err := backend.GetSettings(db, requestActor(c).Name)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, settings)
+	}
+}
+
+// SaveSettingsHandler replaces the caller's preferences.
+func SaveSettingsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var settings models.Settings
+		if err := c.ShouldBindJSON(&settings); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		settings.User = requestActor(c).Name
+
+		if err := backend.SaveSettings(db, settings, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+		saved, err := backend.GetSettings(db, settings.User)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, saved)
+	}
+}
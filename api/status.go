@@ -0,0 +1,50 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Planned workouts are completed by sending their actual numbers and
+// "status": "completed" with PATCH /workouts/:id.
+
+// StartWorkoutHandler starts a planned workout now.
+func StartWorkoutHandler(db *sql.DB) gin.HandlerFunc {
+	return changeStatusHandler(db, backend.StartWorkout)
+}
+
+// SkipWorkoutHandler marks a planned or started workout as skipped.
+func SkipWorkoutHandler(db *sql.DB) gin.HandlerFunc {
+	return changeStatusHandler(db, backend.SkipWorkout)
+}
+
+func changeStatusHandler(db *sql.DB, change func(db *sql.DB, workoutID, version int, actor backend.Actor) (models.Workout, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+		version, ok := ifMatchVersion(c, id)
+		if !ok {
+			return
+		}
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+
+		workout, err := change(db, id, version, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		setETag(c, workout)
+		c.JSON(http.StatusOK, backend.WorkoutInUnits(workout, units))
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func templateIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid template ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func ListTemplatesHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		templates, err := backend.GetTemplates(db)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		for i := range templates {
+			templates[i] = templateInUnits(templates[i], models.UnitKilogram, units)
+		}
+		c.JSON(http.StatusOK, templates)
+	}
+}
+
+func GetTemplateHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		id, ok := templateIDParam(c)
+		if !ok {
+			return
+		}
+
+		template, err := backend.GetTemplate(db, id)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, templateInUnits(template, models.UnitKilogram, units))
+	}
+}
+
+func CreateTemplateHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		var template models.Template
+		if err := c.ShouldBindJSON(&template); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+
+		id, err := backend.CreateTemplate(db, templateInUnits(template, units, models.UnitKilogram), requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Template created successfully", "id": id})
+	}
+}
+
+// CreateTemplateFromWorkoutHandler saves an existing workout's lifts as a
+// template. The body carries the template name: {"name": "Push Day"}.
+func CreateTemplateFromWorkoutHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workoutID, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+
+		id, err := backend.CreateTemplateFromWorkout(db, workoutID, body.Name, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Template created successfully", "id": id})
+	}
+}
+
+func DeleteTemplateHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := templateIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.DeleteTemplate(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+	}
+}
+
+// StartFromTemplateHandler returns an unsaved workout built from a template.
+// Weights are pre-filled from the last performance unless ?prefill=false, and
+// rounded to what the caller's plates can load.
+func StartFromTemplateHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings, ok := requestSettings(c, db)
+		if !ok {
+			return
+		}
+		id, ok := templateIDParam(c)
+		if !ok {
+			return
+		}
+		prefill, err := strconv.ParseBool(c.DefaultQuery("prefill", "true"))
+		if err != nil {
+			badRequest(c, "invalid prefill")
+			return
+		}
+
+		draft, err := backend.WorkoutFromTemplate(db, id, prefill)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, backend.DraftInUnits(draft, settings))
+	}
+}
+
+// RepeatLastSessionHandler returns an unsaved copy of the most recent workout
+// containing ?exercise=, its weights rounded to the caller's plates.
+func RepeatLastSessionHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		settings, ok := requestSettings(c, db)
+		if !ok {
+			return
+		}
+		exercise := c.Query("exercise")
+		if exercise == "" {
+			badRequest(c, "exercise is required")
+			return
+		}
+
+		draft, err := backend.RepeatLastSession(db, exercise)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, backend.DraftInUnits(draft, settings))
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"fitness-dev/backend"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ListTrashHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		units, ok := requestUnits(c, db)
+		if !ok {
+			return
+		}
+		workouts, err := backend.ListTrash(db)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, workoutsInUnits(workouts, units))
+	}
+}
+
+func RestoreWorkoutHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.RestoreWorkout(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Workout restored successfully"})
+	}
+}
+
+func PurgeWorkoutHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := workoutIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := backend.PurgeWorkout(db, id, requestActor(c)); err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Workout permanently deleted"})
+	}
+}
+
+func EmptyTrashHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n, err := backend.EmptyTrash(db, requestActor(c))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Trash emptied", "purged": n})
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"database/sql"
+	"math"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Weights are stored in kg. Each request works in ?units= (kg or lb),
+// falling back to the caller's saved preference and then to kg.
+
+// requestSettings returns the caller's settings with Units set to the unit
+// this request works in. On failure a problem response is written and ok is
+// false.
+func requestSettings(c *gin.Context, db *sql.DB) (s models.Settings, ok bool) {
+	units, err := backend.ValidateUnits(c.Query("units"))
+	if err != nil {
+		respondError(c, err)
+		return models.Settings{}, false
+	}
+	s, err = backend.GetSettings(db, requestActor(c).Name)
+	if err != nil {
+		respondError(c, err)
+		return models.Settings{}, false
+	}
+	return backend.InUnits(s, units), true
+}
+
+// requestUnits is requestSettings for handlers that only need the unit.
+func requestUnits(c *gin.Context, db *sql.DB) (string, bool) {
+	s, ok := requestSettings(c, db)
+	return s.Units, ok
+}
+
+func workoutsInUnits(workouts []models.Workout, units string) []models.Workout {
+	if workouts == nil {
+		return nil
+	}
+	converted := make([]models.Workout, len(workouts))
+	for i, w := range workouts {
+		converted[i] = backend.WorkoutInUnits(w, units)
+	}
+	return converted
+}
+
+// convert converts a weight between units. Values going out are rounded to
+// two decimals and values coming in to the gram.
+func convert(weight float64, from, to string) float64 {
+	if from == to {
+		return weight
+	}
+	w := backend.ConvertWeight(weight, from, to)
+	if to == models.UnitKilogram {
+		return math.Round(w*1000) / 1000
+	}
+	return math.Round(w*100) / 100
+}
+
+func templateInUnits(t models.Template, from, to string) models.Template {
+	exercises := make([]models.TemplateExercise, len(t.Exercises))
+	for i, e := range t.Exercises {
+		e.Weight = convert(e.Weight, from, to)
+		exercises[i] = e
+	}
+	t.Exercises = exercises
+	return t
+}
+
+func programInUnits(p models.Program, from, to string) models.Program {
+	rules := make([]models.ProgressionRule, len(p.Rules))
+	for i, r := range p.Rules {
+		r.Increment = convert(r.Increment, from, to)
+		r.TrainingMax = convert(r.TrainingMax, from, to)
+		r.StartWeight = convert(r.StartWeight, from, to)
+		rules[i] = r
+	}
+	p.Rules = rules
+	return p
+}
+
+func blockInUnits(b models.Block, from, to string) models.Block {
+	b.TargetTonnage = convert(b.TargetTonnage, from, to)
+	return b
+}
+
+func weeksInUnits(weeks []models.WeekStats, units string) []models.WeekStats {
+	converted := make([]models.WeekStats, len(weeks))
+	for i, w := range weeks {
+		w.Tonnage = convert(w.Tonnage, models.UnitKilogram, units)
+		converted[i] = w
+	}
+	return converted
+}
+
+func analysisInUnits(a models.BlockAnalysis, units string) models.BlockAnalysis {
+	a.Block = blockInUnits(a.Block, models.UnitKilogram, units)
+	a.Weeks = weeksInUnits(a.Weeks, units)
+	return a
+}
+
+// recordsInUnits converts lifted weights; bodyweight, relative strength and
+// the Wilks and DOTS scores stay in kg, as the formulas are defined in kg.
+func recordsInUnits(records []models.PersonalRecord, units string) []models.PersonalRecord {
+	converted := make([]models.PersonalRecord, len(records))
+	for i, r := range records {
+		r.Weight = convert(r.Weight, models.UnitKilogram, units)
+		r.E1RM = convert(r.E1RM, models.UnitKilogram, units)
+		converted[i] = r
+	}
+	return converted
+}
+
+// loadInUnits converts a tonnage load series; srpe loads have no unit.
+func loadInUnits(days []models.LoadDay, method, units string) []models.LoadDay {
+	if method != models.LoadTonnage {
+		return days
+	}
+	converted := make([]models.LoadDay, len(days))
+	for i, d := range days {
+		d.Load = convert(d.Load, models.UnitKilogram, units)
+		d.Acute = convert(d.Acute, models.UnitKilogram, units)
+		d.Chronic = convert(d.Chronic, models.UnitKilogram, units)
+		d.Strain = convert(d.Strain, models.UnitKilogram, units)
+		converted[i] = d
+	}
+	return converted
+}
+
+// goalInUnits converts the target of weight based goals; frequency goals
+// count sessions.
+func goalInUnits(g models.Goal, from, to string) models.Goal {
+	if g.Kind != models.GoalKindFrequency {
+		g.Target = convert(g.Target, from, to)
+	}
+	return g
+}
+
+func progressInUnits(progress []models.GoalProgress, units string) []models.GoalProgress {
+	converted := make([]models.GoalProgress, len(progress))
+	for i, p := range progress {
+		if p.Goal.Kind != models.GoalKindFrequency {
+			p.Baseline = convert(p.Baseline, models.UnitKilogram, units)
+			p.Current = convert(p.Current, models.UnitKilogram, units)
+			p.Trend = convert(p.Trend, models.UnitKilogram, units)
+		}
+		p.Goal = goalInUnits(p.Goal, models.UnitKilogram, units)
+		converted[i] = p
+	}
+	return converted
+}
+
+// badgesInUnits converts the thresholds of weight badges and fills in the
+// descriptions; the other events count sessions, days or weeks.
+func badgesInUnits(badges []models.Badge, units string) []models.Badge {
+	converted := make([]models.Badge, len(badges))
+	for i, b := range badges {
+		converted[i] = backend.BadgeInUnits(b, units)
+	}
+	return converted
+}
+
+// leaderboardInUnits converts tonnage leaderboards; sessions are counted and
+// relative e1RMs are per kg of bodyweight, and percent improvements have no unit.
+func leaderboardInUnits(board models.Leaderboard, units string) models.Leaderboard {
+	if board.Challenge.Metric != models.ChallengeTonnage {
+		return board
+	}
+	entries := make([]models.LeaderboardEntry, len(board.Entries))
+	for i, e := range board.Entries {
+		e.Value = convert(e.Value, models.UnitKilogram, units)
+		e.Baseline = convert(e.Baseline, models.UnitKilogram, units)
+		if board.Challenge.Scoring == models.ScoringTotal {
+			e.Score = e.Value
+		}
+		entries[i] = e
+	}
+	board.Entries = entries
+	return board
+}
+
+// adherenceInUnits converts the tonnages of an adherence report.
+func adherenceInUnits(a models.Adherence, units string) models.Adherence {
+	a.TargetTonnage = convert(a.TargetTonnage, models.UnitKilogram, units)
+	a.ActualTonnage = convert(a.ActualTonnage, models.UnitKilogram, units)
+	exercises := make([]models.ExerciseAdherence, len(a.Exercises))
+	for i, e := range a.Exercises {
+		e.TargetTonnage = convert(e.TargetTonnage, models.UnitKilogram, units)
+		e.ActualTonnage = convert(e.ActualTonnage, models.UnitKilogram, units)
+		exercises[i] = e
+	}
+	a.Exercises = exercises
+	sessions := make([]models.AdherenceSession, len(a.Sessions))
+	for i, s := range a.Sessions {
+		s.TargetTonnage = convert(s.TargetTonnage, models.UnitKilogram, units)
+		s.ActualTonnage = convert(s.ActualTonnage, models.UnitKilogram, units)
+		sessions[i] = s
+	}
+	a.Sessions = sessions
+	return a
+}
@@ -0,0 +1,276 @@
+package backend
+
+import (
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// defaultAchievementRules are the badges awarded unless FITNESS_ACHIEVEMENTS
+// names another rule file.
+//
+//go:embed achievements.json
+var defaultAchievementRules []byte
+
+// AchievementRules reads the achievement rules from the JSON file named by
+// FITNESS_ACHIEVEMENTS, falling back to the built-in rules. The file is read
+// on every call, so badges can be added without a restart.
+func AchievementRules() ([]models.AchievementRule, error) {
+	data := defaultAchievementRules
+	if path := os.Getenv("FITNESS_ACHIEVEMENTS"); path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read achievement rules: %v", err)
+		}
+	}
+
+	var rules []models.AchievementRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse achievement rules: %v", err)
+	}
+	seen := map[string]bool{}
+	for i, rule := range rules {
+		switch {
+		case rule.ID == "" || rule.Name == "":
+			return nil, fmt.Errorf("achievement rule %d needs an id and a name", i+1)
+		case seen[rule.ID]:
+			return nil, fmt.Errorf("achievement rule %q is defined twice", rule.ID)
+		case !slices.Contains(models.AchievementEvents, rule.Event):
+			return nil, fmt.Errorf("achievement rule %q has unknown event %q", rule.ID, rule.Event)
+		case rule.Threshold < 0:
+			return nil, fmt.Errorf("achievement rule %q has a negative threshold", rule.ID)
+		}
+		seen[rule.ID] = true
+	}
+	return rules, nil
+}
+
+// matches reports whether an event earns the rule's badge.
+func matches(rule models.AchievementRule, event models.AchievementEvent) bool {
+	if rule.Event != event.Type || event.Value < rule.Threshold {
+		return false
+	}
+	return rule.Exercise == "" || strings.EqualFold(strings.TrimSpace(rule.Exercise), strings.TrimSpace(event.Exercise))
+}
+
+// awardAchievements is called inside the transaction that completes a
+// workout, when it is inserted or later marked completed. It awards the badges
+// earned by the workout's events; badges already earned are kept as they
+// were. A broken rule file is logged rather than failing the change.
+func awardAchievements(tx *sql.Tx, workoutID int, workout models.Workout) error {
+	rules, err := AchievementRules()
+	if err != nil {
+		log.Printf("Skipping achievements: %v", err)
+		return nil
+	}
+	events, err := achievementEvents(tx, workoutID, workout)
+	if err != nil {
+		return err
+	}
+
+	earnedAt := now()
+	for _, rule := range rules {
+		if !slices.ContainsFunc(events, func(e models.AchievementEvent) bool { return matches(rule, e) }) {
+			continue
+		}
+		_, err := tx.Exec(`INSERT OR IGNORE INTO achievements (athlete, badge, workout_id, earned_at) VALUES (?, ?, ?, ?)`,
+			workout.Athlete, rule.ID, workoutID, earnedAt)
+		if err != nil {
+			return fmt.Errorf("failed to award badge: %v", err)
+		}
+	}
+	return nil
+}
+
+// achievementEvents works out what a newly completed workout achieved: the
+// athlete's session count and streaks with it, its tonnage, the best e1RM of
+// each exercise, and any exercise whose best e1RM it beat.
+func achievementEvents(q querier, workoutID int, workout models.Workout) ([]models.AchievementEvent, error) {
+	var events []models.AchievementEvent
+
+	var count int
+	err := q.QueryRow(`SELECT count(*) FROM workouts WHERE athlete = ? AND deleted_at IS NULL AND status = 'completed'`, workout.Athlete).Scan(&count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count workouts: %v", err)
+	}
+	events = append(events, models.AchievementEvent{Type: models.EventSessions, Value: float64(count)})
+
+	sessions, err := athleteSessions(q, workout.Athlete)
+	if err != nil {
+		return nil, err
+	}
+	var days, mondays []time.Time
+	for _, s := range sessions {
+		days = append(days, s.day)
+		mondays = append(mondays, weekStart(s.day))
+	}
+	today := truncateDay(time.Now())
+	events = append(events,
+		models.AchievementEvent{Type: models.EventDailyStreak, Value: float64(streak(days, today, 1).Longest)},
+		models.AchievementEvent{Type: models.EventWeeklyStreak, Value: float64(streak(mondays, weekStart(today), 7).Longest)})
+
+	lc, err := newLoadingContext(q)
+	if err != nil {
+		return nil, err
+	}
+	best, err := previousBests(q, lc, workoutID, workout.Athlete)
+	if err != nil {
+		return nil, err
+	}
+	var tonnage float64
+	e1rms := map[string]models.AchievementEvent{}
+	records := map[string]models.AchievementEvent{}
+	for i := range workout.Lifts {
+		lift := lc.effectiveLift(workout.Athlete, workout.Date, GetLift(workout, i))
+		if lift.WarmUp {
+			continue
+		}
+		tonnage += liftTonnage(lift)
+
+		key := strings.ToLower(strings.TrimSpace(lift.Name))
+		e1rm := round2(LiftOneRepMax(lift))
+		if current, ok := e1rms[key]; !ok || e1rm > current.Value {
+			e1rms[key] = models.AchievementEvent{Type: models.EventE1RM, Exercise: lift.Name, Value: e1rm}
+		}
+		if previous, ok := best[key]; ok && e1rm > previous && e1rm > records[key].Value {
+			records[key] = models.AchievementEvent{Type: models.EventPersonalRecord, Exercise: lift.Name, Value: e1rm}
+		}
+	}
+	events = append(events, models.AchievementEvent{Type: models.EventSessionTonnage, Value: round2(tonnage)})
+	for _, e := range e1rms {
+		events = append(events, e)
+	}
+	for _, record := range records {
+		events = append(events, record)
+	}
+	return events, nil
+}
+
+// previousBests returns the athlete's best e1RM per exercise, keyed by lower
+// case name, in every completed workout but the one given.
+func previousBests(q querier, lc *loadingContext, workoutID int, athlete string) (map[string]float64, error) {
+	query := `SELECT w.day, l.name, l.weight, l.reps, l.rpe, l.rir FROM lifts l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE w.athlete = ? AND w.id != ? AND w.deleted_at IS NULL AND w.status = 'completed' AND l.warm_up = 0`
+	rows, err := q.Query(query, athlete, workoutID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch lift history: %v", err)
+	}
+	defer rows.Close()
+
+	best := map[string]float64{}
+	for rows.Next() {
+		var day string
+		var lift models.Lift
+		var rir sql.NullInt64
+		if err := rows.Scan(&day, &lift.Name, &lift.Weight, &lift.Reps, &lift.RPE, &rir); err != nil {
+			return nil, fmt.Errorf("failed to scan lift history: %v", err)
+		}
+		lift.RIR = nullableInt(rir)
+		key := strings.ToLower(strings.TrimSpace(lift.Name))
+		best[key] = max(best[key], round2(LiftOneRepMax(lc.effectiveLift(athlete, day, lift))))
+	}
+	return best, rows.Err()
+}
+
+// GetAchievements returns the badges an athlete has earned, most recent
+// first, and the ones still locked. Earned badges whose rule has since been
+// removed from the rule file are left out.
+func GetAchievements(db *sql.DB, athlete string) (models.Achievements, error) {
+	rules, err := AchievementRules()
+	if err != nil {
+		return models.Achievements{}, err
+	}
+	earned, err := earnedBadges(db, `athlete = ?`, athlete)
+	if err != nil {
+		return models.Achievements{}, err
+	}
+
+	result := models.Achievements{Athlete: athlete, Earned: []models.Badge{}, Locked: []models.Badge{}}
+	byID := map[string]models.Badge{}
+	for _, b := range earned {
+		byID[b.ID] = b
+	}
+	for _, b := range earned {
+		if i := slices.IndexFunc(rules, func(r models.AchievementRule) bool { return r.ID == b.ID }); i >= 0 {
+			b.AchievementRule = rules[i]
+			result.Earned = append(result.Earned, b)
+		}
+	}
+	for _, rule := range rules {
+		if _, ok := byID[rule.ID]; !ok {
+			result.Locked = append(result.Locked, models.Badge{AchievementRule: rule})
+		}
+	}
+	return result, nil
+}
+
+// weightEvents are the events whose values, and so rule thresholds, are
+// weights in kg.
+var weightEvents = []string{models.EventSessionTonnage, models.EventPersonalRecord, models.EventE1RM}
+
+// BadgeInUnits returns a badge with its threshold in units when it is a
+// weight, rounded to two decimals, and {threshold} in its description filled
+// in with it.
+func BadgeInUnits(b models.Badge, units string) models.Badge {
+	threshold := strconv.FormatFloat(b.Threshold, 'f', -1, 64)
+	if slices.Contains(weightEvents, b.Event) {
+		if units == "" {
+			units = models.UnitKilogram
+		}
+		b.Threshold = round2(ConvertWeight(b.Threshold, models.UnitKilogram, units))
+		threshold = strconv.FormatFloat(b.Threshold, 'f', -1, 64) + " " + units
+	}
+	b.Description = strings.ReplaceAll(b.Description, "{threshold}", threshold)
+	return b
+}
+
+// GetWorkoutBadges returns the badges a workout earned when it was logged.
+func GetWorkoutBadges(db *sql.DB, workoutID int) ([]models.Badge, error) {
+	rules, err := AchievementRules()
+	if err != nil {
+		return nil, err
+	}
+	earned, err := earnedBadges(db, `workout_id = ?`, workoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	badges := []models.Badge{}
+	for _, rule := range rules {
+		if i := slices.IndexFunc(earned, func(b models.Badge) bool { return b.ID == rule.ID }); i >= 0 {
+			earned[i].AchievementRule = rule
+			badges = append(badges, earned[i])
+		}
+	}
+	return badges, nil
+}
+
+// earnedBadges returns the stored badges matching where, most recent first.
+// Only the ID of each rule is filled in.
+func earnedBadges(db *sql.DB, where string, args ...interface{}) ([]models.Badge, error) {
+	rows, err := db.Query(`SELECT badge, workout_id, earned_at FROM achievements WHERE `+where+` ORDER BY earned_at DESC, badge`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch badges: %v", err)
+	}
+	defer rows.Close()
+
+	var badges []models.Badge
+	for rows.Next() {
+		b := models.Badge{Earned: true}
+		if err := rows.Scan(&b.ID, &b.WorkoutID, &b.EarnedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan badge row: %v", err)
+		}
+		badges = append(badges, b)
+	}
+	return badges, rows.Err()
+}
@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"fitness-dev/models"
+)
+
+func badgeIDs(t *testing.T, badges []models.Badge) []string {
+	t.Helper()
+	ids := []string{}
+	for _, b := range badges {
+		ids = append(ids, b.ID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestWorkoutBadges(t *testing.T) {
+	db := newTestDB(t)
+
+	// A first squat over 100 kg e1RM reaches the milestone but beats no earlier best
+	first := logSquat(t, db, "sam", "05/10/2026", 100, 5)
+	badges, err := GetWorkoutBadges(db, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := badgeIDs(t, badges), []string{"first_session", "tonnage_1000", "two_plate_squat"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first workout badges = %v, want %v", got, want)
+	}
+
+	lighter := logSquat(t, db, "sam", "07/10/2026", 90, 5)
+	if badges, _ := GetWorkoutBadges(db, lighter); len(badges) != 0 {
+		t.Errorf("lighter workout badges = %v, want none", badgeIDs(t, badges))
+	}
+
+	heavier := logSquat(t, db, "sam", "09/10/2026", 105, 5)
+	badges, err = GetWorkoutBadges(db, heavier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := badgeIDs(t, badges), []string{"first_pr"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("heavier workout badges = %v, want %v", got, want)
+	}
+}
+
+func TestBadgeInUnits(t *testing.T) {
+	squat := models.Badge{AchievementRule: models.AchievementRule{Description: "Reach an estimated 1RM of {threshold} in the squat", Event: models.EventE1RM, Threshold: 100}}
+	sessions := models.Badge{AchievementRule: models.AchievementRule{Description: "Log {threshold} workouts", Event: models.EventSessions, Threshold: 10}}
+	tests := []struct {
+		badge     models.Badge
+		units     string
+		threshold float64
+		want      string
+	}{
+		{squat, "", 100, "Reach an estimated 1RM of 100 kg in the squat"},
+		{squat, models.UnitPound, 220.46, "Reach an estimated 1RM of 220.46 lb in the squat"},
+		{sessions, models.UnitPound, 10, "Log 10 workouts"},
+	}
+	for _, tt := range tests {
+		got := BadgeInUnits(tt.badge, tt.units)
+		if got.Threshold != tt.threshold || got.Description != tt.want {
+			t.Errorf("BadgeInUnits(%s, %q) = %g, %q; want %g, %q", tt.badge.Event, tt.units, got.Threshold, got.Description, tt.threshold, tt.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// GetAdherence compares the sessions an athlete planned from one day to
+// another (DD/MM/YYYY, inclusive, the last four weeks if empty) with what
+// they did. A workout counts as planned if it was not logged straight away
+// as completed: it has a status other than completed, targets, or a planned
+// session linked to it. Planned sessions left without a workout, because
+// they predate sessions coming with one or it was deleted, count on their own.
+func GetAdherence(db *sql.DB, athlete, from, to string) (models.Adherence, error) {
+	athlete = strings.TrimSpace(athlete)
+	today := truncateDay(time.Now())
+	start, end := today.AddDate(0, 0, -27), today
+	verr := &ValidationError{}
+	if athlete == "" {
+		verr.Add("athlete", "is required")
+	}
+	var err error
+	if from != "" {
+		if start, err = time.Parse(models.DateLayout, from); err != nil {
+			verr.Add("from", "must be a valid date in DD/MM/YYYY format")
+		}
+	}
+	if to != "" {
+		if end, err = time.Parse(models.DateLayout, to); err != nil {
+			verr.Add("to", "must be a valid date in DD/MM/YYYY format")
+		}
+	}
+	if end.Before(start) {
+		verr.Add("to", "must not be before from")
+	}
+	if err := verr.OrNil(); err != nil {
+		return models.Adherence{}, err
+	}
+
+	a := models.Adherence{
+		Athlete:   athlete,
+		From:      start.Format(models.DateLayout),
+		To:        end.Format(models.DateLayout),
+		Exercises: []models.ExerciseAdherence{},
+		Sessions:  []models.AdherenceSession{},
+	}
+	inRange := func(date string) (time.Time, bool) {
+		day, err := time.Parse(models.DateLayout, date)
+		return day, err == nil && !day.Before(start) && !day.After(end)
+	}
+
+	planned, err := GetPlannedSessions(db, athlete, "", "", false)
+	if err != nil {
+		return models.Adherence{}, err
+	}
+	linked := map[int]models.PlannedSession{}
+	for _, p := range planned {
+		if p.WorkoutID != 0 {
+			linked[p.WorkoutID] = p
+		}
+	}
+
+	workouts, err := GetAthleteWorkouts(db, athlete)
+	if err != nil {
+		return models.Adherence{}, err
+	}
+	live := map[int]bool{}
+	for _, w := range workouts {
+		live[w.ID] = true
+	}
+	for _, p := range planned {
+		if live[p.WorkoutID] {
+			continue
+		}
+		if day, ok := inRange(p.Date); ok {
+			a.Sessions = append(a.Sessions, models.AdherenceSession{Date: p.Date, Status: dueStatus(models.StatusPlanned, day, today), PlannedID: p.ID, Title: p.Title})
+		}
+	}
+	exercises := map[string]*models.ExerciseAdherence{}
+	var order []string
+	for _, w := range workouts {
+		day, ok := inRange(w.Date)
+		if !ok {
+			continue
+		}
+		p, fromPlan := linked[w.ID]
+		if w.Status == models.StatusCompleted && !fromPlan && !hasTargets(w) {
+			a.Unplanned++
+			continue
+		}
+
+		s := models.AdherenceSession{Date: w.Date, Status: dueStatus(w.Status, day, today), WorkoutID: w.ID, PlannedID: p.ID, Title: p.Title}
+		due := s.Status == models.StatusCompleted || s.Status == models.StatusSkipped || s.Status == models.AdherenceMissed
+		for i := range w.Lifts {
+			lift := GetLift(w, i)
+			if !hasTarget(lift) {
+				continue
+			}
+			target := lift.TargetWeight * float64(lift.TargetReps*lift.TargetSets)
+			var actual float64
+			var sets int
+			if w.Status == models.StatusCompleted {
+				actual, sets = liftTonnage(lift), lift.Sets
+			}
+			s.TargetTonnage += target
+			s.ActualTonnage += actual
+			if !due {
+				continue
+			}
+
+			key := strings.ToLower(lift.Name)
+			e, ok := exercises[key]
+			if !ok {
+				e = &models.ExerciseAdherence{Exercise: lift.Name}
+				exercises[key] = e
+				order = append(order, key)
+			}
+			e.TargetSets += lift.TargetSets
+			e.ActualSets += sets
+			e.TargetTonnage += target
+			e.ActualTonnage += actual
+			a.TargetSets += lift.TargetSets
+			a.ActualSets += sets
+			a.TargetTonnage += target
+			a.ActualTonnage += actual
+		}
+		s.TargetTonnage, s.ActualTonnage = round2(s.TargetTonnage), round2(s.ActualTonnage)
+		a.Sessions = append(a.Sessions, s)
+	}
+
+	for _, s := range a.Sessions {
+		a.Planned++
+		switch s.Status {
+		case models.StatusCompleted:
+			a.Completed++
+		case models.StatusSkipped:
+			a.Skipped++
+		case models.AdherenceMissed:
+			a.Missed++
+		case models.StatusInProgress:
+			a.InProgress++
+		default:
+			a.Upcoming++
+		}
+	}
+	a.SessionAdherence = percent(float64(a.Completed), float64(a.Completed+a.Skipped+a.Missed))
+	a.SetAdherence = percent(float64(a.ActualSets), float64(a.TargetSets))
+	a.VolumeAdherence = percent(a.ActualTonnage, a.TargetTonnage)
+	a.TargetTonnage, a.ActualTonnage = round2(a.TargetTonnage), round2(a.ActualTonnage)
+
+	slices.Sort(order)
+	for _, key := range order {
+		e := exercises[key]
+		e.VolumeAdherence = percent(e.ActualTonnage, e.TargetTonnage)
+		e.TargetTonnage, e.ActualTonnage = round2(e.TargetTonnage), round2(e.ActualTonnage)
+		a.Exercises = append(a.Exercises, *e)
+	}
+	slices.SortStableFunc(a.Sessions, func(x, y models.AdherenceSession) int {
+		return strings.Compare(sortableDate(x.Date), sortableDate(y.Date))
+	})
+	return a, nil
+}
+
+// dueStatus is the status of a planned session in an adherence report:
+// sessions still planned for a day gone by were missed.
+func dueStatus(status string, day, today time.Time) string {
+	if status == models.StatusPlanned && day.Before(today) {
+		return models.AdherenceMissed
+	}
+	return status
+}
+
+// hasTargets reports whether any lift of a workout was planned with a target.
+func hasTargets(w models.Workout) bool {
+	for i := range w.Lifts {
+		if hasTarget(GetLift(w, i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// percent returns part as a percentage of whole, or 0 if whole is 0.
+func percent(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return round2(part / whole * 100)
+}
@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func TestGetAdherence(t *testing.T) {
+	db := newTestDB(t)
+	today := time.Now()
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format(models.DateLayout) }
+	plan := func(offset int, title string) int {
+		t.Helper()
+		id, err := CreatePlannedSession(db, models.PlannedSession{Athlete: "sam", Date: day(offset), Title: title}, testActor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	plan(-3, "Missed")
+	plan(2, "Upcoming")
+	if _, err := SkipPlannedSession(db, plan(-5, "Skipped"), testActor); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CompletePlannedSession(db, plan(-1, "Done"), logSquat(t, db, "sam", day(-1), 100, 5), testActor); err != nil {
+		t.Fatal(err)
+	}
+	_, err := InsertWorkout(db, models.Workout{
+		Date: day(-2), TimeIn: "07:00", TimeOut: "08:00", MoodIn: "Good", MoodOut: "Good", Athlete: "sam",
+		Lifts: []string{"Squat"}, Weight: []float64{100}, Reps: []int{5}, Sets: []int{2},
+		TargetWeight: []float64{100}, TargetReps: []int{5}, TargetSets: []int{3},
+	}, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logSquat(t, db, "sam", day(-4), 100, 5)
+	logSquat(t, db, "sam", day(-30), 100, 5)
+
+	a, err := GetAdherence(db, "sam", day(-10), day(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := [...]int{a.Planned, a.Completed, a.Skipped, a.Missed, a.InProgress, a.Upcoming, a.Unplanned}
+	if want := [...]int{5, 2, 1, 1, 0, 1, 1}; got != want {
+		t.Errorf("planned, completed, skipped, missed, in progress, upcoming, unplanned = %v, want %v", got, want)
+	}
+	if a.SessionAdherence != 50 || a.SetAdherence != 66.67 || a.VolumeAdherence != 66.67 {
+		t.Errorf("session, set, volume adherence = %v, %v, %v, want 50, 66.67, 66.67", a.SessionAdherence, a.SetAdherence, a.VolumeAdherence)
+	}
+	if a.TargetTonnage != 1500 || a.ActualTonnage != 1000 {
+		t.Errorf("tonnage = %v of %v, want 1000 of 1500", a.ActualTonnage, a.TargetTonnage)
+	}
+
+	titles := map[string]string{}
+	for _, s := range a.Sessions {
+		if s.Title != "" {
+			if _, ok := titles[s.Title]; ok {
+				t.Errorf("session %q counted twice", s.Title)
+			}
+			titles[s.Title] = s.Status
+		}
+	}
+	want := map[string]string{"Missed": models.AdherenceMissed, "Upcoming": models.StatusPlanned, "Skipped": models.StatusSkipped, "Done": models.StatusCompleted}
+	for title, status := range want {
+		if titles[title] != status {
+			t.Errorf("session %q is %q, want %q", title, titles[title], status)
+		}
+	}
+}
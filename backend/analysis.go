@@ -0,0 +1,313 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// Thresholds used when comparing a block's training against its plan.
+const (
+	tonnageTolerance   = 0.10 // fraction either side of target_tonnage
+	intensityTolerance = 5.0  // percentage points either side of target_intensity
+	moodDropForDeload  = 1.0  // fall in weekly mood that calls for a deload
+	lowMood            = 1.5  // weekly mood at or below this calls for a deload
+	lightWeekFraction  = 0.70 // a week below this share of the heaviest week counts as light
+	maxHardWeeks       = 6    // hard weeks in a row before a deload is due
+)
+
+// LiftOneRepMax estimates a one rep max from a lift with the Epley formula,
+// taking effort into account. Reps left in reserve (RIR, or 10 - RPE) count as
+// reps that could have been done, so an easy triple estimates a higher max
+// than a grinder at the same weight.
+func LiftOneRepMax(lift models.Lift) float64 {
+	return epley(lift.Weight, float64(lift.Reps)+repsInReserve(lift))
+}
+
+func repsInReserve(lift models.Lift) float64 {
+	switch {
+	case lift.RIR != nil:
+		return float64(*lift.RIR)
+	case lift.RPE > 0:
+		return MaxRPE - lift.RPE
+	default:
+		return 0
+	}
+}
+
+func epley(weight, reps float64) float64 {
+	if reps <= 1 {
+		return weight
+	}
+	return weight * (1 + reps/30)
+}
+
+// moodScore places a mood on a 0 (Exhausted) to 5 (Energetic) scale.
+func moodScore(mood string) (float64, bool) {
+	for i, m := range models.Moods {
+		if m == mood {
+			return float64(i), true
+		}
+	}
+	return 0, false
+}
+
+// bestEstimates returns an athlete's best estimated 1RM per exercise (lower
+// case) logged before day, using effective load.
+func bestEstimates(db *sql.DB, lc *loadingContext, athlete, before string) (map[string]float64, error) {
+	query := `SELECT w.athlete, w.day, l.name, l.weight, l.reps, l.rpe, l.rir FROM lifts l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE w.deleted_at IS NULL AND w.status = 'completed' AND l.warm_up = 0 AND w.athlete = ? AND ` + sortableDay + ` < ?`
+	rows, err := db.Query(query, athlete, sortableDate(before))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch lift history: %v", err)
+	}
+	defer rows.Close()
+
+	best := map[string]float64{}
+	for rows.Next() {
+		var athlete, day string
+		var lift models.Lift
+		var rir sql.NullInt64
+		if err := rows.Scan(&athlete, &day, &lift.Name, &lift.Weight, &lift.Reps, &lift.RPE, &rir); err != nil {
+			return nil, fmt.Errorf("failed to scan lift history: %v", err)
+		}
+		lift.RIR = nullableInt(rir)
+		lift = lc.effectiveLift(athlete, day, lift)
+		key := strings.ToLower(lift.Name)
+		if e := LiftOneRepMax(lift); e > best[key] {
+			best[key] = e
+		}
+	}
+	return best, rows.Err()
+}
+
+// weeklyStats buckets an athlete's training from start to end (inclusive)
+// into weeks running Monday to Sunday; the first and last week may be cut
+// short by start and end. Intensity is each set's weight as a percentage of
+// the best estimated 1RM for that exercise so far. Bodyweight exercises count
+// with their effective load.
+func weeklyStats(db *sql.DB, athlete string, start, end time.Time) ([]models.WeekStats, error) {
+	lc, err := newLoadingContext(db)
+	if err != nil {
+		return nil, err
+	}
+	best, err := bestEstimates(db, lc, athlete, start.Format(models.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+	workouts, err := workoutsBetween(db, athlete, start.Format(models.DateLayout), end.Format(models.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	first := weekStart(start)
+	weeks := make([]models.WeekStats, weekIndex(first, end)+1)
+	intensitySum := make([]float64, len(weeks))
+	intensitySets := make([]int, len(weeks))
+	moodSum := make([]float64, len(weeks))
+	moodCount := make([]int, len(weeks))
+	for i := range weeks {
+		weeks[i].WeekStart = first.AddDate(0, 0, 7*i).Format(models.DateLayout)
+	}
+
+	for _, w := range workouts {
+		day, err := time.Parse(models.DateLayout, w.Date)
+		if err != nil {
+			continue
+		}
+		i := weekIndex(first, day)
+		weeks[i].Sessions++
+
+		for _, mood := range []string{w.MoodIn, w.MoodOut} {
+			if score, ok := moodScore(mood); ok {
+				moodSum[i] += score
+				moodCount[i]++
+			}
+		}
+
+		for j := range w.Lifts {
+			lift := lc.effectiveLift(w.Athlete, w.Date, GetLift(w, j))
+			if lift.WarmUp {
+				continue
+			}
+			weeks[i].Tonnage += liftTonnage(lift)
+
+			key := strings.ToLower(lift.Name)
+			if e := LiftOneRepMax(lift); e > best[key] {
+				best[key] = e
+			}
+			if best[key] > 0 {
+				intensitySum[i] += lift.Weight / best[key] * 100 * float64(lift.Sets)
+				intensitySets[i] += lift.Sets
+			}
+		}
+
+		for _, c := range w.Cardio {
+			weeks[i].CardioSessions++
+			weeks[i].CardioDistance += c.Distance
+			weeks[i].CardioMinutes += float64(c.Duration) / 60
+		}
+	}
+
+	for i := range weeks {
+		if intensitySets[i] > 0 {
+			weeks[i].Intensity = intensitySum[i] / float64(intensitySets[i])
+		}
+		if moodCount[i] > 0 {
+			weeks[i].Mood = moodSum[i] / float64(moodCount[i])
+		}
+		weeks[i].CardioDistance = round2(weeks[i].CardioDistance)
+		weeks[i].CardioMinutes = round2(weeks[i].CardioMinutes)
+	}
+	return weeks, nil
+}
+
+// WeeklyStats summarises an athlete's last n weeks (Monday to Sunday), ending
+// with this week so far.
+func WeeklyStats(db *sql.DB, athlete string, n int) ([]models.WeekStats, error) {
+	today := truncateDay(time.Now())
+	return weeklyStats(db, athlete, weekStart(today).AddDate(0, 0, -7*(n-1)), today)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// AnalyzeBlock compares the weeks of a block so far against its plan and
+// recommends deloads or changes in volume and intensity.
+func AnalyzeBlock(db *sql.DB, id int) (models.BlockAnalysis, error) {
+	block, err := GetBlock(db, id)
+	if err != nil {
+		return models.BlockAnalysis{}, err
+	}
+	return analyzeBlock(db, block)
+}
+
+// AnalyzeCurrentBlock is AnalyzeBlock for the athlete's block covering today.
+func AnalyzeCurrentBlock(db *sql.DB, athlete string) (models.BlockAnalysis, error) {
+	block, err := CurrentBlock(db, athlete)
+	if err != nil {
+		return models.BlockAnalysis{}, err
+	}
+	return analyzeBlock(db, block)
+}
+
+func analyzeBlock(db *sql.DB, block models.Block) (models.BlockAnalysis, error) {
+	analysis := models.BlockAnalysis{Block: block, Weeks: []models.WeekStats{}}
+
+	start, _ := time.Parse(models.DateLayout, block.StartDate)
+	end, _ := time.Parse(models.DateLayout, block.EndDate)
+	today := truncateDay(time.Now())
+	if today.Before(start) {
+		analysis.Recommendations = []models.Recommendation{{Kind: models.RecommendOnTrack, Reason: "block has not started yet"}}
+		return analysis, nil
+	}
+	if today.Before(end) {
+		end = today
+	}
+
+	weeks, err := weeklyStats(db, block.Athlete, start, end)
+	if err != nil {
+		return models.BlockAnalysis{}, err
+	}
+	analysis.Weeks = weeks
+	analysis.Recommendations = recommend(block, weeks, weekStart(end).AddDate(0, 0, 7).After(today))
+	return analysis, nil
+}
+
+// recommend applies the block rules to its weeks. A deload takes priority over
+// volume and intensity adjustments. When the last week is still in progress
+// its tonnage is not compared against the target.
+func recommend(block models.Block, weeks []models.WeekStats, lastWeekPartial bool) []models.Recommendation {
+	var trained []models.WeekStats
+	for _, w := range weeks {
+		if w.Sessions > 0 {
+			trained = append(trained, w)
+		}
+	}
+	if len(trained) == 0 {
+		return []models.Recommendation{{Kind: models.RecommendOnTrack, Reason: "no workouts logged in this block yet"}}
+	}
+	latest := trained[len(trained)-1]
+
+	if block.Goal != models.GoalDeload {
+		if reason := deloadReason(trained); reason != "" {
+			return []models.Recommendation{{Kind: models.RecommendDeload, Reason: reason}}
+		}
+	}
+
+	var recs []models.Recommendation
+	volumeWeek, haveVolume := latest, true
+	if lastWeekPartial && latest.WeekStart == weeks[len(weeks)-1].WeekStart {
+		volumeWeek, haveVolume = models.WeekStats{}, false
+		if len(trained) > 1 {
+			volumeWeek, haveVolume = trained[len(trained)-2], true
+		}
+	}
+	if block.TargetTonnage > 0 && haveVolume {
+		switch {
+		case volumeWeek.Tonnage < block.TargetTonnage*(1-tonnageTolerance):
+			recs = append(recs, models.Recommendation{Kind: models.RecommendIncreaseVolume,
+				Reason: fmt.Sprintf("week of %s reached %.0fkg of the %.0fkg target", volumeWeek.WeekStart, volumeWeek.Tonnage, block.TargetTonnage)})
+		case volumeWeek.Tonnage > block.TargetTonnage*(1+tonnageTolerance):
+			recs = append(recs, models.Recommendation{Kind: models.RecommendReduceVolume,
+				Reason: fmt.Sprintf("week of %s reached %.0fkg, above the %.0fkg target", volumeWeek.WeekStart, volumeWeek.Tonnage, block.TargetTonnage)})
+		}
+	}
+	if block.TargetIntensity > 0 && latest.Intensity > 0 {
+		switch {
+		case latest.Intensity < block.TargetIntensity-intensityTolerance:
+			recs = append(recs, models.Recommendation{Kind: models.RecommendIncreaseIntensity,
+				Reason: fmt.Sprintf("average intensity %.0f%% is below the %.0f%% target", latest.Intensity, block.TargetIntensity)})
+		case latest.Intensity > block.TargetIntensity+intensityTolerance:
+			recs = append(recs, models.Recommendation{Kind: models.RecommendReduceIntensity,
+				Reason: fmt.Sprintf("average intensity %.0f%% is above the %.0f%% target", latest.Intensity, block.TargetIntensity)})
+		}
+	}
+
+	if len(recs) == 0 {
+		recs = append(recs, models.Recommendation{Kind: models.RecommendOnTrack, Reason: "training matches the block plan"})
+	}
+	return recs
+}
+
+// deloadReason returns why a deload is due, or "" if it is not.
+func deloadReason(trained []models.WeekStats) string {
+	latest := trained[len(trained)-1]
+	if latest.Mood > 0 && latest.Mood <= lowMood {
+		return fmt.Sprintf("average mood in the week of %s was %.1f out of 5", latest.WeekStart, latest.Mood)
+	}
+
+	if len(trained) >= 2 {
+		first := trained[len(trained)-min(3, len(trained))]
+		if first.Mood-latest.Mood >= moodDropForDeload {
+			return fmt.Sprintf("mood fell from %.1f to %.1f since the week of %s", first.Mood, latest.Mood, first.WeekStart)
+		}
+
+		previous := trained[len(trained)-2]
+		if latest.Tonnage > previous.Tonnage*(1+tonnageTolerance) && latest.Mood < previous.Mood {
+			return fmt.Sprintf("tonnage rose to %.0fkg while mood dropped from %.1f to %.1f", latest.Tonnage, previous.Mood, latest.Mood)
+		}
+	}
+
+	var heaviest float64
+	for _, w := range trained {
+		heaviest = max(heaviest, w.Tonnage)
+	}
+	hard := 0
+	for _, w := range trained {
+		if w.Tonnage < heaviest*lightWeekFraction {
+			hard = 0
+		} else {
+			hard++
+		}
+	}
+	if hard >= maxHardWeeks {
+		return fmt.Sprintf("%d hard weeks in a row without a lighter week", hard)
+	}
+	return ""
+}
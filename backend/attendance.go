@@ -0,0 +1,207 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// Limits on attendance reports.
+const (
+	DefaultAttendanceWeeks = 12
+	MaxAttendanceWeeks     = 260
+	commonTimeSlots        = 3 // hours listed in Attendance.CommonTimes
+	calendarLevels         = 4
+)
+
+// session is when one workout took place.
+type session struct {
+	day     time.Time
+	hour    int     // hour of day it started, -1 if unknown
+	minutes float64 // 0 if the times could not be read
+}
+
+// athleteSessions returns the completed workouts of an athlete, oldest first.
+func athleteSessions(q querier, athlete string) ([]session, error) {
+	query := `SELECT day, time_in, time_out FROM workouts WHERE athlete = ? AND deleted_at IS NULL AND status = 'completed'
+		ORDER BY ` + sortableDay + `, time_in`
+	rows, err := q.Query(query, athlete)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts: %v", err)
+	}
+	defer rows.Close()
+
+	var sessions []session
+	for rows.Next() {
+		var day, timeIn, timeOut string
+		if err := rows.Scan(&day, &timeIn, &timeOut); err != nil {
+			return nil, fmt.Errorf("failed to scan workout row: %v", err)
+		}
+		d, err := time.Parse(models.DateLayout, day)
+		if err != nil {
+			continue
+		}
+		s := session{day: d, hour: -1}
+		in, inErr := parseClock(timeIn)
+		out, outErr := parseClock(timeOut)
+		if inErr == nil {
+			s.hour = in.Hour()
+		}
+		if inErr == nil && outErr == nil {
+			s.minutes = SessionDuration(in, out).Minutes()
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// weekStart returns the Monday of day's week.
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// weekIndex returns how many weeks after the week starting on Monday first
+// day's week begins.
+func weekIndex(first, day time.Time) int {
+	return int(math.Round(weekStart(day).Sub(first).Hours()/24)) / 7
+}
+
+// GetAttendance reports an athlete's streaks, session durations and times,
+// and their sessions in each of the last weeks (Monday to Sunday).
+func GetAttendance(db *sql.DB, athlete string, weeks int) (models.Attendance, error) {
+	if weeks < 1 || weeks > MaxAttendanceWeeks {
+		verr := &ValidationError{}
+		verr.Add("weeks", "must be between 1 and %d", MaxAttendanceWeeks)
+		return models.Attendance{}, verr
+	}
+	sessions, err := athleteSessions(db, athlete)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	today := truncateDay(time.Now())
+
+	report := models.Attendance{Athlete: athlete, Sessions: len(sessions), CommonTimes: []models.TimeSlot{}}
+	var days, mondays []time.Time
+	for _, s := range sessions {
+		days = append(days, s.day)
+		mondays = append(mondays, weekStart(s.day))
+	}
+	report.DailyStreak = streak(days, today, 1)
+	report.WeeklyStreak = streak(mondays, weekStart(today), 7)
+
+	var minutes float64
+	var timed int
+	hours := map[int]int{}
+	for _, s := range sessions {
+		if s.minutes > 0 {
+			minutes += s.minutes
+			timed++
+		}
+		if s.hour >= 0 {
+			hours[s.hour]++
+		}
+	}
+	if timed > 0 {
+		report.AverageDuration = round2(minutes / float64(timed))
+	}
+
+	for hour, n := range hours {
+		report.CommonTimes = append(report.CommonTimes, models.TimeSlot{Hour: hour, Sessions: n, Share: round2(float64(n) / float64(len(sessions)) * 100)})
+	}
+	sort.Slice(report.CommonTimes, func(i, j int) bool {
+		a, b := report.CommonTimes[i], report.CommonTimes[j]
+		return a.Sessions > b.Sessions || (a.Sessions == b.Sessions && a.Hour < b.Hour)
+	})
+	if len(report.CommonTimes) > commonTimeSlots {
+		report.CommonTimes = report.CommonTimes[:commonTimeSlots]
+	}
+
+	first := weekStart(today).AddDate(0, 0, -7*(weeks-1))
+	report.Weeks = make([]models.WeekCount, weeks)
+	for i := range report.Weeks {
+		report.Weeks[i].WeekStart = first.AddDate(0, 0, 7*i).Format(models.DateLayout)
+	}
+	for _, monday := range mondays {
+		if i := weekIndex(first, monday); !monday.Before(first) && i < weeks {
+			report.Weeks[i].Sessions++
+		}
+	}
+	return report, nil
+}
+
+// streak finds runs in sorted periods (days, or the Mondays of weeks) that
+// are step days apart. The current run may end in the period before now, as
+// a day or week without a session yet has not broken it.
+func streak(periods []time.Time, now time.Time, step int) models.Streak {
+	var s models.Streak
+	var run int
+	var runStart, last time.Time
+	for _, p := range periods {
+		switch {
+		case run > 0 && p.Equal(last):
+			continue
+		case run > 0 && p.Equal(last.AddDate(0, 0, step)):
+			run++
+		default:
+			run, runStart = 1, p
+		}
+		last = p
+		if run > s.Longest {
+			s.Longest = run
+			s.LongestStart = runStart.Format(models.DateLayout)
+			s.LongestEnd = p.AddDate(0, 0, step-1).Format(models.DateLayout)
+		}
+	}
+	if run > 0 && (last.Equal(now) || last.Equal(now.AddDate(0, 0, -step))) {
+		s.Current = run
+	}
+	return s
+}
+
+// GetCalendar returns every day of a year with the athlete's sessions and
+// minutes trained. Levels split training days into quarters of the year's
+// longest day.
+func GetCalendar(db *sql.DB, athlete string, year int) (models.Calendar, error) {
+	if year < 1900 || year > 9999 {
+		verr := &ValidationError{}
+		verr.Add("year", "must be a four digit year")
+		return models.Calendar{}, verr
+	}
+	sessions, err := athleteSessions(db, athlete)
+	if err != nil {
+		return models.Calendar{}, err
+	}
+
+	first := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	calendar := models.Calendar{Athlete: athlete, Year: year, Days: make([]models.CalendarDay, first.AddDate(1, 0, -1).YearDay())}
+	for i := range calendar.Days {
+		calendar.Days[i].Date = first.AddDate(0, 0, i).Format(models.DateLayout)
+	}
+
+	var longest float64
+	for _, s := range sessions {
+		if s.day.Year() != year {
+			continue
+		}
+		d := &calendar.Days[s.day.YearDay()-1]
+		d.Sessions++
+		d.Minutes += s.minutes
+		longest = math.Max(longest, d.Minutes)
+	}
+	for i := range calendar.Days {
+		d := &calendar.Days[i]
+		switch {
+		case d.Sessions == 0:
+			d.Level = 0
+		case longest == 0:
+			d.Level = 1
+		default:
+			d.Level = max(1, int(math.Ceil(d.Minutes/longest*calendarLevels)))
+		}
+	}
+	return calendar, nil
+}
@@ -0,0 +1,114 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func parseDay(t *testing.T, day string) time.Time {
+	t.Helper()
+	d, err := time.Parse(models.DateLayout, day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestWeekStart(t *testing.T) {
+	tests := []struct{ day, want string }{
+		{"12/10/2026", "12/10/2026"}, // Monday
+		{"14/10/2026", "12/10/2026"},
+		{"18/10/2026", "12/10/2026"}, // Sunday
+		{"19/10/2026", "19/10/2026"},
+		{"01/10/2026", "28/09/2026"},
+		{"01/01/2026", "29/12/2025"},
+	}
+	for _, tt := range tests {
+		if got := weekStart(parseDay(t, tt.day)).Format(models.DateLayout); got != tt.want {
+			t.Errorf("weekStart(%s) = %s, want %s", tt.day, got, tt.want)
+		}
+	}
+}
+
+func TestStreak(t *testing.T) {
+	tests := []struct {
+		name    string
+		days    []string
+		now     string
+		current int
+		longest int
+		start   string
+		end     string
+	}{
+		{"logged today", []string{"10/10/2026", "11/10/2026", "12/10/2026"}, "12/10/2026", 3, 3, "10/10/2026", "12/10/2026"},
+		{"today not logged yet", []string{"10/10/2026", "11/10/2026"}, "12/10/2026", 2, 2, "10/10/2026", "11/10/2026"},
+		{"broken yesterday", []string{"09/10/2026", "10/10/2026"}, "12/10/2026", 0, 2, "09/10/2026", "10/10/2026"},
+		{"gap day", []string{"06/10/2026", "07/10/2026", "08/10/2026", "10/10/2026", "11/10/2026"}, "11/10/2026", 2, 3, "06/10/2026", "08/10/2026"},
+		{"two sessions a day", []string{"10/10/2026", "10/10/2026", "11/10/2026"}, "11/10/2026", 2, 2, "10/10/2026", "11/10/2026"},
+		{"across years", []string{"30/12/2025", "31/12/2025", "01/01/2026"}, "01/01/2026", 3, 3, "30/12/2025", "01/01/2026"},
+		{"none", nil, "12/10/2026", 0, 0, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var days []time.Time
+			for _, d := range tt.days {
+				days = append(days, parseDay(t, d))
+			}
+			got := streak(days, parseDay(t, tt.now), 1)
+			want := models.Streak{Current: tt.current, Longest: tt.longest, LongestStart: tt.start, LongestEnd: tt.end}
+			if got != want {
+				t.Errorf("streak = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestWeeklyStreak(t *testing.T) {
+	// A Sunday and the Monday after are two weeks in a row; the week of
+	// 26/10 has no session yet.
+	var mondays []time.Time
+	for _, d := range []string{"04/10/2026", "11/10/2026", "12/10/2026", "19/10/2026"} {
+		mondays = append(mondays, weekStart(parseDay(t, d)))
+	}
+	got := streak(mondays, parseDay(t, "26/10/2026"), 7)
+	want := models.Streak{Current: 4, Longest: 4, LongestStart: "28/09/2026", LongestEnd: "25/10/2026"}
+	if got != want {
+		t.Errorf("weekly streak = %+v, want %+v", got, want)
+	}
+}
+
+func TestAthleteSessionsOrder(t *testing.T) {
+	db := newTestDB(t)
+	days := []string{"02/02/2026", "31/12/2025", "01/01/2026", "30/12/2025", "31/01/2026", "15/11/2025"}
+	for _, day := range days {
+		logSquat(t, db, "sam", day, 100, 5)
+	}
+	logSquat(t, db, "alex", "01/12/2025", 100, 5)
+
+	sessions, err := athleteSessions(db, "sam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"15/11/2025", "30/12/2025", "31/12/2025", "01/01/2026", "31/01/2026", "02/02/2026"}
+	if len(sessions) != len(want) {
+		t.Fatalf("got %d sessions, want %d", len(sessions), len(want))
+	}
+	for i, s := range sessions {
+		if got := s.day.Format(models.DateLayout); got != want[i] {
+			t.Errorf("session %d is on %s, want %s", i, got, want[i])
+		}
+		if s.hour != 7 || s.minutes != 60 {
+			t.Errorf("session %d started at %d for %v minutes, want 7 and 60", i, s.hour, s.minutes)
+		}
+	}
+
+	a, err := GetAttendance(db, "sam", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.DailyStreak.Longest != 3 || a.DailyStreak.LongestStart != "30/12/2025" || a.DailyStreak.LongestEnd != "01/01/2026" {
+		t.Errorf("longest daily streak = %+v, want 30/12/2025 to 01/01/2026", a.DailyStreak)
+	}
+}
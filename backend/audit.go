@@ -0,0 +1,199 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// Sources a change can come from.
+const (
+	SourceAPI    = "api"
+	SourceCLI    = "cli"
+	SourceSync   = "sync"
+	SourceSystem = "system"
+)
+
+// Audit actions.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+)
+
+// auditTimeLayout is fixed width so timestamps sort correctly as text.
+const auditTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// Actor identifies who made a change and through which interface.
+type Actor struct {
+	Name   string
+	Source string
+}
+
+// SystemActor is used for changes the application makes on its own, such as
+// purging expired trash.
+var SystemActor = Actor{Name: "system", Source: SourceSystem}
+
+// Kinds of records the audit log tracks.
+const (
+	EntityWorkout        = "workout"
+	EntityTemplate       = "template"
+	EntityProgram        = "program"
+	EntityBlock          = "block"
+	EntityMeasurement    = "measurement"
+	EntitySettings       = "settings"
+	EntityEquipment      = "equipment"
+	EntityGoal           = "goal"
+	EntityGroup          = "group"
+	EntityChallenge      = "challenge"
+	EntityPlannedSession = "planned_session"
+	EntityExercise       = "exercise"
+)
+
+// recordAudit appends an entry to the audit log. key identifies the record
+// within its entity: its ID, or the user or name for records keyed by one.
+// before and after are the record as it was and as it is now; either may be
+// nil.
+func recordAudit(tx *sql.Tx, actor Actor, action, entity string, key interface{}, before, after interface{}) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	// workout_id predates other entities and stays 0 for them
+	workoutID := 0
+	if entity == EntityWorkout {
+		workoutID, _ = key.(int)
+	}
+	query := `INSERT INTO audit_log (workout_id, entity, entity_key, action, actor, source, created_at, before_json, after_json) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, workoutID, entity, fmt.Sprint(key), action, actor.Name, actor.Source, time.Now().UTC().Format(auditTimeLayout), beforeJSON, afterJSON)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %v", err)
+	}
+	return nil
+}
+
+func auditJSON(record interface{}) (interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %v", err)
+	}
+	// Nil pointers and interfaces are stored as NULL
+	if string(data) == "null" {
+		return nil, nil
+	}
+	return string(data), nil
+}
+
+// auditSnapshot loads the current state of a workout, trashed or not, for the audit log.
+func auditSnapshot(tx *sql.Tx, workoutID int) (*models.Workout, error) {
+	workout, err := getWorkout(tx, workoutID, true)
+	if err != nil {
+		return nil, err
+	}
+	return &workout, nil
+}
+
+// AuditFilter narrows down GetAuditLog. Zero values are not applied.
+type AuditFilter struct {
+	WorkoutID int
+	Entity    string
+	Key       string // only applied together with Entity
+	From      string // RFC 3339, inclusive
+	To        string // RFC 3339, exclusive
+	Limit     int
+}
+
+// auditKey is the key of an audit entry. Entries written before other
+// entities were audited only have a workout_id.
+const auditKey = `(CASE WHEN entity_key = '' THEN CAST(workout_id AS TEXT) ELSE entity_key END)`
+
+// GetAuditLog returns audit entries matching filter, oldest first.
+func GetAuditLog(db *sql.DB, filter AuditFilter) ([]models.AuditEntry, error) {
+	query := `SELECT id, workout_id, entity, ` + auditKey + `, action, actor, source, created_at, before_json, after_json FROM audit_log`
+	var where []string
+	var args []interface{}
+	if filter.WorkoutID != 0 {
+		where = append(where, `entity = ? AND workout_id = ?`)
+		args = append(args, EntityWorkout, filter.WorkoutID)
+	}
+	if filter.Entity != "" {
+		where = append(where, `entity = ?`)
+		args = append(args, filter.Entity)
+		if filter.Key != "" {
+			where = append(where, auditKey+` = ?`)
+			args = append(args, filter.Key)
+		}
+	}
+	if filter.From != "" {
+		where = append(where, `created_at >= ?`)
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where = append(where, `created_at < ?`)
+		args = append(args, filter.To)
+	}
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY id`
+	if filter.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch audit log: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.WorkoutID, &entry.Entity, &entry.Key, &entry.Action, &entry.Actor, &entry.Source, &entry.CreatedAt, &before, &after); err != nil {
+			return nil, fmt.Errorf("failed to scan audit row: %v", err)
+		}
+		if before.Valid {
+			entry.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entry.After = json.RawMessage(after.String)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// ParseAuditTime turns a user supplied bound into the RFC 3339 form stored in
+// the audit log. It accepts RFC 3339 timestamps or DD/MM/YYYY dates; a date
+// used as an upper bound covers the whole day.
+func ParseAuditTime(value string, upper bool) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC().Format(auditTimeLayout), nil
+	}
+
+	t, err := time.Parse(models.DateLayout, strings.ReplaceAll(value, "-", "/"))
+	if err != nil {
+		return "", &ValidationError{Fields: []FieldError{{Field: "time", Message: fmt.Sprintf("%q must be RFC 3339 or DD/MM/YYYY", value)}}}
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.UTC().Format(auditTimeLayout), nil
+}
@@ -0,0 +1,255 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func validateBlock(block models.Block) error {
+	verr := &ValidationError{}
+	if block.Name == "" {
+		verr.Add("name", "is required")
+	}
+	if block.Athlete == "" {
+		verr.Add("athlete", "is required")
+	}
+	start, startErr := time.Parse(models.DateLayout, block.StartDate)
+	if startErr != nil {
+		verr.Add("start_date", "must be a valid date in DD/MM/YYYY format")
+	}
+	end, endErr := time.Parse(models.DateLayout, block.EndDate)
+	if endErr != nil {
+		verr.Add("end_date", "must be a valid date in DD/MM/YYYY format")
+	}
+	if startErr == nil && endErr == nil && end.Before(start) {
+		verr.Add("end_date", "must not be before start_date")
+	}
+
+	validGoal := false
+	for _, g := range models.Goals {
+		if g == block.Goal {
+			validGoal = true
+		}
+	}
+	if !validGoal {
+		verr.Add("goal", "must be one of %s", strings.Join(models.Goals, ", "))
+	}
+	if block.TargetIntensity < 0 || block.TargetIntensity > 100 {
+		verr.Add("target_intensity", "must be between 0 and 100")
+	}
+	if block.TargetTonnage < 0 {
+		verr.Add("target_tonnage", "must not be negative")
+	}
+	return verr.OrNil()
+}
+
+func normalizeBlock(block models.Block) models.Block {
+	block.Name = strings.TrimSpace(block.Name)
+	block.Athlete = strings.TrimSpace(block.Athlete)
+	block.StartDate = strings.ReplaceAll(strings.TrimSpace(block.StartDate), "-", "/")
+	block.EndDate = strings.ReplaceAll(strings.TrimSpace(block.EndDate), "-", "/")
+	block.Goal = strings.ToLower(strings.TrimSpace(block.Goal))
+	return block
+}
+
+// checkBlockOverlap returns a conflict if block shares any day with another
+// block of the same athlete.
+func checkBlockOverlap(tx *sql.Tx, block models.Block) error {
+	query := `SELECT name FROM blocks WHERE id != ? AND athlete = ?
+		AND ` + sortableColumn("start_date") + ` <= ? AND ` + sortableColumn("end_date") + ` >= ? LIMIT 1`
+	var name string
+	err := tx.QueryRow(query, block.ID, block.Athlete, sortableDate(block.EndDate), sortableDate(block.StartDate)).Scan(&name)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check for overlapping blocks: %v", err)
+	}
+	return &ConflictError{Message: fmt.Sprintf("block overlaps with %q", name)}
+}
+
+// sortableColumn is sortableDay for any DD/MM/YYYY column.
+func sortableColumn(column string) string {
+	return fmt.Sprintf(`(substr(%[1]s, 7, 4) || substr(%[1]s, 4, 2) || substr(%[1]s, 1, 2))`, column)
+}
+
+// CreateBlock stores a new training block and returns its ID.
+func CreateBlock(db *sql.DB, block models.Block, actor Actor) (int, error) {
+	block = normalizeBlock(block)
+	block.ID = 0
+	if err := validateBlock(block); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		if err := checkBlockOverlap(tx, block); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO blocks (athlete, name, start_date, end_date, goal, target_intensity, target_tonnage) VALUES (?, ?, ?, ?, ?, ?, ?)`
+		result, err := tx.Exec(query, block.Athlete, block.Name, block.StartDate, block.EndDate, block.Goal, block.TargetIntensity, block.TargetTonnage)
+		if err != nil {
+			return fmt.Errorf("failed to insert block: %v", err)
+		}
+		blockID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve block ID: %v", err)
+		}
+		id = int(blockID)
+
+		block.ID = id
+		return recordAudit(tx, actor, ActionCreate, EntityBlock, id, nil, block)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateBlock replaces a block's plan.
+func UpdateBlock(db *sql.DB, block models.Block, actor Actor) error {
+	block = normalizeBlock(block)
+	if err := validateBlock(block); err != nil {
+		return err
+	}
+
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getBlock(tx, block.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkBlockOverlap(tx, block); err != nil {
+			return err
+		}
+
+		query := `UPDATE blocks SET athlete = ?, name = ?, start_date = ?, end_date = ?, goal = ?, target_intensity = ?, target_tonnage = ? WHERE id = ?`
+		if _, err := tx.Exec(query, block.Athlete, block.Name, block.StartDate, block.EndDate, block.Goal, block.TargetIntensity, block.TargetTonnage, block.ID); err != nil {
+			return fmt.Errorf("failed to update block: %v", err)
+		}
+		return recordAudit(tx, actor, ActionUpdate, EntityBlock, block.ID, before, block)
+	})
+}
+
+const blockColumns = `id, athlete, name, start_date, end_date, goal, target_intensity, target_tonnage`
+
+func scanBlock(row rowScanner) (models.Block, error) {
+	var b models.Block
+	err := row.Scan(&b.ID, &b.Athlete, &b.Name, &b.StartDate, &b.EndDate, &b.Goal, &b.TargetIntensity, &b.TargetTonnage)
+	return b, err
+}
+
+// GetBlocks returns an athlete's blocks in date order.
+func GetBlocks(db *sql.DB, athlete string) ([]models.Block, error) {
+	rows, err := db.Query(`SELECT `+blockColumns+` FROM blocks WHERE athlete = ? ORDER BY `+sortableColumn("start_date"), athlete)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch blocks: %v", err)
+	}
+	defer rows.Close()
+
+	var blocks []models.Block
+	for rows.Next() {
+		block, err := scanBlock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan block row: %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, rows.Err()
+}
+
+func GetBlock(db *sql.DB, id int) (models.Block, error) {
+	return getBlock(db, id)
+}
+
+func getBlock(q querier, id int) (models.Block, error) {
+	block, err := scanBlock(q.QueryRow(`SELECT `+blockColumns+` FROM blocks WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return models.Block{}, &NotFoundError{Resource: "block", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.Block{}, fmt.Errorf("failed to fetch block: %v", err)
+	}
+	return block, nil
+}
+
+// BlockForDate returns the athlete's block covering day (DD/MM/YYYY).
+func BlockForDate(db *sql.DB, athlete, day string) (models.Block, error) {
+	query := `SELECT ` + blockColumns + ` FROM blocks
+		WHERE athlete = ? AND ` + sortableColumn("start_date") + ` <= ? AND ` + sortableColumn("end_date") + ` >= ?`
+	key := sortableDate(day)
+	block, err := scanBlock(db.QueryRow(query, athlete, key, key))
+	if err == sql.ErrNoRows {
+		return models.Block{}, &NotFoundError{Resource: "block on", Key: day}
+	}
+	if err != nil {
+		return models.Block{}, fmt.Errorf("failed to fetch block: %v", err)
+	}
+	return block, nil
+}
+
+// CurrentBlock returns the athlete's block covering today.
+func CurrentBlock(db *sql.DB, athlete string) (models.Block, error) {
+	return BlockForDate(db, athlete, time.Now().Format(models.DateLayout))
+}
+
+func DeleteBlock(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getBlock(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM blocks WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete block: %v", err)
+		}
+		return recordAudit(tx, actor, ActionDelete, EntityBlock, id, before, nil)
+	})
+}
+
+// GetBlockWorkouts returns the completed workouts of the block's athlete that
+// fall within it, oldest first.
+func GetBlockWorkouts(db *sql.DB, id int) ([]models.Workout, error) {
+	block, err := GetBlock(db, id)
+	if err != nil {
+		return nil, err
+	}
+	return workoutsBetween(db, block.Athlete, block.StartDate, block.EndDate)
+}
+
+// workoutsBetween returns an athlete's completed workouts from start to end
+// (DD/MM/YYYY, inclusive) with their lifts, oldest first. An empty athlete
+// returns the workouts of every athlete.
+func workoutsBetween(db *sql.DB, athlete, start, end string) ([]models.Workout, error) {
+	query := `SELECT ` + workoutColumns + ` FROM workouts
+		WHERE deleted_at IS NULL AND status = 'completed' AND (? = '' OR athlete = ?) AND ` + sortableDay + ` BETWEEN ? AND ?
+		ORDER BY ` + sortableDay + `, time_in`
+	rows, err := db.Query(query, athlete, athlete, sortableDate(start), sortableDate(end))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts: %v", err)
+	}
+	defer rows.Close()
+
+	var workouts []models.Workout
+	for rows.Next() {
+		workout, err := scanWorkout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		workouts = append(workouts, workout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts: %v", err)
+	}
+	rows.Close()
+
+	for i := range workouts {
+		if err := loadDetails(db, &workouts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return workouts, nil
+}
@@ -0,0 +1,210 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"fitness-dev/models"
+)
+
+// normalizeCardio canonicalises activity names, fills in the duration and
+// distance of interval sessions from their bouts, and computes pace.
+func normalizeCardio(cardio []models.Cardio) []models.Cardio {
+	if cardio == nil {
+		return nil
+	}
+	normalized := make([]models.Cardio, len(cardio))
+	for i, c := range cardio {
+		c.Activity = strings.ToLower(strings.TrimSpace(c.Activity))
+		if len(c.Intervals) > 0 {
+			var duration int
+			var distance float64
+			for _, iv := range c.Intervals {
+				duration += iv.Duration + iv.Rest
+				distance += iv.Distance
+			}
+			if c.Duration == 0 {
+				c.Duration = duration
+			}
+			if c.Distance == 0 {
+				c.Distance = distance
+			}
+		}
+		c.Pace = pace(c.Duration, c.Distance)
+		for j := range c.Splits {
+			c.Splits[j].Pace = pace(c.Splits[j].Duration, c.Splits[j].Distance)
+		}
+		normalized[i] = c
+	}
+	return normalized
+}
+
+// pace returns seconds per km, or 0 without a distance.
+func pace(duration int, distance float64) float64 {
+	if distance <= 0 || duration <= 0 {
+		return 0
+	}
+	return math.Round(float64(duration)/distance*10) / 10
+}
+
+func validateCardio(cardio []models.Cardio, verr *ValidationError) {
+	for i, c := range cardio {
+		field := fmt.Sprintf("cardio[%d]", i)
+		valid := false
+		for _, a := range models.Activities {
+			if a == c.Activity {
+				valid = true
+			}
+		}
+		if !valid {
+			verr.Add(field+".activity", "must be one of %s", strings.Join(models.Activities, ", "))
+		}
+		if c.Duration < 1 || c.Duration > int(MaxSessionDuration.Seconds()) {
+			verr.Add(field+".duration", "must be between 1 and %d seconds", int(MaxSessionDuration.Seconds()))
+		}
+		if c.Distance < 0 || c.Distance > MaxDistance {
+			verr.Add(field+".distance", "must be between 0 and %g km", MaxDistance)
+		}
+		if c.AvgHR != 0 && (c.AvgHR < MinHeartRate || c.AvgHR > MaxHeartRate) {
+			verr.Add(field+".avg_hr", "must be between %d and %d", MinHeartRate, MaxHeartRate)
+		}
+		if c.Elevation < 0 || c.Elevation > MaxElevation {
+			verr.Add(field+".elevation", "must be between 0 and %g m", MaxElevation)
+		}
+		if c.Activity == models.ActivityIntervals && len(c.Intervals) == 0 {
+			verr.Add(field+".intervals", "at least one interval is required")
+		}
+		for j, iv := range c.Intervals {
+			ivField := fmt.Sprintf("%s.intervals[%d]", field, j)
+			if iv.Duration < 1 {
+				verr.Add(ivField+".duration", "must be at least 1 second")
+			}
+			if iv.Rest < 0 {
+				verr.Add(ivField+".rest", "must not be negative")
+			}
+			if iv.Distance < 0 {
+				verr.Add(ivField+".distance", "must not be negative")
+			}
+			if iv.AvgHR != 0 && (iv.AvgHR < MinHeartRate || iv.AvgHR > MaxHeartRate) {
+				verr.Add(ivField+".avg_hr", "must be between %d and %d", MinHeartRate, MaxHeartRate)
+			}
+		}
+		for j, s := range c.Splits {
+			if s.Distance <= 0 || s.Duration < 0 {
+				verr.Add(fmt.Sprintf("%s.splits[%d]", field, j), "distance must be positive and duration not negative")
+			}
+		}
+	}
+}
+
+func insertCardio(tx *sql.Tx, workoutID int, workout models.Workout) error {
+	cardioQuery := `INSERT INTO cardio (workout_id, activity, distance, duration, avg_hr, elevation) VALUES (?, ?, ?, ?, ?, ?)`
+	intervalQuery := `INSERT INTO cardio_intervals (cardio_id, duration, distance, rest, avg_hr) VALUES (?, ?, ?, ?, ?)`
+	splitQuery := `INSERT INTO cardio_splits (cardio_id, distance, duration, avg_hr, elevation) VALUES (?, ?, ?, ?, ?)`
+	for _, c := range workout.Cardio {
+		result, err := tx.Exec(cardioQuery, workoutID, c.Activity, c.Distance, c.Duration, c.AvgHR, c.Elevation)
+		if err != nil {
+			return fmt.Errorf("failed to insert cardio: %v", err)
+		}
+		cardioID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve cardio ID: %v", err)
+		}
+		for _, iv := range c.Intervals {
+			if _, err := tx.Exec(intervalQuery, cardioID, iv.Duration, iv.Distance, iv.Rest, iv.AvgHR); err != nil {
+				return fmt.Errorf("failed to insert interval: %v", err)
+			}
+		}
+		for _, s := range c.Splits {
+			if _, err := tx.Exec(splitQuery, cardioID, s.Distance, s.Duration, s.AvgHR, s.Elevation); err != nil {
+				return fmt.Errorf("failed to insert split: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteCardio removes the cardio activities of the workouts matching where.
+func deleteCardio(tx *sql.Tx, where string, args ...interface{}) error {
+	for _, table := range []string{"cardio_intervals", "cardio_splits"} {
+		_, err := tx.Exec(`DELETE FROM `+table+` WHERE cardio_id IN
+			(SELECT id FROM cardio WHERE workout_id IN (SELECT id FROM workouts WHERE `+where+`))`, args...)
+		if err != nil {
+			return fmt.Errorf("failed to delete %s: %v", table, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM cardio WHERE workout_id IN (SELECT id FROM workouts WHERE `+where+`)`, args...); err != nil {
+		return fmt.Errorf("failed to delete cardio: %v", err)
+	}
+	return nil
+}
+
+func loadCardio(q querier, workout *models.Workout) error {
+	rows, err := q.Query(`SELECT id, activity, distance, duration, avg_hr, elevation FROM cardio WHERE workout_id = ? ORDER BY id`, workout.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch cardio: %v", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		var c models.Cardio
+		if err := rows.Scan(&id, &c.Activity, &c.Distance, &c.Duration, &c.AvgHR, &c.Elevation); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan cardio row: %v", err)
+		}
+		c.Pace = pace(c.Duration, c.Distance)
+		ids = append(ids, id)
+		workout.Cardio = append(workout.Cardio, c)
+	}
+	rows.Close()
+
+	for i, id := range ids {
+		rows, err := q.Query(`SELECT duration, distance, rest, avg_hr FROM cardio_intervals WHERE cardio_id = ? ORDER BY id`, id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch intervals: %v", err)
+		}
+		for rows.Next() {
+			var iv models.Interval
+			if err := rows.Scan(&iv.Duration, &iv.Distance, &iv.Rest, &iv.AvgHR); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan interval row: %v", err)
+			}
+			workout.Cardio[i].Intervals = append(workout.Cardio[i].Intervals, iv)
+		}
+		rows.Close()
+
+		rows, err = q.Query(`SELECT distance, duration, avg_hr, elevation FROM cardio_splits WHERE cardio_id = ? ORDER BY id`, id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch splits: %v", err)
+		}
+		for rows.Next() {
+			var s models.Split
+			if err := rows.Scan(&s.Distance, &s.Duration, &s.AvgHR, &s.Elevation); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan split row: %v", err)
+			}
+			s.Pace = pace(s.Duration, s.Distance)
+			workout.Cardio[i].Splits = append(workout.Cardio[i].Splits, s)
+		}
+		rows.Close()
+	}
+	return nil
+}
+
+// AddCardio appends a cardio activity to a workout.
+func AddCardio(db *sql.DB, workoutID, version int, cardio models.Cardio, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, "", actor, func(workout *models.Workout) error {
+		workout.Cardio = append(workout.Cardio, cardio)
+		return nil
+	})
+}
+
+// loadDetails loads everything recorded within a workout: lifts and cardio.
+func loadDetails(q querier, workout *models.Workout) error {
+	if err := loadLifts(q, workout); err != nil {
+		return err
+	}
+	return loadCardio(q, workout)
+}
@@ -0,0 +1,603 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// MaxChallengeDays is the longest window a challenge can run over.
+const MaxChallengeDays = 366
+
+func normalizeGroup(group models.Group) models.Group {
+	group.Name = strings.TrimSpace(group.Name)
+	members := []string{}
+	for _, m := range group.Members {
+		if m = strings.TrimSpace(m); m != "" && !slices.Contains(members, m) {
+			members = append(members, m)
+		}
+	}
+	group.Members = members
+	return group
+}
+
+// checkGroupName returns a ConflictError if another group already has name.
+func checkGroupName(tx *sql.Tx, name string, id int) error {
+	var existing int
+	err := tx.QueryRow(`SELECT count(*) FROM groups WHERE name = ? AND id != ?`, name, id).Scan(&existing)
+	if err != nil {
+		return fmt.Errorf("failed to check for existing group: %v", err)
+	}
+	if existing > 0 {
+		return &ConflictError{Message: fmt.Sprintf("a group named %q already exists", name)}
+	}
+	return nil
+}
+
+func insertMembers(tx *sql.Tx, group models.Group) error {
+	for i, m := range group.Members {
+		if _, err := tx.Exec(`INSERT INTO group_members (group_id, position, athlete) VALUES (?, ?, ?)`, group.ID, i, m); err != nil {
+			return fmt.Errorf("failed to insert group member: %v", err)
+		}
+	}
+	return nil
+}
+
+// CreateGroup stores a new group with its members and returns its ID.
+func CreateGroup(db *sql.DB, group models.Group, actor Actor) (int, error) {
+	group = normalizeGroup(group)
+	if group.Name == "" {
+		verr := &ValidationError{}
+		verr.Add("name", "is required")
+		return 0, verr
+	}
+
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		if err := checkGroupName(tx, group.Name, 0); err != nil {
+			return err
+		}
+		result, err := tx.Exec(`INSERT INTO groups (name) VALUES (?)`, group.Name)
+		if err != nil {
+			return fmt.Errorf("failed to insert group: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve group ID: %v", err)
+		}
+		group.ID = int(id)
+		if err := insertMembers(tx, group); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, ActionCreate, EntityGroup, group.ID, nil, group)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return group.ID, nil
+}
+
+// UpdateGroup renames a group and replaces its members.
+func UpdateGroup(db *sql.DB, group models.Group, actor Actor) error {
+	group = normalizeGroup(group)
+	if group.Name == "" {
+		verr := &ValidationError{}
+		verr.Add("name", "is required")
+		return verr
+	}
+
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getGroup(tx, group.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkGroupName(tx, group.Name, group.ID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE groups SET name = ? WHERE id = ?`, group.Name, group.ID); err != nil {
+			return fmt.Errorf("failed to update group: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM group_members WHERE group_id = ?`, group.ID); err != nil {
+			return fmt.Errorf("failed to replace group members: %v", err)
+		}
+		if err := insertMembers(tx, group); err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, ActionUpdate, EntityGroup, group.ID, before, group)
+	})
+}
+
+// DeleteGroup deletes a group along with its challenges.
+func DeleteGroup(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getGroup(tx, id)
+		if err != nil {
+			return err
+		}
+		challenges, err := getChallenges(tx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`DELETE FROM challenges WHERE group_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete group challenges: %v", err)
+		}
+		for _, c := range challenges {
+			if err := recordAudit(tx, actor, ActionDelete, EntityChallenge, c.ID, c, nil); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM group_members WHERE group_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete group members: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM groups WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete group: %v", err)
+		}
+		return recordAudit(tx, actor, ActionDelete, EntityGroup, id, before, nil)
+	})
+}
+
+// JoinGroup adds a user to the end of a group's members. Joining twice does
+// nothing.
+func JoinGroup(db *sql.DB, id int, user string, actor Actor) error {
+	return changeMembers(db, id, actor, func(tx *sql.Tx) (int64, error) {
+		query := `INSERT OR IGNORE INTO group_members (group_id, position, athlete)
+			SELECT ?, coalesce(max(position) + 1, 0), ? FROM group_members WHERE group_id = ?`
+		result, err := tx.Exec(query, id, strings.TrimSpace(user), id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to join group: %v", err)
+		}
+		return result.RowsAffected()
+	})
+}
+
+// LeaveGroup removes a user from a group.
+func LeaveGroup(db *sql.DB, id int, user string, actor Actor) error {
+	return changeMembers(db, id, actor, func(tx *sql.Tx) (int64, error) {
+		result, err := tx.Exec(`DELETE FROM group_members WHERE group_id = ? AND athlete = ?`, id, strings.TrimSpace(user))
+		if err != nil {
+			return 0, fmt.Errorf("failed to leave group: %v", err)
+		}
+		n, err := result.RowsAffected()
+		if err == nil && n == 0 {
+			return 0, &NotFoundError{Resource: "group member", Key: fmt.Sprintf("%d/%s", id, user)}
+		}
+		return n, err
+	})
+}
+
+// changeMembers runs fn, which adds or removes members of a group and
+// reports how many, and audits the change if there was one.
+func changeMembers(db *sql.DB, id int, actor Actor, fn func(tx *sql.Tx) (int64, error)) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getGroup(tx, id)
+		if err != nil {
+			return err
+		}
+		n, err := fn(tx)
+		if err != nil || n == 0 {
+			return err
+		}
+		after, err := getGroup(tx, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, ActionUpdate, EntityGroup, id, before, after)
+	})
+}
+
+func loadMembers(q querier, group *models.Group) error {
+	rows, err := q.Query(`SELECT athlete FROM group_members WHERE group_id = ? ORDER BY position`, group.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch group members: %v", err)
+	}
+	defer rows.Close()
+
+	group.Members = []string{}
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			return fmt.Errorf("failed to scan group member: %v", err)
+		}
+		group.Members = append(group.Members, m)
+	}
+	return rows.Err()
+}
+
+func GetGroup(db *sql.DB, id int) (models.Group, error) {
+	return getGroup(db, id)
+}
+
+func getGroup(q querier, id int) (models.Group, error) {
+	group := models.Group{ID: id}
+	err := q.QueryRow(`SELECT name FROM groups WHERE id = ?`, id).Scan(&group.Name)
+	if err == sql.ErrNoRows {
+		return models.Group{}, &NotFoundError{Resource: "group", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.Group{}, fmt.Errorf("failed to fetch group: %v", err)
+	}
+	if err := loadMembers(q, &group); err != nil {
+		return models.Group{}, err
+	}
+	return group, nil
+}
+
+// GetGroups returns the groups member belongs to, or every group if member
+// is empty, ordered by name.
+func GetGroups(db *sql.DB, member string) ([]models.Group, error) {
+	query := `SELECT id, name FROM groups ORDER BY name`
+	args := []interface{}{}
+	if member != "" {
+		query = `SELECT id, name FROM groups WHERE id IN (SELECT group_id FROM group_members WHERE athlete = ?) ORDER BY name`
+		args = append(args, member)
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch groups: %v", err)
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		var g models.Group
+		if err := rows.Scan(&g.ID, &g.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan group row: %v", err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range groups {
+		if err := loadMembers(db, &groups[i]); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+func normalizeChallenge(c models.Challenge) models.Challenge {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Metric = strings.ToLower(strings.TrimSpace(c.Metric))
+	c.Exercise = strings.TrimSpace(c.Exercise)
+	c.StartDate = strings.ReplaceAll(strings.TrimSpace(c.StartDate), "-", "/")
+	c.EndDate = strings.ReplaceAll(strings.TrimSpace(c.EndDate), "-", "/")
+	c.Scoring = strings.ToLower(strings.TrimSpace(c.Scoring))
+	if c.Scoring == "" {
+		c.Scoring = models.ScoringTotal
+	}
+	if c.Metric != models.ChallengeRelativeE1RM {
+		c.Exercise = ""
+	}
+	return c
+}
+
+// validateChallenge checks a challenge's fields. Stored challenges also need
+// a name, ad hoc leaderboards do not.
+func validateChallenge(db *sql.DB, c models.Challenge, stored bool) error {
+	verr := &ValidationError{}
+	if stored && c.Name == "" {
+		verr.Add("name", "is required")
+	}
+	if !slices.Contains(models.ChallengeMetrics, c.Metric) {
+		verr.Add("metric", "must be one of %s", strings.Join(models.ChallengeMetrics, ", "))
+	}
+	if c.Metric == models.ChallengeRelativeE1RM && c.Exercise == "" {
+		verr.Add("exercise", "is required for %s challenges", models.ChallengeRelativeE1RM)
+	}
+	if !slices.Contains(models.ScoringMethods, c.Scoring) {
+		verr.Add("scoring", "must be one of %s", strings.Join(models.ScoringMethods, ", "))
+	}
+
+	start, startErr := time.Parse(models.DateLayout, c.StartDate)
+	if startErr != nil {
+		verr.Add("start_date", "must be a valid date in DD/MM/YYYY format")
+	}
+	end, err := time.Parse(models.DateLayout, c.EndDate)
+	switch {
+	case err != nil:
+		verr.Add("end_date", "must be a valid date in DD/MM/YYYY format")
+	case startErr == nil && end.Before(start):
+		verr.Add("end_date", "must not be before start_date")
+	case startErr == nil && end.Sub(start).Hours()/24 >= MaxChallengeDays:
+		verr.Add("end_date", "must be less than %d days after start_date", MaxChallengeDays)
+	}
+
+	var groups int
+	if err := db.QueryRow(`SELECT count(*) FROM groups WHERE id = ?`, c.GroupID).Scan(&groups); err != nil {
+		return fmt.Errorf("failed to check group: %v", err)
+	}
+	if groups == 0 {
+		verr.Add("group_id", "must be an existing group")
+	}
+	return verr.OrNil()
+}
+
+// CreateChallenge stores a new challenge and returns its ID.
+func CreateChallenge(db *sql.DB, c models.Challenge, actor Actor) (int, error) {
+	c = normalizeChallenge(c)
+	if err := validateChallenge(db, c, true); err != nil {
+		return 0, err
+	}
+
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		query := `INSERT INTO challenges (group_id, name, metric, exercise, start_date, end_date, scoring) VALUES (?, ?, ?, ?, ?, ?, ?)`
+		result, err := tx.Exec(query, c.GroupID, c.Name, c.Metric, c.Exercise, c.StartDate, c.EndDate, c.Scoring)
+		if err != nil {
+			return fmt.Errorf("failed to insert challenge: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve challenge ID: %v", err)
+		}
+		c.ID = int(id)
+		return recordAudit(tx, actor, ActionCreate, EntityChallenge, c.ID, nil, c)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return c.ID, nil
+}
+
+// UpdateChallenge replaces a challenge.
+func UpdateChallenge(db *sql.DB, c models.Challenge, actor Actor) error {
+	c = normalizeChallenge(c)
+	if err := validateChallenge(db, c, true); err != nil {
+		return err
+	}
+
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getChallenge(tx, c.ID)
+		if err != nil {
+			return err
+		}
+		query := `UPDATE challenges SET group_id = ?, name = ?, metric = ?, exercise = ?, start_date = ?, end_date = ?, scoring = ? WHERE id = ?`
+		if _, err := tx.Exec(query, c.GroupID, c.Name, c.Metric, c.Exercise, c.StartDate, c.EndDate, c.Scoring, c.ID); err != nil {
+			return fmt.Errorf("failed to update challenge: %v", err)
+		}
+		return recordAudit(tx, actor, ActionUpdate, EntityChallenge, c.ID, before, c)
+	})
+}
+
+func DeleteChallenge(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getChallenge(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM challenges WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete challenge: %v", err)
+		}
+		return recordAudit(tx, actor, ActionDelete, EntityChallenge, id, before, nil)
+	})
+}
+
+const challengeColumns = `id, group_id, name, metric, exercise, start_date, end_date, scoring`
+
+func scanChallenge(row rowScanner) (models.Challenge, error) {
+	var c models.Challenge
+	err := row.Scan(&c.ID, &c.GroupID, &c.Name, &c.Metric, &c.Exercise, &c.StartDate, &c.EndDate, &c.Scoring)
+	return c, err
+}
+
+func GetChallenge(db *sql.DB, id int) (models.Challenge, error) {
+	return getChallenge(db, id)
+}
+
+func getChallenge(q querier, id int) (models.Challenge, error) {
+	c, err := scanChallenge(q.QueryRow(`SELECT `+challengeColumns+` FROM challenges WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return models.Challenge{}, &NotFoundError{Resource: "challenge", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.Challenge{}, fmt.Errorf("failed to fetch challenge: %v", err)
+	}
+	return c, nil
+}
+
+// GetChallenges returns the challenges of a group, or of every group if
+// groupID is 0, latest start first.
+func GetChallenges(db *sql.DB, groupID int) ([]models.Challenge, error) {
+	return getChallenges(db, groupID)
+}
+
+func getChallenges(q querier, groupID int) ([]models.Challenge, error) {
+	query := `SELECT ` + challengeColumns + ` FROM challenges WHERE ? = 0 OR group_id = ?
+		ORDER BY ` + sortableColumn("start_date") + ` DESC, id`
+	rows, err := q.Query(query, groupID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch challenges: %v", err)
+	}
+	defer rows.Close()
+
+	challenges := []models.Challenge{}
+	for rows.Next() {
+		c, err := scanChallenge(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan challenge row: %v", err)
+		}
+		challenges = append(challenges, c)
+	}
+	return challenges, rows.Err()
+}
+
+// GetLeaderboard ranks the members of a challenge's group.
+func GetLeaderboard(db *sql.DB, id int) (models.Leaderboard, error) {
+	c, err := GetChallenge(db, id)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+	return leaderboard(db, c)
+}
+
+// GetGroupLeaderboard ranks the members of a group on a challenge that is
+// not stored, named after the group.
+func GetGroupLeaderboard(db *sql.DB, groupID int, c models.Challenge) (models.Leaderboard, error) {
+	group, err := GetGroup(db, groupID)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+	c.GroupID, c.Name = groupID, group.Name
+	c = normalizeChallenge(c)
+	if err := validateChallenge(db, c, false); err != nil {
+		return models.Leaderboard{}, err
+	}
+	return leaderboard(db, c)
+}
+
+// leaderboard scores every member of the challenge's group who has not
+// opted out of leaderboards. Improvement scoring compares the window with
+// the same number of days just before it; members with nothing to compare
+// against are unranked, as are members who never logged a bodyweight in a
+// relative e1RM challenge.
+func leaderboard(db *sql.DB, c models.Challenge) (models.Leaderboard, error) {
+	board := models.Leaderboard{Challenge: c, Entries: []models.LeaderboardEntry{}}
+	group, err := GetGroup(db, c.GroupID)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+	optedOut, err := leaderboardOptOuts(db)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+	members := map[string]bool{}
+	for _, m := range group.Members {
+		if optedOut[m] {
+			board.Hidden++
+		} else {
+			members[m] = true
+		}
+	}
+
+	lc, err := newLoadingContext(db)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+	start, _ := time.Parse(models.DateLayout, c.StartDate)
+	end, _ := time.Parse(models.DateLayout, c.EndDate)
+	values, err := challengeValues(db, lc, c, members, start, end)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+	var baselines map[string]float64
+	if c.Scoring == models.ScoringImprovement {
+		days := int(end.Sub(start).Hours()/24) + 1
+		baselines, err = challengeValues(db, lc, c, members, start.AddDate(0, 0, -days), start.AddDate(0, 0, -1))
+		if err != nil {
+			return models.Leaderboard{}, err
+		}
+	}
+
+	for _, m := range group.Members {
+		if !members[m] {
+			continue
+		}
+		e := models.LeaderboardEntry{Athlete: m, Value: values[m], Score: values[m]}
+		if c.Scoring == models.ScoringImprovement {
+			e.Baseline, e.Score = baselines[m], 0
+			if e.Baseline > 0 {
+				e.Score = round2((e.Value - e.Baseline) / e.Baseline * 100)
+			} else {
+				e.Unranked = models.UnrankedNoBaseline
+			}
+		}
+		if c.Metric == models.ChallengeRelativeE1RM {
+			if bw, ok := lc.bodyweightOn(m, c.EndDate); !ok || bw <= 0 {
+				e.Unranked = models.UnrankedNoBodyweight
+			}
+		}
+		board.Entries = append(board.Entries, e)
+	}
+
+	ranked := func(e models.LeaderboardEntry) bool {
+		return e.Unranked == ""
+	}
+	sort.Slice(board.Entries, func(i, j int) bool {
+		a, b := board.Entries[i], board.Entries[j]
+		if ranked(a) != ranked(b) {
+			return ranked(a)
+		}
+		return a.Score > b.Score || (a.Score == b.Score && a.Athlete < b.Athlete)
+	})
+	for i := range board.Entries {
+		e := &board.Entries[i]
+		switch {
+		case !ranked(*e):
+		case i > 0 && board.Entries[i-1].Score == e.Score:
+			e.Rank = board.Entries[i-1].Rank
+		default:
+			e.Rank = i + 1
+		}
+	}
+	return board, nil
+}
+
+// leaderboardOptOuts returns the users who asked to be left out of
+// leaderboards.
+func leaderboardOptOuts(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT user FROM user_settings WHERE leaderboard_opt_out = 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch leaderboard opt-outs: %v", err)
+	}
+	defer rows.Close()
+
+	users := map[string]bool{}
+	for rows.Next() {
+		var user string
+		if err := rows.Scan(&user); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard opt-out: %v", err)
+		}
+		users[user] = true
+	}
+	return users, rows.Err()
+}
+
+// challengeValues measures a challenge's metric for each member over the
+// days from start to end. Relative e1RMs use the athlete's bodyweight on the
+// day of each lift; athletes who never logged one have no value.
+func challengeValues(db *sql.DB, lc *loadingContext, c models.Challenge, members map[string]bool, start, end time.Time) (map[string]float64, error) {
+	workouts, err := workoutsBetween(db, "", start.Format(models.DateLayout), end.Format(models.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]float64{}
+	for _, w := range workouts {
+		if !members[w.Athlete] {
+			continue
+		}
+		switch c.Metric {
+		case models.ChallengeSessions:
+			values[w.Athlete]++
+		case models.ChallengeTonnage:
+			for i := range w.Lifts {
+				if lift := GetLift(w, i); !lift.WarmUp {
+					values[w.Athlete] += liftTonnage(lc.effectiveLift(w.Athlete, w.Date, lift))
+				}
+			}
+		case models.ChallengeRelativeE1RM:
+			bw, ok := lc.bodyweightOn(w.Athlete, w.Date)
+			if !ok || bw <= 0 {
+				continue
+			}
+			for i := range w.Lifts {
+				lift := GetLift(w, i)
+				if lift.WarmUp || !strings.EqualFold(strings.TrimSpace(lift.Name), c.Exercise) {
+					continue
+				}
+				values[w.Athlete] = max(values[w.Athlete], LiftOneRepMax(lc.effectiveLift(w.Athlete, w.Date, lift))/bw)
+			}
+		}
+	}
+	for m, v := range values {
+		values[m] = round2(v)
+	}
+	return values, nil
+}
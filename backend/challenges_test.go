@@ -0,0 +1,129 @@
+package backend
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"fitness-dev/models"
+)
+
+func TestGroupLeaderboard(t *testing.T) {
+	db := newTestDB(t)
+	groupID, err := CreateGroup(db, models.Group{Name: "Crew", Members: []string{"alice", "bob", "cara", "dan"}}, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logSquat(t, db, "alice", "09/09/2026", 80, 5)
+	logSquat(t, db, "alice", "10/10/2026", 100, 5)
+	logSquat(t, db, "bob", "11/10/2026", 120, 5)
+	logSquat(t, db, "cara", "12/10/2026", 100, 5)
+	logSquat(t, db, "dan", "13/10/2026", 200, 5)
+	for _, athlete := range []string{"alice", "cara", "dan"} {
+		m := models.Measurement{Athlete: athlete, Date: "01/09/2026", Metric: models.MetricBodyweight, Value: 80, Unit: models.UnitKilogram}
+		if _, err := LogMeasurement(db, m, testActor); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SaveSettings(db, models.Settings{User: "dan", Units: models.UnitKilogram, LeaderboardOptOut: true}, testActor); err != nil {
+		t.Fatal(err)
+	}
+
+	october := models.Challenge{StartDate: "01/10/2026", EndDate: "31/10/2026"}
+	tests := []struct {
+		name      string
+		challenge func(c models.Challenge) models.Challenge
+		want      []string
+	}{
+		{
+			name: "tonnage",
+			challenge: func(c models.Challenge) models.Challenge {
+				c.Metric = models.ChallengeTonnage
+				return c
+			},
+			want: []string{"1 bob 1800", "2 alice 1500", "2 cara 1500"},
+		},
+		{
+			name: "sessions",
+			challenge: func(c models.Challenge) models.Challenge {
+				c.Metric = models.ChallengeSessions
+				return c
+			},
+			want: []string{"1 alice 1", "1 bob 1", "1 cara 1"},
+		},
+		{
+			name: "relative e1RM",
+			challenge: func(c models.Challenge) models.Challenge {
+				c.Metric, c.Exercise = models.ChallengeRelativeE1RM, "squat"
+				return c
+			},
+			want: []string{"1 alice 1.46", "1 cara 1.46", "0 bob 0 no_bodyweight"},
+		},
+		{
+			name: "relative e1RM improvement",
+			challenge: func(c models.Challenge) models.Challenge {
+				c.Metric, c.Exercise, c.Scoring = models.ChallengeRelativeE1RM, "Squat", models.ScoringImprovement
+				return c
+			},
+			want: []string{"1 alice 24.79", "0 bob 0 no_bodyweight", "0 cara 0 no_baseline"},
+		},
+		{
+			name: "tonnage improvement",
+			challenge: func(c models.Challenge) models.Challenge {
+				c.Metric, c.Scoring = models.ChallengeTonnage, models.ScoringImprovement
+				return c
+			},
+			want: []string{"1 alice 25", "0 bob 0 no_baseline", "0 cara 0 no_baseline"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			board, err := GetGroupLeaderboard(db, groupID, tt.challenge(october))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range board.Entries {
+				entry := fmt.Sprintf("%d %s %g", e.Rank, e.Athlete, e.Score)
+				if e.Unranked != "" {
+					entry += " " + e.Unranked
+				}
+				got = append(got, entry)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %q, want %q", got, tt.want)
+			}
+			if board.Hidden != 1 {
+				t.Errorf("hidden = %d, want the one member who opted out", board.Hidden)
+			}
+		})
+	}
+}
+
+func TestGroupLeaderboardValidation(t *testing.T) {
+	db := newTestDB(t)
+	groupID, err := CreateGroup(db, models.Group{Name: "Crew", Members: []string{"alice"}}, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		challenge models.Challenge
+		want      []string
+	}{
+		{"unknown metric", models.Challenge{Metric: "steps", StartDate: "01/10/2026", EndDate: "31/10/2026"}, []string{"metric"}},
+		{"no exercise", models.Challenge{Metric: models.ChallengeRelativeE1RM, StartDate: "01/10/2026", EndDate: "31/10/2026"}, []string{"exercise"}},
+		{"ends first", models.Challenge{Metric: models.ChallengeSessions, StartDate: "31/10/2026", EndDate: "01/10/2026"}, []string{"end_date"}},
+		{"too long", models.Challenge{Metric: models.ChallengeSessions, StartDate: "01/01/2026", EndDate: "02/01/2027"}, []string{"end_date"}},
+		{"bad scoring", models.Challenge{Metric: models.ChallengeSessions, Scoring: "best", StartDate: "01/10/2026", EndDate: "31/10/2026"}, []string{"scoring"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GetGroupLeaderboard(db, groupID, tt.challenge)
+			if got := invalidFields(t, err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("invalid fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
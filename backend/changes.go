@@ -0,0 +1,32 @@
+package backend
+
+import (
+	"database/sql"
+
+	"fitness-dev/models"
+)
+
+// recordChange is called inside the transaction of every workout change. It
+// appends to the audit log, keeps data derived from workouts in step and
+// awards badges to workouts as they are completed. before and after are the
+// workout as it was and as it is now; either may be nil.
+func recordChange(tx *sql.Tx, actor Actor, action string, workoutID int, before, after *models.Workout) error {
+	if err := recordAudit(tx, actor, action, EntityWorkout, workoutID, before, after); err != nil {
+		return err
+	}
+
+	for _, w := range []*models.Workout{before, after} {
+		if w == nil {
+			continue
+		}
+		if err := refreshDailyLoad(tx, w.Athlete, w.Date); err != nil {
+			return err
+		}
+	}
+
+	if after != nil && after.DeletedAt == "" && after.Status == models.StatusCompleted &&
+		(before == nil || before.Status != models.StatusCompleted) {
+		return awardAchievements(tx, workoutID, *after)
+	}
+	return nil
+}
@@ -0,0 +1,431 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"fitness-dev/models"
+)
+
+// MaxPlatePairs is the most pairs of one plate an equipment profile can list.
+const MaxPlatePairs = 20
+
+// weightEpsilon absorbs floating point error when matching plate weights.
+const weightEpsilon = 1e-6
+
+// barbellWarmUp ramps from the empty bar to the working weight, each step a
+// share of the working weight rounded down to what the plates can make.
+var barbellWarmUp = []struct {
+	percent float64
+	reps    int
+	sets    int
+}{
+	{0, 10, 2}, // empty bar
+	{0.40, 5, 1},
+	{0.60, 3, 1},
+	{0.80, 2, 1},
+}
+
+// dumbbellWarmUp is shorter, as dumbbells only come in fixed steps.
+var dumbbellWarmUp = []struct {
+	percent float64
+	reps    int
+	sets    int
+}{
+	{0.50, 8, 1},
+	{0.75, 4, 1},
+}
+
+// DefaultEquipment is a typical home gym in the user's unit, used until they
+// save their own profile. Their smallest plate is included if it is lighter
+// than the ones listed.
+func DefaultEquipment(s models.Settings) models.Equipment {
+	eq := models.Equipment{User: s.User, Units: s.Units}
+	if s.Units == models.UnitPound {
+		eq.Bars = []models.Bar{{Name: "Barbell", Weight: 45}, {Name: "EZ bar", Weight: 25}}
+		eq.Plates = []models.PlatePair{{Weight: 45, Pairs: 4}, {Weight: 35, Pairs: 1}, {Weight: 25, Pairs: 2}, {Weight: 10, Pairs: 2}, {Weight: 5, Pairs: 2}, {Weight: 2.5, Pairs: 2}}
+		eq.DumbbellIncrement = 5
+	} else {
+		eq.Units = models.UnitKilogram
+		eq.Bars = []models.Bar{{Name: "Barbell", Weight: 20}, {Name: "EZ bar", Weight: 10}}
+		eq.Plates = []models.PlatePair{{Weight: 25, Pairs: 4}, {Weight: 20, Pairs: 1}, {Weight: 15, Pairs: 1}, {Weight: 10, Pairs: 2}, {Weight: 5, Pairs: 2}, {Weight: 2.5, Pairs: 2}, {Weight: 1.25, Pairs: 2}}
+		eq.DumbbellIncrement = 2
+	}
+	if smallest := eq.Plates[len(eq.Plates)-1].Weight; s.SmallestPlate > 0 && s.SmallestPlate < smallest {
+		eq.Plates = append(eq.Plates, models.PlatePair{Weight: s.SmallestPlate, Pairs: 2})
+	}
+	return eq
+}
+
+func validateEquipment(eq models.Equipment) error {
+	verr := &ValidationError{}
+	if eq.Units != models.UnitKilogram && eq.Units != models.UnitPound {
+		verr.Add("units", "must be %s or %s", models.UnitKilogram, models.UnitPound)
+	}
+	maxWeight := ConvertWeight(MaxWeight, models.UnitKilogram, eq.Units)
+
+	if len(eq.Bars) == 0 {
+		verr.Add("bars", "at least one bar is required")
+	}
+	names := map[string]bool{}
+	for i, bar := range eq.Bars {
+		key := strings.ToLower(bar.Name)
+		if bar.Name == "" {
+			verr.Add(fmt.Sprintf("bars[%d].name", i), "is required")
+		} else if names[key] {
+			verr.Add(fmt.Sprintf("bars[%d].name", i), "%q is listed twice", bar.Name)
+		}
+		names[key] = true
+		if bar.Weight < 0 || bar.Weight > maxWeight {
+			verr.Add(fmt.Sprintf("bars[%d].weight", i), "must be between 0 and %g", math.Round(maxWeight))
+		}
+	}
+
+	weights := map[float64]bool{}
+	for i, p := range eq.Plates {
+		if p.Weight <= 0 || p.Weight > maxWeight {
+			verr.Add(fmt.Sprintf("plates[%d].weight", i), "must be greater than 0 and at most %g", math.Round(maxWeight))
+		} else if weights[p.Weight] {
+			verr.Add(fmt.Sprintf("plates[%d].weight", i), "%g is listed twice", p.Weight)
+		}
+		weights[p.Weight] = true
+		if p.Pairs < 1 || p.Pairs > MaxPlatePairs {
+			verr.Add(fmt.Sprintf("plates[%d].pairs", i), "must be between 1 and %d", MaxPlatePairs)
+		}
+	}
+
+	if eq.DumbbellIncrement < 0 || eq.DumbbellIncrement > maxWeight {
+		verr.Add("dumbbell_increment", "must be between 0 and %g", math.Round(maxWeight))
+	}
+	return verr.OrNil()
+}
+
+// GetEquipment returns a user's equipment profile, or the default one for
+// their settings if they have not saved one.
+func GetEquipment(db *sql.DB, user string) (models.Equipment, error) {
+	return getEquipment(db, user)
+}
+
+func getEquipment(q querier, user string) (models.Equipment, error) {
+	eq := models.Equipment{User: user}
+	err := q.QueryRow(`SELECT units, dumbbell_increment FROM equipment WHERE user = ?`, user).Scan(&eq.Units, &eq.DumbbellIncrement)
+	if err == sql.ErrNoRows {
+		settings, err := getSettings(q, user)
+		if err != nil {
+			return models.Equipment{}, err
+		}
+		return DefaultEquipment(settings), nil
+	}
+	if err != nil {
+		return models.Equipment{}, fmt.Errorf("failed to fetch equipment: %v", err)
+	}
+
+	rows, err := q.Query(`SELECT name, weight FROM equipment_bars WHERE user = ? ORDER BY position`, user)
+	if err != nil {
+		return models.Equipment{}, fmt.Errorf("failed to fetch bars: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bar models.Bar
+		if err := rows.Scan(&bar.Name, &bar.Weight); err != nil {
+			return models.Equipment{}, fmt.Errorf("failed to scan bar row: %v", err)
+		}
+		eq.Bars = append(eq.Bars, bar)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Equipment{}, fmt.Errorf("failed to fetch bars: %v", err)
+	}
+	rows.Close()
+
+	rows, err = q.Query(`SELECT weight, pairs FROM equipment_plates WHERE user = ? ORDER BY weight DESC`, user)
+	if err != nil {
+		return models.Equipment{}, fmt.Errorf("failed to fetch plates: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p models.PlatePair
+		if err := rows.Scan(&p.Weight, &p.Pairs); err != nil {
+			return models.Equipment{}, fmt.Errorf("failed to scan plate row: %v", err)
+		}
+		eq.Plates = append(eq.Plates, p)
+	}
+	return eq, rows.Err()
+}
+
+// SaveEquipment replaces a user's equipment profile. An empty unit is taken
+// from their settings.
+func SaveEquipment(db *sql.DB, eq models.Equipment, actor Actor) error {
+	eq.User = strings.TrimSpace(eq.User)
+	if eq.User == "" {
+		verr := &ValidationError{}
+		verr.Add("user", "is required")
+		return verr
+	}
+	units, err := ValidateUnits(eq.Units)
+	if err != nil {
+		return err
+	}
+	if units == "" {
+		settings, err := GetSettings(db, eq.User)
+		if err != nil {
+			return err
+		}
+		units = settings.Units
+	}
+	eq.Units = units
+	for i := range eq.Bars {
+		eq.Bars[i].Name = strings.TrimSpace(eq.Bars[i].Name)
+	}
+	if err := validateEquipment(eq); err != nil {
+		return err
+	}
+
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getEquipment(tx, eq.User)
+		if err != nil {
+			return err
+		}
+
+		query := `INSERT INTO equipment (user, units, dumbbell_increment) VALUES (?, ?, ?)
+			ON CONFLICT (user) DO UPDATE SET units = excluded.units, dumbbell_increment = excluded.dumbbell_increment`
+		if _, err := tx.Exec(query, eq.User, eq.Units, eq.DumbbellIncrement); err != nil {
+			return fmt.Errorf("failed to save equipment: %v", err)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM equipment_bars WHERE user = ?`, eq.User); err != nil {
+			return fmt.Errorf("failed to delete bars: %v", err)
+		}
+		for i, bar := range eq.Bars {
+			_, err := tx.Exec(`INSERT INTO equipment_bars (user, position, name, weight) VALUES (?, ?, ?, ?)`, eq.User, i, bar.Name, bar.Weight)
+			if err != nil {
+				return fmt.Errorf("failed to insert bar: %v", err)
+			}
+		}
+
+		if _, err := tx.Exec(`DELETE FROM equipment_plates WHERE user = ?`, eq.User); err != nil {
+			return fmt.Errorf("failed to delete plates: %v", err)
+		}
+		for _, p := range eq.Plates {
+			_, err := tx.Exec(`INSERT INTO equipment_plates (user, weight, pairs) VALUES (?, ?, ?)`, eq.User, p.Weight, p.Pairs)
+			if err != nil {
+				return fmt.Errorf("failed to insert plate: %v", err)
+			}
+		}
+
+		after, err := getEquipment(tx, eq.User)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, ActionUpdate, EntityEquipment, eq.User, before, after)
+	})
+}
+
+// findBar returns the named bar, or the first one if name is empty.
+func findBar(eq models.Equipment, name string) (models.Bar, error) {
+	if len(eq.Bars) == 0 {
+		return models.Bar{}, &NotFoundError{Resource: "bar", Key: name}
+	}
+	if name == "" {
+		return eq.Bars[0], nil
+	}
+	for _, bar := range eq.Bars {
+		if strings.EqualFold(bar.Name, strings.TrimSpace(name)) {
+			return bar, nil
+		}
+	}
+	return models.Bar{}, &NotFoundError{Resource: "bar", Key: name}
+}
+
+// LoadPlates works out the plates per side for target, in the equipment's
+// unit, on the named bar.
+func LoadPlates(eq models.Equipment, barName string, target float64) (models.PlateLoading, error) {
+	bar, err := findBar(eq, barName)
+	if err != nil {
+		return models.PlateLoading{}, err
+	}
+	if target < bar.Weight-weightEpsilon {
+		verr := &ValidationError{}
+		verr.Add("weight", "must be at least the %g%s %s", bar.Weight, eq.Units, bar.Name)
+		return models.PlateLoading{}, verr
+	}
+	return loadBar(eq, bar, target), nil
+}
+
+// loadBar finds the heaviest load up to target the plates can make on bar,
+// with the fewest plates that make it. Every combination of the pairs on hand
+// is tried, as taking the heaviest plate first can miss a load: with one 20
+// and two 15s, 30 a side is 15 + 15, not 20 and whatever is left.
+func loadBar(eq models.Equipment, bar models.Bar, target float64) models.PlateLoading {
+	plates := append([]models.PlatePair(nil), eq.Plates...)
+	sort.Slice(plates, func(i, j int) bool { return plates[i].Weight > plates[j].Weight })
+
+	// Loads a side can take, keyed by their weight in ten-thousandths
+	remaining := (target - bar.Weight) / 2
+	sides := map[int64][]float64{0: {}}
+	for _, p := range plates {
+		if p.Weight <= 0 {
+			continue
+		}
+		var loads []int64
+		for load := range sides {
+			loads = append(loads, load)
+		}
+		for _, load := range loads {
+			side := sides[load]
+			for n := 1; n <= p.Pairs; n++ {
+				weight := plateSum(side) + float64(n)*p.Weight
+				if weight > remaining+weightEpsilon {
+					break
+				}
+				next := append(append([]float64(nil), side...), slices.Repeat([]float64{p.Weight}, n)...)
+				key := int64(math.Round(weight * 1e4))
+				if current, ok := sides[key]; !ok || fewerPlates(next, current) {
+					sides[key] = next
+				}
+			}
+		}
+	}
+
+	best := int64(0)
+	for load := range sides {
+		best = max(best, load)
+	}
+	loading := models.PlateLoading{Units: eq.Units, Target: round2(target), Bar: bar, PerSide: sides[best]}
+	side := plateSum(loading.PerSide)
+	loading.Total = round2(bar.Weight + 2*side)
+	loading.Exact = math.Abs(remaining-side) < weightEpsilon
+	return loading
+}
+
+func plateSum(plates []float64) float64 {
+	sum := 0.0
+	for _, p := range plates {
+		sum += p
+	}
+	return sum
+}
+
+// fewerPlates reports whether a is a better way to load a side than b: fewer
+// plates, or as many but heavier ones first. Both are heaviest first.
+func fewerPlates(a, b []float64) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return false
+}
+
+// WarmUpRamp builds warm-up sets for a working weight given in units. The
+// sets are returned in units; barbell loadings are in the equipment's unit.
+func WarmUpRamp(eq models.Equipment, req models.WarmUpRequest, units string) (models.WarmUp, error) {
+	if units == "" {
+		units = models.UnitKilogram
+	}
+	implement := strings.ToLower(strings.TrimSpace(req.Implement))
+	if implement == "" {
+		implement = models.ImplementBarbell
+	}
+
+	verr := &ValidationError{}
+	if implement != models.ImplementBarbell && implement != models.ImplementDumbbell {
+		verr.Add("implement", "must be %s or %s", models.ImplementBarbell, models.ImplementDumbbell)
+	}
+	if req.Weight <= 0 {
+		verr.Add("weight", "must be greater than 0")
+	}
+	if implement == models.ImplementDumbbell && eq.DumbbellIncrement <= 0 {
+		verr.Add("implement", "the equipment profile has no dumbbells")
+	}
+	if err := verr.OrNil(); err != nil {
+		return models.WarmUp{}, err
+	}
+
+	ramp := models.WarmUp{Exercise: strings.TrimSpace(req.Exercise), Implement: implement, Units: units, Working: round2(req.Weight), Sets: []models.WarmUpSet{}}
+	working := ConvertWeight(req.Weight, units, eq.Units)
+	previous := 0.0
+
+	if implement == models.ImplementDumbbell {
+		for _, step := range dumbbellWarmUp {
+			w := math.Floor(working*step.percent/eq.DumbbellIncrement+weightEpsilon) * eq.DumbbellIncrement
+			if w <= previous || w >= working-weightEpsilon {
+				continue
+			}
+			ramp.Sets = append(ramp.Sets, models.WarmUpSet{Weight: round2(ConvertWeight(w, eq.Units, units)), Reps: step.reps, Sets: step.sets})
+			previous = w
+		}
+		return ramp, nil
+	}
+
+	bar, err := findBar(eq, req.Bar)
+	if err != nil {
+		return models.WarmUp{}, err
+	}
+	if working < bar.Weight-weightEpsilon {
+		verr.Add("weight", "must be at least the %g%s %s", bar.Weight, eq.Units, bar.Name)
+		return models.WarmUp{}, verr
+	}
+	for _, step := range barbellWarmUp {
+		loading := loadBar(eq, bar, math.Max(bar.Weight, working*step.percent))
+		if loading.Total <= previous || loading.Total >= working-weightEpsilon {
+			continue
+		}
+		ramp.Sets = append(ramp.Sets, models.WarmUpSet{Weight: round2(ConvertWeight(loading.Total, eq.Units, units)), Reps: step.reps, Sets: step.sets, Loading: &loading})
+		previous = loading.Total
+	}
+	return ramp, nil
+}
+
+// AddWarmUp inserts a warm-up ramp before the first set of req.Exercise in a
+// workout being done, marked as warm-ups. Without a working weight the
+// exercise's first set is used, or its target if nothing was lifted yet. The warm-ups are entered in the equipment's
+// unit, so plate totals are kept exactly; req.Weight is in units.
+func AddWarmUp(db *sql.DB, workoutID, version int, eq models.Equipment, req models.WarmUpRequest, units string, actor Actor) (models.Workout, error) {
+	if strings.TrimSpace(req.Exercise) == "" {
+		verr := &ValidationError{}
+		verr.Add("exercise", "is required")
+		return models.Workout{}, verr
+	}
+	if req.Weight != 0 {
+		req.Weight = ConvertWeight(req.Weight, units, eq.Units)
+	}
+
+	return modifyWorkout(db, workoutID, version, eq.Units, actor, func(workout *models.Workout) error {
+		if workout.Status != models.StatusInProgress {
+			return &ConflictError{Message: fmt.Sprintf("workout %d is %s; warm-ups can only be added to a workout in progress", workoutID, workout.Status)}
+		}
+		index := -1
+		for i, name := range workout.Lifts {
+			if strings.EqualFold(name, strings.TrimSpace(req.Exercise)) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return &NotFoundError{Resource: "lift", Key: fmt.Sprintf("%s in workout %d", req.Exercise, workoutID)}
+		}
+		if req.Weight == 0 && index < len(workout.Weight) {
+			req.Weight = workout.Weight[index]
+		}
+		if req.Weight == 0 && index < len(workout.TargetWeight) {
+			req.Weight = workout.TargetWeight[index]
+		}
+
+		ramp, err := WarmUpRamp(eq, req, eq.Units)
+		if err != nil {
+			return err
+		}
+		name := workout.Lifts[index]
+		for i, set := range ramp.Sets {
+			insertLift(workout, index+i, models.Lift{Name: name, Weight: set.Weight, Reps: set.Reps, Sets: set.Sets, WarmUp: true})
+		}
+		return nil
+	})
+}
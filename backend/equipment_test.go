@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"fitness-dev/models"
+)
+
+func TestLoadPlates(t *testing.T) {
+	home := DefaultEquipment(kgPlates)
+	// One 20 and two 15s: greedy picking would load 20 + 5 for 30 a side
+	few := models.Equipment{
+		Units:  models.UnitKilogram,
+		Bars:   []models.Bar{{Name: "Barbell", Weight: 20}},
+		Plates: []models.PlatePair{{Weight: 20, Pairs: 1}, {Weight: 15, Pairs: 2}, {Weight: 5, Pairs: 1}},
+	}
+
+	tests := []struct {
+		name    string
+		eq      models.Equipment
+		target  float64
+		perSide []float64
+		total   float64
+		exact   bool
+	}{
+		{"empty bar", home, 20, []float64{}, 20, true},
+		{"exact", home, 142.5, []float64{25, 25, 10, 1.25}, 142.5, true},
+		{"fewest plates", home, 60, []float64{20}, 60, true},
+		{"rounded down to the smallest plate", home, 101, []float64{25, 15}, 100, false},
+		{"heavier than the plates on hand", home, 500, []float64{25, 25, 25, 25, 20, 15, 10, 10, 5, 5, 2.5, 2.5, 1.25, 1.25}, 365, false},
+		{"limited pairs", few, 80, []float64{15, 15}, 80, true},
+		{"limited pairs unreachable", few, 44, []float64{5}, 30, false},
+		{"lb", DefaultEquipment(lbPlates), 315, []float64{45, 45, 45}, 315, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadPlates(tt.eq, "", tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.PerSide, tt.perSide) || got.Total != tt.total || got.Exact != tt.exact {
+				t.Errorf("LoadPlates(%g) = %v per side, %g total, exact %v; want %v, %g, %v",
+					tt.target, got.PerSide, got.Total, got.Exact, tt.perSide, tt.total, tt.exact)
+			}
+		})
+	}
+
+	_, err := LoadPlates(home, "", 15)
+	if got := invalidFields(t, err); !reflect.DeepEqual(got, []string{"weight"}) {
+		t.Errorf("lighter than the bar: invalid fields = %v, want [weight]", got)
+	}
+	var notFound *NotFoundError
+	if _, err := LoadPlates(home, "Trap bar", 100); !errors.As(err, &notFound) {
+		t.Errorf("unknown bar: error = %v, want a *NotFoundError", err)
+	}
+}
+
+func TestWarmUpRamp(t *testing.T) {
+	kgHome := DefaultEquipment(kgPlates)
+	few := models.Equipment{
+		Units:  models.UnitKilogram,
+		Bars:   []models.Bar{{Name: "Barbell", Weight: 20}},
+		Plates: []models.PlatePair{{Weight: 20, Pairs: 1}, {Weight: 15, Pairs: 2}, {Weight: 5, Pairs: 1}},
+	}
+
+	tests := []struct {
+		name  string
+		eq    models.Equipment
+		req   models.WarmUpRequest
+		units string
+		want  []string // weight reps x sets
+	}{
+		{"barbell", kgHome, models.WarmUpRequest{Weight: 100}, models.UnitKilogram,
+			[]string{"20 10x2", "40 5x1", "60 3x1", "80 2x1"}},
+		{"rounded down to the plates", kgHome, models.WarmUpRequest{Weight: 105}, models.UnitKilogram,
+			[]string{"20 10x2", "40 5x1", "62.5 3x1", "82.5 2x1"}},
+		{"limited pairs", few, models.WarmUpRequest{Weight: 100}, models.UnitKilogram,
+			[]string{"20 10x2", "30 5x1", "60 3x1", "80 2x1"}},
+		{"lb equipment", DefaultEquipment(lbPlates), models.WarmUpRequest{Weight: 225}, models.UnitPound,
+			[]string{"45 10x2", "90 5x1", "135 3x1", "180 2x1"}},
+		{"lb request on kg plates", kgHome, models.WarmUpRequest{Weight: 220.46226}, models.UnitPound,
+			[]string{"44.09 10x2", "88.18 5x1", "132.28 3x1", "176.37 2x1"}},
+		{"light working weight", kgHome, models.WarmUpRequest{Weight: 30}, models.UnitKilogram,
+			[]string{"20 10x2", "22.5 2x1"}},
+		{"dumbbell", kgHome, models.WarmUpRequest{Weight: 30, Implement: models.ImplementDumbbell}, models.UnitKilogram,
+			[]string{"14 8x1", "22 4x1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ramp, err := WarmUpRamp(tt.eq, tt.req, tt.units)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, set := range ramp.Sets {
+				got = append(got, fmt.Sprintf("%g %dx%d", set.Weight, set.Reps, set.Sets))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sets = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name string
+		req  models.WarmUpRequest
+		want []string
+	}{
+		{"no weight", models.WarmUpRequest{}, []string{"weight"}},
+		{"lighter than the bar", models.WarmUpRequest{Weight: 15}, []string{"weight"}},
+		{"unknown implement", models.WarmUpRequest{Weight: 100, Implement: "kettlebell"}, []string{"implement"}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := WarmUpRamp(kgHome, tt.req, models.UnitKilogram)
+			if got := invalidFields(t, err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("invalid fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddWarmUp(t *testing.T) {
+	db := newTestDB(t)
+	eq := DefaultEquipment(kgPlates)
+	started := models.Workout{Athlete: "sam", Date: "12/10/2026", TimeIn: "07:00", Status: models.StatusInProgress,
+		Lifts: []string{"Squat"}, Weight: []float64{0}, Reps: []int{0}, Sets: []int{0},
+		TargetWeight: []float64{100}, TargetReps: []int{5}, TargetSets: []int{3}}
+	id, err := InsertWorkout(db, started, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing lifted yet, so the ramp leads up to the target
+	workout, err := AddWarmUp(db, id, 0, eq, models.WarmUpRequest{Exercise: "squat"}, models.UnitKilogram, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(workout.Weight, []float64{20, 40, 60, 80, 0}) || !reflect.DeepEqual(workout.WarmUp, []bool{true, true, true, true, false}) {
+		t.Errorf("weights %v, warm-ups %v, want the ramp to 100 before the working set", workout.Weight, workout.WarmUp)
+	}
+
+	completed := logSquat(t, db, "sam", "11/10/2026", 100, 5)
+	var conflict *ConflictError
+	if _, err := AddWarmUp(db, completed, 0, eq, models.WarmUpRequest{Exercise: "Squat"}, models.UnitKilogram, testActor); !errors.As(err, &conflict) {
+		t.Errorf("completed workout: error = %v, want a *ConflictError", err)
+	}
+}
@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotFoundError is returned when the requested row does not exist.
+type NotFoundError struct {
+	Resource string
+	Key      string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %s not found", e.Resource, e.Key)
+}
+
+// FieldError describes a single invalid field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when input data fails validation.
+// It carries every field problem found, not just the first one.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", f.Field, f.Message))
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Add records a field problem.
+func (e *ValidationError) Add(field, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// OrNil returns the error if any field problems were recorded, nil otherwise.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ConflictError is returned when a write clashes with existing data.
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+// PreconditionFailedError is returned when a write was made against a stale
+// version of a workout.
+type PreconditionFailedError struct {
+	Current int
+	Given   int
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("workout has been modified: current version is %d, got %d", e.Current, e.Given)
+}
@@ -0,0 +1,316 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"fitness-dev/models"
+)
+
+func validateExercise(exercise models.Exercise) error {
+	verr := &ValidationError{}
+	if exercise.Name == "" {
+		verr.Add("name", "is required")
+	}
+	valid := false
+	for _, l := range models.LoadingTypes {
+		if l == exercise.Loading {
+			valid = true
+		}
+	}
+	if !valid {
+		verr.Add("loading", "must be one of %s", strings.Join(models.LoadingTypes, ", "))
+	}
+	if valid && exercise.Loading != models.LoadingExternal && (exercise.BodyweightFraction <= 0 || exercise.BodyweightFraction > 1) {
+		verr.Add("bodyweight_fraction", "must be greater than 0 and at most 1")
+	}
+	return verr.OrNil()
+}
+
+// GetExercises returns the stored exercise definitions ordered by name.
+func GetExercises(db *sql.DB) ([]models.Exercise, error) {
+	return loadExercises(db)
+}
+
+func loadExercises(q querier) ([]models.Exercise, error) {
+	rows, err := q.Query(`SELECT name, loading, bodyweight_fraction FROM exercises ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exercises: %v", err)
+	}
+	defer rows.Close()
+
+	var exercises []models.Exercise
+	for rows.Next() {
+		var e models.Exercise
+		if err := rows.Scan(&e.Name, &e.Loading, &e.BodyweightFraction); err != nil {
+			return nil, fmt.Errorf("failed to scan exercise row: %v", err)
+		}
+		exercises = append(exercises, e)
+	}
+	return exercises, rows.Err()
+}
+
+// SetExercise creates or replaces an exercise definition. Training loads of
+// workouts containing the exercise are recomputed.
+func SetExercise(db *sql.DB, exercise models.Exercise, actor Actor) error {
+	exercise.Name = strings.TrimSpace(exercise.Name)
+	exercise.Loading = strings.ToLower(strings.TrimSpace(exercise.Loading))
+	if exercise.Loading == models.LoadingExternal {
+		exercise.BodyweightFraction = 0
+	}
+	if err := validateExercise(exercise); err != nil {
+		return err
+	}
+
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		var before *models.Exercise
+		existing := models.Exercise{}
+		err := tx.QueryRow(`SELECT name, loading, bodyweight_fraction FROM exercises WHERE name = ?`, exercise.Name).
+			Scan(&existing.Name, &existing.Loading, &existing.BodyweightFraction)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to fetch exercise: %v", err)
+		}
+		action := ActionCreate
+		if err == nil {
+			before, action = &existing, ActionUpdate
+		}
+
+		query := `INSERT INTO exercises (name, loading, bodyweight_fraction) VALUES (?, ?, ?)
+			ON CONFLICT (name) DO UPDATE SET loading = excluded.loading, bodyweight_fraction = excluded.bodyweight_fraction`
+		if _, err := tx.Exec(query, exercise.Name, exercise.Loading, exercise.BodyweightFraction); err != nil {
+			return fmt.Errorf("failed to store exercise: %v", err)
+		}
+		if err := recordAudit(tx, actor, action, EntityExercise, exercise.Name, before, exercise); err != nil {
+			return err
+		}
+		return refreshDailyLoads(tx, `id IN (SELECT workout_id FROM lifts WHERE name = ? COLLATE NOCASE)`, exercise.Name)
+	})
+}
+
+// loadingContext works out the effective load of lifts from exercise loading
+// types and each athlete's bodyweight on the day.
+type loadingContext struct {
+	exercises  map[string]models.Exercise // keyed by lower case name
+	bodyweight map[string][]bodyweightOn  // keyed by athlete, oldest first
+}
+
+type bodyweightOn struct {
+	day    string // YYYYMMDD
+	weight float64
+}
+
+func newLoadingContext(q querier) (*loadingContext, error) {
+	exercises, err := loadExercises(q)
+	if err != nil {
+		return nil, err
+	}
+	lc := &loadingContext{exercises: map[string]models.Exercise{}, bodyweight: map[string][]bodyweightOn{}}
+	for _, e := range exercises {
+		lc.exercises[strings.ToLower(e.Name)] = e
+	}
+
+	rows, err := q.Query(`SELECT athlete, day, value, unit FROM measurements WHERE metric = ?`, models.MetricBodyweight)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bodyweight: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var athlete, day, unit string
+		var weight float64
+		if err := rows.Scan(&athlete, &day, &weight, &unit); err != nil {
+			return nil, fmt.Errorf("failed to scan bodyweight row: %v", err)
+		}
+		lc.bodyweight[athlete] = append(lc.bodyweight[athlete], bodyweightOn{sortableDate(day), convertUnit(weight, unit, models.UnitKilogram)})
+	}
+	for _, entries := range lc.bodyweight {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].day < entries[j].day })
+	}
+	return lc, rows.Err()
+}
+
+// bodyweightOn returns the athlete's latest bodyweight on or before day, or
+// their first entry if day predates it.
+func (lc *loadingContext) bodyweightOn(athlete, day string) (float64, bool) {
+	entries := lc.bodyweight[athlete]
+	if len(entries) == 0 {
+		return 0, false
+	}
+	key := sortableDate(day)
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].day > key })
+	if i == 0 {
+		return entries[0].weight, true
+	}
+	return entries[i-1].weight, true
+}
+
+// loading returns the loading type of an exercise.
+func (lc *loadingContext) loading(name string) models.Exercise {
+	if e, ok := lc.exercises[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return e
+	}
+	return models.Exercise{Name: name, Loading: models.LoadingExternal}
+}
+
+// effectiveLift returns lift with Weight replaced by the load actually moved.
+// Without any logged bodyweight the logged weight is used as is.
+func (lc *loadingContext) effectiveLift(athlete, day string, lift models.Lift) models.Lift {
+	e := lc.loading(lift.Name)
+	if e.Loading == models.LoadingExternal {
+		return lift
+	}
+	bw, ok := lc.bodyweightOn(athlete, day)
+	if !ok {
+		return lift
+	}
+
+	body := bw * e.BodyweightFraction
+	switch e.Loading {
+	case models.LoadingBodyweight:
+		lift.Weight = body
+	case models.LoadingBodyweightPlus:
+		lift.Weight = body + lift.Weight
+	case models.LoadingAssisted:
+		lift.Weight = max(body-lift.Weight, 0)
+	}
+	lift.Weight = round2(lift.Weight)
+	return lift
+}
+
+// liftTonnage is the volume of a lift: weight x reps x sets.
+func liftTonnage(lift models.Lift) float64 {
+	return lift.Weight * float64(lift.Reps*lift.Sets)
+}
+
+// GetPersonalRecords returns an athlete's heaviest effective lift and best
+// estimated 1RM per exercise, ordered by exercise name. Each e1RM is scored
+// against the athlete's bodyweight; sex is male, female or empty to leave
+// out the Wilks and DOTS scores.
+func GetPersonalRecords(db *sql.DB, athlete, sex string) ([]models.PersonalRecord, error) {
+	sex, err := ValidateSex(sex)
+	if err != nil {
+		return nil, err
+	}
+	lc, err := newLoadingContext(db)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT w.day, l.name, l.weight, l.reps, l.rpe, l.rir FROM lifts l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE w.athlete = ? AND w.deleted_at IS NULL AND w.status = 'completed' AND l.warm_up = 0
+		ORDER BY ` + sortableDay + `, w.time_in, l.id`
+	rows, err := db.Query(query, athlete)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch lift history: %v", err)
+	}
+	defer rows.Close()
+
+	records := map[string]*models.PersonalRecord{}
+	for rows.Next() {
+		var day string
+		var lift models.Lift
+		var rir sql.NullInt64
+		if err := rows.Scan(&day, &lift.Name, &lift.Weight, &lift.Reps, &lift.RPE, &rir); err != nil {
+			return nil, fmt.Errorf("failed to scan lift history: %v", err)
+		}
+		lift.RIR = nullableInt(rir)
+		lift = lc.effectiveLift(athlete, day, lift)
+
+		key := strings.ToLower(strings.TrimSpace(lift.Name))
+		r, ok := records[key]
+		if !ok {
+			r = &models.PersonalRecord{Exercise: lift.Name, Loading: lc.loading(lift.Name).Loading}
+			records[key] = r
+		}
+		if lift.Weight > r.Weight || (lift.Weight == r.Weight && lift.Reps > r.Reps) || r.Date == "" {
+			r.Weight, r.Reps, r.Date = lift.Weight, lift.Reps, day
+		}
+		if e := round2(LiftOneRepMax(lift)); e > r.E1RM {
+			r.E1RM, r.E1RMDate = e, day
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := []models.PersonalRecord{}
+	for _, r := range records {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool { return strings.ToLower(result[i].Exercise) < strings.ToLower(result[j].Exercise) })
+	scoreRecords(lc, athlete, sex, result)
+	return result, nil
+}
+
+// RecordLifts reports which lifts of each workout set a personal record: an
+// effective e1RM above the athlete's best in every earlier workout of that
+// exercise. The first time an exercise is logged is not a record. Results
+// are keyed by workout ID, with one entry per lift.
+func RecordLifts(db *sql.DB, workouts []models.Workout) (map[int][]bool, error) {
+	records := map[int][]bool{}
+	athletes := map[string]bool{}
+	for _, w := range workouts {
+		records[w.ID] = make([]bool, len(w.Lifts))
+		athletes[w.Athlete] = true
+	}
+	if len(workouts) == 0 {
+		return records, nil
+	}
+	lc, err := newLoadingContext(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for athlete := range athletes {
+		query := `SELECT w.id, w.day, l.name, l.weight, l.reps, l.rpe, l.rir, l.warm_up FROM lifts l
+			JOIN workouts w ON w.id = l.workout_id
+			WHERE w.athlete = ? AND w.deleted_at IS NULL AND w.status = 'completed'
+			ORDER BY ` + sortableDay + `, w.time_in, w.id, l.id`
+		rows, err := db.Query(query, athlete)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch lift history: %v", err)
+		}
+
+		best := map[string]float64{}    // before the current workout
+		current := map[string]float64{} // within it
+		lastWorkout, index := -1, 0
+		for rows.Next() {
+			var workoutID int
+			var day string
+			var lift models.Lift
+			var rir sql.NullInt64
+			if err := rows.Scan(&workoutID, &day, &lift.Name, &lift.Weight, &lift.Reps, &lift.RPE, &rir, &lift.WarmUp); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan lift history: %v", err)
+			}
+			lift.RIR = nullableInt(rir)
+			if workoutID != lastWorkout {
+				for key, e := range current {
+					best[key] = max(best[key], e)
+				}
+				clear(current)
+				lastWorkout, index = workoutID, 0
+			}
+			// Warm-ups keep their place in the workout but never set records
+			if lift.WarmUp {
+				index++
+				continue
+			}
+
+			key := strings.ToLower(strings.TrimSpace(lift.Name))
+			e := round2(LiftOneRepMax(lc.effectiveLift(athlete, day, lift)))
+			previous, logged := best[key]
+			if marks, ok := records[workoutID]; ok && index < len(marks) {
+				marks[index] = logged && e > previous
+			}
+			current[key] = max(current[key], e)
+			index++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
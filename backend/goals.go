@@ -0,0 +1,426 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// Limits and windows used when tracking goals.
+const (
+	MaxWeeklySessions = 21
+	goalBaselineDays  = 28 // how far before a goal's start its baseline is looked for
+	goalTrendWeeks    = 12 // the trend is fitted on at most this many recent weeks
+)
+
+// goalPoint is one value of a goal's measure on a day.
+type goalPoint struct {
+	day   time.Time
+	value float64
+}
+
+func normalizeGoal(goal models.Goal) models.Goal {
+	goal.Athlete = strings.TrimSpace(goal.Athlete)
+	goal.Kind = strings.ToLower(strings.TrimSpace(goal.Kind))
+	goal.Exercise = strings.TrimSpace(goal.Exercise)
+	goal.StartDate = strings.ReplaceAll(strings.TrimSpace(goal.StartDate), "-", "/")
+	goal.Deadline = strings.ReplaceAll(strings.TrimSpace(goal.Deadline), "-", "/")
+	if goal.StartDate == "" {
+		goal.StartDate = time.Now().Format(models.DateLayout)
+	}
+	if goal.Kind == models.GoalKindFrequency || goal.Kind == models.GoalKindBodyweight {
+		goal.Exercise = ""
+	}
+	return goal
+}
+
+func validateGoal(goal models.Goal) error {
+	verr := &ValidationError{}
+	if goal.Athlete == "" {
+		verr.Add("athlete", "is required")
+	}
+	if !slices.Contains(models.GoalKinds, goal.Kind) {
+		verr.Add("kind", "must be one of %s", strings.Join(models.GoalKinds, ", "))
+	}
+	if goal.Kind == models.GoalKindE1RM && goal.Exercise == "" {
+		verr.Add("exercise", "is required for %s goals", models.GoalKindE1RM)
+	}
+
+	switch {
+	case goal.Kind == models.GoalKindFrequency && (goal.Target <= 0 || goal.Target > MaxWeeklySessions):
+		verr.Add("target", "must be greater than 0 and at most %d sessions per week", MaxWeeklySessions)
+	case goal.Kind == models.GoalKindVolume && goal.Target <= 0:
+		verr.Add("target", "must be greater than 0")
+	case (goal.Kind == models.GoalKindE1RM || goal.Kind == models.GoalKindBodyweight) && (goal.Target <= 0 || goal.Target > MaxWeight):
+		verr.Add("target", "must be greater than 0 and at most %g", MaxWeight)
+	}
+
+	start, startErr := time.Parse(models.DateLayout, goal.StartDate)
+	if startErr != nil {
+		verr.Add("start_date", "must be a valid date in DD/MM/YYYY format")
+	}
+	if goal.Deadline != "" {
+		deadline, err := time.Parse(models.DateLayout, goal.Deadline)
+		if err != nil {
+			verr.Add("deadline", "must be a valid date in DD/MM/YYYY format")
+		} else if startErr == nil && !deadline.After(start) {
+			verr.Add("deadline", "must be after start_date")
+		}
+	}
+	return verr.OrNil()
+}
+
+// CreateGoal stores a new goal and returns its ID.
+func CreateGoal(db *sql.DB, goal models.Goal, actor Actor) (int, error) {
+	goal = normalizeGoal(goal)
+	if err := validateGoal(goal); err != nil {
+		return 0, err
+	}
+
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		query := `INSERT INTO goals (athlete, kind, exercise, target, start_date, deadline) VALUES (?, ?, ?, ?, ?, ?)`
+		result, err := tx.Exec(query, goal.Athlete, goal.Kind, goal.Exercise, goal.Target, goal.StartDate, goal.Deadline)
+		if err != nil {
+			return fmt.Errorf("failed to insert goal: %v", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve goal ID: %v", err)
+		}
+		goal.ID = int(id)
+		return recordAudit(tx, actor, ActionCreate, EntityGoal, goal.ID, nil, goal)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return goal.ID, nil
+}
+
+// UpdateGoal replaces a goal.
+func UpdateGoal(db *sql.DB, goal models.Goal, actor Actor) error {
+	goal = normalizeGoal(goal)
+	if err := validateGoal(goal); err != nil {
+		return err
+	}
+
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getGoal(tx, goal.ID)
+		if err != nil {
+			return err
+		}
+		query := `UPDATE goals SET athlete = ?, kind = ?, exercise = ?, target = ?, start_date = ?, deadline = ? WHERE id = ?`
+		if _, err := tx.Exec(query, goal.Athlete, goal.Kind, goal.Exercise, goal.Target, goal.StartDate, goal.Deadline, goal.ID); err != nil {
+			return fmt.Errorf("failed to update goal: %v", err)
+		}
+		return recordAudit(tx, actor, ActionUpdate, EntityGoal, goal.ID, before, goal)
+	})
+}
+
+func DeleteGoal(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getGoal(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM goals WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete goal: %v", err)
+		}
+		return recordAudit(tx, actor, ActionDelete, EntityGoal, id, before, nil)
+	})
+}
+
+const goalColumns = `id, athlete, kind, exercise, target, start_date, deadline`
+
+func scanGoal(row rowScanner) (models.Goal, error) {
+	var g models.Goal
+	err := row.Scan(&g.ID, &g.Athlete, &g.Kind, &g.Exercise, &g.Target, &g.StartDate, &g.Deadline)
+	return g, err
+}
+
+func GetGoal(db *sql.DB, id int) (models.Goal, error) {
+	return getGoal(db, id)
+}
+
+func getGoal(q querier, id int) (models.Goal, error) {
+	goal, err := scanGoal(q.QueryRow(`SELECT `+goalColumns+` FROM goals WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return models.Goal{}, &NotFoundError{Resource: "goal", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.Goal{}, fmt.Errorf("failed to fetch goal: %v", err)
+	}
+	return goal, nil
+}
+
+// GetGoals returns an athlete's goals, soonest deadline first and goals
+// without one last.
+func GetGoals(db *sql.DB, athlete string) ([]models.Goal, error) {
+	query := `SELECT ` + goalColumns + ` FROM goals WHERE athlete = ?
+		ORDER BY deadline = '', ` + sortableColumn("deadline") + `, id`
+	rows, err := db.Query(query, athlete)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch goals: %v", err)
+	}
+	defer rows.Close()
+
+	goals := []models.Goal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal row: %v", err)
+		}
+		goals = append(goals, goal)
+	}
+	return goals, rows.Err()
+}
+
+// GetGoalProgress reports on one goal.
+func GetGoalProgress(db *sql.DB, id int) (models.GoalProgress, error) {
+	goal, err := GetGoal(db, id)
+	if err != nil {
+		return models.GoalProgress{}, err
+	}
+	return goalProgress(db, goal, truncateDay(time.Now()))
+}
+
+// GetGoalsProgress reports on every goal of an athlete.
+func GetGoalsProgress(db *sql.DB, athlete string) ([]models.GoalProgress, error) {
+	goals, err := GetGoals(db, athlete)
+	if err != nil {
+		return nil, err
+	}
+	today := truncateDay(time.Now())
+	progress := make([]models.GoalProgress, len(goals))
+	for i, goal := range goals {
+		if progress[i], err = goalProgress(db, goal, today); err != nil {
+			return nil, err
+		}
+	}
+	return progress, nil
+}
+
+// goalProgress measures a goal from its baseline, the last value on or
+// before its start, to its latest value, and projects when the target will
+// be reached from a regression over recent values.
+func goalProgress(db *sql.DB, goal models.Goal, today time.Time) (models.GoalProgress, error) {
+	report := models.GoalProgress{Goal: goal, Status: models.GoalNoData}
+	start, _ := time.Parse(models.DateLayout, goal.StartDate)
+	since := start.AddDate(0, 0, -goalBaselineDays)
+	if trendStart := today.AddDate(0, 0, -7*goalTrendWeeks); trendStart.Before(since) {
+		since = trendStart
+	}
+
+	points, err := goalSeries(db, goal, since, today)
+	if err != nil {
+		return models.GoalProgress{}, err
+	}
+	if len(points) == 0 {
+		return report, nil
+	}
+
+	report.Baseline = points[0].value
+	for _, p := range points {
+		if p.day.After(start) {
+			break
+		}
+		report.Baseline = p.value
+	}
+	report.Current = points[len(points)-1].value
+
+	// Bodyweight may be lost rather than gained; every other measure is
+	// meant to go up
+	direction := 1.0
+	if goal.Kind == models.GoalKindBodyweight && goal.Target < report.Baseline {
+		direction = -1
+	}
+	achieved := (report.Current-goal.Target)*direction >= 0
+	if span := goal.Target - report.Baseline; span != 0 {
+		report.Progress = round2(math.Min(math.Max((report.Current-report.Baseline)/span*100, 0), 100))
+	}
+
+	var recent []goalPoint
+	for _, p := range points {
+		if !p.day.Before(today.AddDate(0, 0, -7*goalTrendWeeks)) {
+			recent = append(recent, p)
+		}
+	}
+	report.Points = len(recent)
+	intercept, slope, fitted := linearFit(recent)
+	report.Trend = round2(slope * 7)
+
+	deadline, deadlineErr := time.Parse(models.DateLayout, goal.Deadline)
+	hasDeadline := goal.Deadline != "" && deadlineErr == nil
+	switch {
+	case achieved:
+		report.Status = models.GoalAchieved
+		report.Progress = 100
+		return report, nil
+	case hasDeadline && deadline.Before(today):
+		report.Status = models.GoalMissed
+	default:
+		report.Status = models.GoalBehind
+	}
+
+	if fitted && slope*direction > 0 {
+		// Days from the first point at which the fitted line reaches the target
+		reach := (goal.Target - intercept) / slope
+		projected := recent[0].day.AddDate(0, 0, int(math.Ceil(reach)))
+		if projected.Before(today) {
+			projected = today
+		}
+		report.ProjectedDate = projected.Format(models.DateLayout)
+		if report.Status == models.GoalBehind && (!hasDeadline || !projected.After(deadline)) {
+			report.Status = models.GoalOnTrack
+		}
+	}
+	return report, nil
+}
+
+// linearFit fits value = intercept + slope * days since the first point by
+// least squares. It needs at least two points on different days.
+func linearFit(points []goalPoint) (intercept, slope float64, ok bool) {
+	if len(points) < 2 {
+		return 0, 0, false
+	}
+	n := float64(len(points))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.day.Sub(points[0].day).Hours() / 24
+		sumX += x
+		sumY += p.value
+		sumXY += x * p.value
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, 0, false
+	}
+	slope = (n*sumXY - sumX*sumY) / denominator
+	intercept = (sumY - slope*sumX) / n
+	return intercept, slope, true
+}
+
+// goalSeries returns the values a goal is measured by from since to today,
+// oldest first.
+func goalSeries(db *sql.DB, goal models.Goal, since, today time.Time) ([]goalPoint, error) {
+	switch goal.Kind {
+	case models.GoalKindE1RM:
+		return e1rmSeries(db, goal.Athlete, goal.Exercise, since)
+	case models.GoalKindBodyweight:
+		return bodyweightSeries(db, goal.Athlete, since)
+	default:
+		return weeklySeries(db, goal, since, today)
+	}
+}
+
+// e1rmSeries is the best estimated 1RM of an exercise to date after each
+// session from since, using effective load and the same RPE/RIR-aware
+// estimate as personal records, so the latest value matches /records.
+func e1rmSeries(db *sql.DB, athlete, exercise string, since time.Time) ([]goalPoint, error) {
+	lc, err := newLoadingContext(db)
+	if err != nil {
+		return nil, err
+	}
+	query := `SELECT w.id, w.day, l.name, l.weight, l.reps, l.rpe, l.rir FROM lifts l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE w.athlete = ? AND l.name = ? COLLATE NOCASE AND w.deleted_at IS NULL AND w.status = 'completed' AND l.warm_up = 0
+		ORDER BY ` + sortableDay + `, w.time_in, l.id`
+	rows, err := db.Query(query, athlete, exercise)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch lift history: %v", err)
+	}
+	defer rows.Close()
+
+	var points []goalPoint
+	best := 0.0
+	lastWorkout := -1
+	for rows.Next() {
+		var workoutID int
+		var day string
+		var lift models.Lift
+		var rir sql.NullInt64
+		if err := rows.Scan(&workoutID, &day, &lift.Name, &lift.Weight, &lift.Reps, &lift.RPE, &rir); err != nil {
+			return nil, fmt.Errorf("failed to scan lift history: %v", err)
+		}
+		lift.RIR = nullableInt(rir)
+		best = math.Max(best, round2(LiftOneRepMax(lc.effectiveLift(athlete, day, lift))))
+		t, err := time.Parse(models.DateLayout, day)
+		if err != nil || t.Before(since) {
+			continue
+		}
+		if workoutID == lastWorkout {
+			points[len(points)-1].value = best
+			continue
+		}
+		points = append(points, goalPoint{t, best})
+		lastWorkout = workoutID
+	}
+	return points, rows.Err()
+}
+
+// bodyweightSeries is every bodyweight logged, in kg.
+func bodyweightSeries(db *sql.DB, athlete string, since time.Time) ([]goalPoint, error) {
+	measurements, err := GetMeasurements(db, athlete, models.MetricBodyweight, since.Format(models.DateLayout), "")
+	if err != nil {
+		return nil, err
+	}
+	points := make([]goalPoint, 0, len(measurements))
+	for _, m := range measurements {
+		t, err := time.Parse(models.DateLayout, m.Date)
+		if err != nil {
+			continue
+		}
+		points = append(points, goalPoint{t, round2(convertUnit(m.Value, m.Unit, models.UnitKilogram))})
+	}
+	return points, nil
+}
+
+// weeklySeries counts sessions, or sums effective tonnage, in each completed
+// seven day week from since up to yesterday. Each point falls on the last day
+// of its week; the week in progress is left out so it does not read low.
+func weeklySeries(db *sql.DB, goal models.Goal, since, today time.Time) ([]goalPoint, error) {
+	n := int(today.Sub(since).Hours()/24) / 7
+	if n < 1 {
+		return nil, nil
+	}
+	first := today.AddDate(0, 0, -7*n)
+	lc, err := newLoadingContext(db)
+	if err != nil {
+		return nil, err
+	}
+	workouts, err := workoutsBetween(db, goal.Athlete, first.Format(models.DateLayout), today.AddDate(0, 0, -1).Format(models.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]goalPoint, n)
+	for i := range points {
+		points[i].day = first.AddDate(0, 0, 7*i+6)
+	}
+	for _, w := range workouts {
+		day, err := time.Parse(models.DateLayout, w.Date)
+		if err != nil {
+			continue
+		}
+		i := int(day.Sub(first).Hours()/24) / 7
+		if goal.Kind == models.GoalKindFrequency {
+			points[i].value++
+			continue
+		}
+		for j := range w.Lifts {
+			lift := GetLift(w, j)
+			if !lift.WarmUp && (goal.Exercise == "" || strings.EqualFold(lift.Name, goal.Exercise)) {
+				points[i].value += liftTonnage(lc.effectiveLift(w.Athlete, w.Date, lift))
+			}
+		}
+	}
+	for i := range points {
+		points[i].value = round2(points[i].value)
+	}
+	return points, nil
+}
@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func TestLinearFit(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	line := func(days ...int) []goalPoint {
+		var points []goalPoint
+		for _, d := range days {
+			points = append(points, goalPoint{day.AddDate(0, 0, d), 100 + 2*float64(d)})
+		}
+		return points
+	}
+	tests := []struct {
+		name             string
+		points           []goalPoint
+		intercept, slope float64
+		ok               bool
+	}{
+		{"no points", nil, 0, 0, false},
+		{"one point", line(0), 0, 0, false},
+		{"one day", []goalPoint{{day, 100}, {day, 110}}, 0, 0, false},
+		{"a line", line(0, 3, 7, 14), 100, 2, true},
+		{"later start", line(7, 14), 114, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intercept, slope, ok := linearFit(tt.points)
+			if ok != tt.ok || math.Abs(intercept-tt.intercept) > 1e-9 || math.Abs(slope-tt.slope) > 1e-9 {
+				t.Errorf("linearFit() = %v, %v, %v, want %v, %v, %v", intercept, slope, ok, tt.intercept, tt.slope, tt.ok)
+			}
+		})
+	}
+}
+
+func TestE1RMGoalMatchesRecords(t *testing.T) {
+	db := newTestDB(t)
+	one := 1
+	workouts := []models.Workout{
+		{Date: "01/10/2026", Lifts: []string{"Squat"}, Weight: []float64{105}, Reps: []int{6}, Sets: []int{1}, RIR: []*int{&one}},
+		{Date: "05/10/2026", Lifts: []string{"Squat"}, Weight: []float64{110}, Reps: []int{5}, Sets: []int{1}},
+	}
+	for _, w := range workouts {
+		w.TimeIn, w.TimeOut, w.MoodIn, w.MoodOut, w.Athlete = "07:00", "08:00", "Good", "Good", "sam"
+		if _, err := InsertWorkout(db, w, testActor); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goal := models.Goal{Athlete: "sam", Kind: models.GoalKindE1RM, Exercise: "Squat", Target: 150, StartDate: "03/10/2026"}
+	progress, err := goalProgress(db, goal, time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := GetPersonalRecords(db, "sam", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("records = %+v, want one", records)
+	}
+
+	// The later, lighter-estimated session does not lower the best to date
+	if progress.Current != records[0].E1RM || progress.Current != 129.5 {
+		t.Errorf("current = %v, records e1RM = %v, want both 129.5", progress.Current, records[0].E1RM)
+	}
+	if progress.Baseline != 129.5 {
+		t.Errorf("baseline = %v, want 129.5", progress.Baseline)
+	}
+}
@@ -0,0 +1,37 @@
+package backend
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"fitness-dev/models"
+)
+
+var testActor = Actor{Name: "test", Source: SourceCLI}
+
+// newTestDB opens a fresh database in a temporary directory, closed when the
+// test ends.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := DbInitAt(filepath.Join(t.TempDir(), "fitness.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// logSquat logs a completed workout of three sets of squats for athlete and
+// returns its ID.
+func logSquat(t *testing.T, db *sql.DB, athlete, day string, weight float64, reps int) int {
+	t.Helper()
+	id, err := InsertWorkout(db, models.Workout{
+		Date: day, TimeIn: "07:00", TimeOut: "08:00", MoodIn: "Good", MoodOut: "Good", Athlete: athlete,
+		Lifts: []string{"Squat"}, Weight: []float64{weight}, Reps: []int{reps}, Sets: []int{3},
+	}, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
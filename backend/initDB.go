@@ -10,25 +10,31 @@ import (
 )
 
 func DbInit() (*sql.DB, error) {
+	return DbInitAt("fitness.db")
+}
+
+// DbInitAt opens or creates the database file at path and brings it up to
+// the current schema.
+func DbInitAt(path string) (*sql.DB, error) {
 	dbExists := false
 	
 	// Check if the file exists
-	if _, err := os.Stat("fitness.db"); !os.IsNotExist(err) {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
 		dbExists = true
-		log.Println("Using existing database file fitness.db")
+		log.Printf("Using existing database file %s", path)
 	} else {
-		log.Println("Database file does not exist. Creating fitness.db...")
+		log.Printf("Database file does not exist. Creating %s...", path)
 	}
 
 	// Open or create the database
-	db, err := sql.Open("sqlite3", "fitness.db")
+	db, err := sql.Open("sqlite3", path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
 
 	// Ensure the database file is actually created
 	if !dbExists {
-		file, err := os.Create("fitness.db")
+		file, err := os.Create(path)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create database file: %v", err)
 		}
@@ -86,6 +92,11 @@ func DbInit() (*sql.DB, error) {
 		log.Println("Tables already exist. Skipping creation.")
 	}
 
+	// Bring older databases up to the current schema
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %v", err)
+	}
+
 	log.Println("Database initialized successfully!")
 	return db, nil
 }
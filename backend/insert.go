@@ -3,6 +3,8 @@ package backend
 import (
 	"database/sql"
 	"fmt"
+	"time"
+
 	"fitness-dev/models"
 )
 
@@ -23,71 +25,153 @@ func executeInTransaction(db *sql.DB, fn func(tx *sql.Tx) error) error {
 	}()
 
 	// Execute the function
-	if err := fn(tx); err != nil {
+	if err = fn(tx); err != nil {
 		return err
 	}
 
 	// Commit transaction
-	if err := tx.Commit(); err != nil {
+	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %v", err)
 	}
 
 	return nil
 }
 
-func validateWorkoutForInsert(workout models.Workout) error {
-	if workout.Date == "" {
-		return fmt.Errorf("date is required")
+// InsertWorkout stores a new workout and returns its ID. Workouts without an
+// athlete are recorded against the actor creating them, and workouts without
+// a status as completed.
+func InsertWorkout(db *sql.DB, workout models.Workout, actor Actor) (int, error) {
+	var id int
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		var err error
+		id, err = insertWorkout(tx, workout, actor)
+		return err
+	})
+	if err != nil {
+		return 0, err
 	}
-	if workout.TimeIn == "" {
-		return fmt.Errorf("time_in is required")
+	return id, nil
+}
+
+// insertWorkout is InsertWorkout within tx, for callers that write more
+// alongside the workout.
+func insertWorkout(tx *sql.Tx, workout models.Workout, actor Actor) (int, error) {
+	workout = NormalizeWorkout(workout)
+	if workout.Athlete == "" {
+		workout.Athlete = actor.Name
 	}
-	if workout.TimeOut == "" {
-		return fmt.Errorf("time_out is required")
+	if workout.Status == "" {
+		workout.Status = models.StatusCompleted
 	}
-	if workout.MoodIn == "" {
-		return fmt.Errorf("mood_in is required")
+	if err := ValidateWorkout(workout); err != nil {
+		return 0, err
 	}
-	if workout.MoodOut == "" {
-		return fmt.Errorf("mood_out is required")
+
+	workoutQuery := `INSERT INTO workouts (day, time_in, time_out, mood_in, mood_out, athlete, session_rpe, status, version, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?)`
+	result, err := tx.Exec(workoutQuery, workout.Date, workout.TimeIn, workout.TimeOut, workout.MoodIn, workout.MoodOut, workout.Athlete, workout.SessionRPE, workout.Status, now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert workout: %v", err)
 	}
-	if len(workout.Lifts) == 0 {
-		return fmt.Errorf("at least one lift is required")
+
+	workoutID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve workout ID: %v", err)
 	}
-	return nil
-}
+	id := int(workoutID)
 
-func InsertWorkout(db *sql.DB, workout models.Workout) error {
-	if err := validateWorkoutForInsert(workout); err != nil {
-		return err
+	if err := insertLifts(tx, id, workout); err != nil {
+		return 0, err
+	}
+	if err := insertCardio(tx, id, workout); err != nil {
+		return 0, err
 	}
 
-	return executeInTransaction(db, func(tx *sql.Tx) error {
-		workoutQuery := `INSERT INTO workouts (day, time_in, time_out, mood_in, mood_out) VALUES (?, ?, ?, ?, ?)`
-		result, err := tx.Exec(workoutQuery, workout.Date, workout.TimeIn, workout.TimeOut, workout.MoodIn, workout.MoodOut)
-		if err != nil {
-			return fmt.Errorf("failed to insert workout: %v", err)
-		}
+	after, err := auditSnapshot(tx, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordChange(tx, actor, ActionCreate, id, nil, after); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
 
-		workoutID, err := result.LastInsertId()
+func insertLifts(tx *sql.Tx, workoutID int, workout models.Workout) error {
+	liftQuery := `INSERT INTO lifts (workout_id, name, weight, reps, sets, rpe, rir, tempo, rest, unit, target_weight, target_reps, target_sets, warm_up)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	units := entryUnits(workout)
+	for i := 0; i < len(workout.Lifts); i++ {
+		lift := GetLift(workout, i)
+		_, err := tx.Exec(liftQuery, workoutID, lift.Name, lift.Weight, lift.Reps, lift.Sets, lift.RPE, lift.RIR, lift.Tempo, lift.Rest, units[i],
+			lift.TargetWeight, lift.TargetReps, lift.TargetSets, lift.WarmUp)
 		if err != nil {
-			return fmt.Errorf("failed to retrieve workout ID: %v", err)
+			return fmt.Errorf("failed to insert lift: %v", err)
 		}
+	}
+	return nil
+}
 
-		liftQuery := `INSERT INTO lifts (workout_id, name, weight, reps, sets) VALUES (?, ?, ?, ?, ?)`
-		for i := 0; i < len(workout.Lifts); i++ {
-			_, err = tx.Exec(liftQuery, workoutID, workout.Lifts[i], workout.Weight[i], workout.Reps[i], workout.Sets[i])
-			if err != nil {
-				return fmt.Errorf("failed to insert lift: %v", err)
-			}
-		}
+// now returns the current time in the format stored in updated_at columns.
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
 
-		return nil
-	})
+// checkVersion resolves a write that matched no rows into either a missing
+// workout or a stale version.
+func checkVersion(tx *sql.Tx, workoutID, given int) error {
+	var current int
+	err := tx.QueryRow(`SELECT version FROM workouts WHERE id = ? AND deleted_at IS NULL`, workoutID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return &NotFoundError{Resource: "workout", Key: fmt.Sprint(workoutID)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch workout version: %v", err)
+	}
+	return &PreconditionFailedError{Current: current, Given: given}
 }
 
-func UpdateWorkout(db *sql.DB, workout models.Workout) error {
+// replaceWorkout overwrites a workout row and all of its lifts and cardio. The write only
+// succeeds if the stored version still matches workout.Version.
+func replaceWorkout(tx *sql.Tx, workout models.Workout) error {
+	if workout.Status == "" {
+		workout.Status = models.StatusCompleted
+	}
+	workoutQuery := `UPDATE workouts SET day = ?, time_in = ?, time_out = ?, mood_in = ?, mood_out = ?, athlete = ?, session_rpe = ?, status = ?, version = version + 1, updated_at = ? WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	result, err := tx.Exec(workoutQuery, workout.Date, workout.TimeIn, workout.TimeOut, workout.MoodIn, workout.MoodOut, workout.Athlete, workout.SessionRPE, workout.Status, now(), workout.ID, workout.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update workout: %v", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return checkVersion(tx, workout.ID, workout.Version)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM lifts WHERE workout_id = ?`, workout.ID); err != nil {
+		return fmt.Errorf("failed to delete lifts: %v", err)
+	}
+	if err := insertLifts(tx, workout.ID, workout); err != nil {
+		return err
+	}
+	if err := deleteCardio(tx, `id = ?`, workout.ID); err != nil {
+		return err
+	}
+	return insertCardio(tx, workout.ID, workout)
+}
+
+func UpdateWorkout(db *sql.DB, workout models.Workout, actor Actor) error {
+	workout = NormalizeWorkout(workout)
+	if err := ValidateWorkoutUpdate(workout); err != nil {
+		return err
+	}
+
 	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getWorkoutByID(tx, workout.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkTransition(before, workout.Status); err != nil {
+			return err
+		}
+
 		workoutQuery := `UPDATE workouts SET `
 		var args []interface{}
 		if workout.Date != "" {
@@ -110,16 +194,36 @@ func UpdateWorkout(db *sql.DB, workout models.Workout) error {
 			workoutQuery += `mood_out = ?, `
 			args = append(args, workout.MoodOut)
 		}
+		if workout.Athlete != "" {
+			workoutQuery += `athlete = ?, `
+			args = append(args, workout.Athlete)
+		}
+		if workout.SessionRPE != 0 {
+			workoutQuery += `session_rpe = ?, `
+			args = append(args, workout.SessionRPE)
+		}
+		if workout.Status != "" {
+			workoutQuery += `status = ?, `
+			args = append(args, workout.Status)
+		}
 
-		// Remove the trailing comma and space
-		workoutQuery = workoutQuery[:len(workoutQuery)-2]
-		workoutQuery += ` WHERE id = ?`
-		args = append(args, workout.ID)
+		// Every change bumps the version so outstanding ETags go stale
+		workoutQuery += `version = version + 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL`
+		args = append(args, now(), workout.ID)
 
-		_, err := tx.Exec(workoutQuery, args...)
+		// A non-zero version means the caller expects to be editing that version
+		if workout.Version != 0 {
+			workoutQuery += ` AND version = ?`
+			args = append(args, workout.Version)
+		}
+
+		result, err := tx.Exec(workoutQuery, args...)
 		if err != nil {
 			return fmt.Errorf("failed to update workout: %v", err)
 		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return checkVersion(tx, workout.ID, workout.Version)
+		}
 
 		if len(workout.Lifts) > 0 {
 			liftDeleteQuery := `DELETE FROM lifts WHERE workout_id = ?`
@@ -128,48 +232,58 @@ func UpdateWorkout(db *sql.DB, workout models.Workout) error {
 				return fmt.Errorf("failed to delete lifts: %v", err)
 			}
 
-			liftQuery := `INSERT INTO lifts (workout_id, name, weight, reps, sets) VALUES (?, ?, ?, ?, ?)`
-			for i := 0; i < len(workout.Lifts); i++ {
-				_, err = tx.Exec(liftQuery, workout.ID, workout.Lifts[i], workout.Weight[i], workout.Reps[i], workout.Sets[i])
-				if err != nil {
-					return fmt.Errorf("failed to insert lift: %v", err)
-				}
+			if err := insertLifts(tx, workout.ID, workout); err != nil {
+				return err
 			}
 		}
 
-		return nil
+		if len(workout.Cardio) > 0 {
+			if err := deleteCardio(tx, `id = ?`, workout.ID); err != nil {
+				return err
+			}
+			if err := insertCardio(tx, workout.ID, workout); err != nil {
+				return err
+			}
+		}
+
+		after, err := auditSnapshot(tx, workout.ID)
+		if err != nil {
+			return err
+		}
+		// The fields left alone may not be enough for a workout now completed
+		if after.Status == models.StatusCompleted && before.Status != models.StatusCompleted {
+			if err := ValidateWorkout(*after); err != nil {
+				return err
+			}
+		}
+		return recordChange(tx, actor, ActionUpdate, workout.ID, &before, after)
 	})
 }
 
-func DeleteWorkout(db *sql.DB, workoutID int) error {
-	tx, err := db.Begin()
+// DeleteWorkout moves a workout to the trash. It stays restorable until it is
+// purged, either explicitly or once the trash retention period has passed.
+func DeleteWorkout(db *sql.DB, workoutID int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		return deleteWorkout(tx, workoutID, actor)
+	})
+}
+
+// deleteWorkout is DeleteWorkout within tx.
+func deleteWorkout(tx *sql.Tx, workoutID int, actor Actor) error {
+	before, err := getWorkoutByID(tx, workoutID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %v", err)
+		return err
 	}
 
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			return
-		}
-	}()
-
-	workoutDeleteQuery := `DELETE FROM workouts WHERE id = ?`
-	_, err = tx.Exec(workoutDeleteQuery, workoutID)
-	if err != nil {
+	workoutDeleteQuery := `UPDATE workouts SET deleted_at = ?, version = version + 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL`
+	stamp := now()
+	if _, err := tx.Exec(workoutDeleteQuery, stamp, stamp, workoutID); err != nil {
 		return fmt.Errorf("failed to delete workout: %v", err)
 	}
 
-	liftDeleteQuery := `DELETE FROM lifts WHERE workout_id = ?`
-	_, err = tx.Exec(liftDeleteQuery, workoutID)
+	after, err := auditSnapshot(tx, workoutID)
 	if err != nil {
-		return fmt.Errorf("failed to delete lifts: %v", err)
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %v", err)
+		return err
 	}
-
-	return nil
+	return recordChange(tx, actor, ActionDelete, workoutID, &before, after)
 }
-
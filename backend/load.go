@@ -0,0 +1,361 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// Rolling windows for training load metrics, in days.
+const (
+	acuteDays   = 7
+	chronicDays = 28
+)
+
+var loadMethods = []string{models.LoadTonnage, models.LoadSRPE}
+
+// refreshDailyLoad recomputes an athlete's load for one day from the workouts
+// table and marks the rolling metrics from that day onwards as stale.
+func refreshDailyLoad(tx *sql.Tx, athlete, day string) error {
+	if _, err := time.Parse(models.DateLayout, day); err != nil {
+		return nil
+	}
+
+	// Without a session RPE, the average RPE of the workout's lifts stands in
+	rows, err := tx.Query(`SELECT id, time_in, time_out,
+			coalesce(nullif(session_rpe, 0), (SELECT avg(rpe) FROM lifts WHERE workout_id = workouts.id AND rpe > 0 AND warm_up = 0), 0)
+		FROM workouts WHERE athlete = ? AND day = ? AND deleted_at IS NULL AND status = 'completed'`, athlete, day)
+	if err != nil {
+		return fmt.Errorf("failed to fetch workouts for training load: %v", err)
+	}
+	var sessions int
+	var srpe float64
+	for rows.Next() {
+		var id int
+		var timeIn, timeOut string
+		var rpe float64
+		if err := rows.Scan(&id, &timeIn, &timeOut, &rpe); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan workout for training load: %v", err)
+		}
+		sessions++
+		in, inErr := parseClock(timeIn)
+		out, outErr := parseClock(timeOut)
+		if inErr == nil && outErr == nil {
+			srpe += rpe * SessionDuration(in, out).Minutes()
+		}
+	}
+	rows.Close()
+
+	tonnage, err := dayTonnage(tx, athlete, day)
+	if err != nil {
+		return err
+	}
+
+	upsert := `INSERT INTO daily_load (athlete, day, method, load, sessions) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (athlete, day, method) DO UPDATE SET load = excluded.load, sessions = excluded.sessions`
+	for method, load := range map[string]float64{models.LoadTonnage: tonnage, models.LoadSRPE: srpe} {
+		if _, err := tx.Exec(upsert, athlete, day, method, load, sessions); err != nil {
+			return fmt.Errorf("failed to store daily load: %v", err)
+		}
+	}
+
+	return markLoadDirty(tx, athlete, day)
+}
+
+// dayTonnage sums the effective volume of an athlete's lifts on one day.
+func dayTonnage(tx *sql.Tx, athlete, day string) (float64, error) {
+	lc, err := newLoadingContext(tx)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := tx.Query(`SELECT l.name, l.weight, l.reps, l.sets FROM lifts l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE w.athlete = ? AND w.day = ? AND w.deleted_at IS NULL AND w.status = 'completed' AND l.warm_up = 0`, athlete, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute tonnage: %v", err)
+	}
+	defer rows.Close()
+
+	var tonnage float64
+	for rows.Next() {
+		var lift models.Lift
+		if err := rows.Scan(&lift.Name, &lift.Weight, &lift.Reps, &lift.Sets); err != nil {
+			return 0, fmt.Errorf("failed to scan lift for tonnage: %v", err)
+		}
+		tonnage += liftTonnage(lc.effectiveLift(athlete, day, lift))
+	}
+	return round2(tonnage), rows.Err()
+}
+
+// refreshDailyLoads runs refreshDailyLoad for every athlete and day with a
+// live workout matching where, after exercise or bodyweight data changed.
+func refreshDailyLoads(tx *sql.Tx, where string, args ...interface{}) error {
+	rows, err := tx.Query(`SELECT DISTINCT athlete, day FROM workouts WHERE deleted_at IS NULL AND `+where, args...)
+	if err != nil {
+		return fmt.Errorf("failed to find affected training days: %v", err)
+	}
+	var days [][2]string
+	for rows.Next() {
+		var athlete, day string
+		if err := rows.Scan(&athlete, &day); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan training day: %v", err)
+		}
+		days = append(days, [2]string{athlete, day})
+	}
+	rows.Close()
+
+	for _, d := range days {
+		if err := refreshDailyLoad(tx, d[0], d[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markLoadDirty records that an athlete's rolling metrics need recomputing
+// from day onwards.
+func markLoadDirty(tx *sql.Tx, athlete, day string) error {
+	var dirty sql.NullString
+	err := tx.QueryRow(`SELECT dirty_from FROM load_state WHERE athlete = ?`, athlete).Scan(&dirty)
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(`INSERT INTO load_state (athlete, dirty_from) VALUES (?, ?)`, athlete, day)
+	} else if err == nil && (!dirty.Valid || sortableDate(day) < sortableDate(dirty.String)) {
+		_, err = tx.Exec(`UPDATE load_state SET dirty_from = ? WHERE athlete = ?`, day, athlete)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update training load state: %v", err)
+	}
+	return nil
+}
+
+// updateLoadMetrics brings an athlete's stored rolling metrics up to date.
+// Only days from the earliest changed day, or after the last computed day,
+// are recomputed.
+func updateLoadMetrics(db *sql.DB, athlete string) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		var computed, dirty sql.NullString
+		err := tx.QueryRow(`SELECT computed_through, dirty_from FROM load_state WHERE athlete = ?`, athlete).Scan(&computed, &dirty)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch training load state: %v", err)
+		}
+
+		var first, last string
+		err = tx.QueryRow(`SELECT min(`+sortableDay+`), max(`+sortableDay+`) FROM daily_load WHERE athlete = ?`, athlete).Scan(&first, &last)
+		if err != nil {
+			return fmt.Errorf("failed to fetch training load range: %v", err)
+		}
+		firstDay, _ := time.Parse("20060102", first)
+		lastDay, _ := time.Parse("20060102", last)
+
+		through := truncateDay(time.Now())
+		if lastDay.After(through) {
+			through = lastDay
+		}
+
+		from := firstDay
+		if computed.Valid {
+			computedDay, _ := time.Parse(models.DateLayout, computed.String)
+			from = computedDay.AddDate(0, 0, 1)
+			if dirty.Valid {
+				if dirtyDay, err := time.Parse(models.DateLayout, dirty.String); err == nil && dirtyDay.Before(from) {
+					from = dirtyDay
+				}
+			}
+		}
+		if from.Before(firstDay) {
+			from = firstDay
+		}
+		if from.After(through) {
+			return nil
+		}
+
+		loads, sessions, err := dailyLoads(tx, athlete, from.AddDate(0, 0, 1-chronicDays))
+		if err != nil {
+			return err
+		}
+
+		upsert := `INSERT INTO daily_load (athlete, day, method, load, sessions, acute, chronic, acwr, monotony, strain)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (athlete, day, method) DO UPDATE SET acute = excluded.acute, chronic = excluded.chronic,
+				acwr = excluded.acwr, monotony = excluded.monotony, strain = excluded.strain`
+		for d := from; !d.After(through); d = d.AddDate(0, 0, 1) {
+			day := d.Format(models.DateLayout)
+			for _, method := range loadMethods {
+				m := rollingMetrics(loads[method], d, firstDay)
+				_, err := tx.Exec(upsert, athlete, day, method, loads[method][day], sessions[day], m.Acute, m.Chronic, m.ACWR, m.Monotony, m.Strain)
+				if err != nil {
+					return fmt.Errorf("failed to store training load metrics: %v", err)
+				}
+			}
+		}
+
+		_, err = tx.Exec(`UPDATE load_state SET computed_through = ?, dirty_from = NULL WHERE athlete = ?`, through.Format(models.DateLayout), athlete)
+		if err != nil {
+			return fmt.Errorf("failed to update training load state: %v", err)
+		}
+		return nil
+	})
+}
+
+// dailyLoads returns an athlete's stored load per method and day, and sessions
+// per day, from since onwards.
+func dailyLoads(tx *sql.Tx, athlete string, since time.Time) (map[string]map[string]float64, map[string]int, error) {
+	rows, err := tx.Query(`SELECT day, method, load, sessions FROM daily_load WHERE athlete = ? AND `+sortableDay+` >= ?`,
+		athlete, since.Format("20060102"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch daily load: %v", err)
+	}
+	defer rows.Close()
+
+	loads := map[string]map[string]float64{}
+	for _, method := range loadMethods {
+		loads[method] = map[string]float64{}
+	}
+	sessions := map[string]int{}
+	for rows.Next() {
+		var day, method string
+		var load float64
+		var n int
+		if err := rows.Scan(&day, &method, &load, &n); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan daily load: %v", err)
+		}
+		if loads[method] != nil {
+			loads[method][day] = load
+		}
+		sessions[day] = n
+	}
+	return loads, sessions, rows.Err()
+}
+
+// rollingMetrics computes the acute and chronic load, ACWR, monotony and
+// strain for day from daily loads keyed by DD/MM/YYYY. first is the first day
+// of the athlete's history; until it is chronicDays old, the chronic load is
+// averaged over the days there are.
+func rollingMetrics(loads map[string]float64, day, first time.Time) models.LoadDay {
+	var m models.LoadDay
+	var week []float64
+	for i := 0; i < chronicDays; i++ {
+		load := loads[day.AddDate(0, 0, -i).Format(models.DateLayout)]
+		m.Chronic += load
+		if i < acuteDays {
+			m.Acute += load
+			week = append(week, load)
+		}
+	}
+	m.Chronic = m.Chronic * acuteDays / float64(historyDays(first, day))
+	if m.Chronic > 0 {
+		m.ACWR = m.Acute / m.Chronic
+	}
+
+	mean := m.Acute / acuteDays
+	var variance float64
+	for _, load := range week {
+		variance += (load - mean) * (load - mean)
+	}
+	if sd := math.Sqrt(variance / acuteDays); sd > 0 {
+		m.Monotony = mean / sd
+	}
+	m.Strain = m.Acute * m.Monotony
+
+	m.Acute = round2(m.Acute)
+	m.Chronic = round2(m.Chronic)
+	m.ACWR = round2(m.ACWR)
+	m.Monotony = round2(m.Monotony)
+	m.Strain = round2(m.Strain)
+	return m
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+// historyDays is the number of days from first to day, both included, up to
+// chronicDays.
+func historyDays(first, day time.Time) int {
+	days := int(math.Round(day.Sub(first).Hours()/24)) + 1
+	if days > chronicDays {
+		return chronicDays
+	}
+	if days < 1 {
+		return 1
+	}
+	return days
+}
+
+// acwrZone classifies an acute:chronic workload ratio. A ratio over less than
+// chronicDays of history is not classified.
+func acwrZone(acwr, chronic float64, history int) string {
+	switch {
+	case chronic == 0:
+		return ""
+	case history < chronicDays:
+		return models.ZoneInsufficient
+	case acwr < 0.8:
+		return models.ZoneUndertraining
+	case acwr <= 1.3:
+		return models.ZoneOptimal
+	case acwr <= 1.5:
+		return models.ZoneHigh
+	default:
+		return models.ZoneDanger
+	}
+}
+
+// GetTrainingLoad returns an athlete's daily load and rolling metrics from
+// start to end (DD/MM/YYYY, inclusive). Stored metrics are brought up to date
+// first, which only touches days changed since the last request.
+func GetTrainingLoad(db *sql.DB, athlete, method, start, end string) ([]models.LoadDay, error) {
+	verr := &ValidationError{}
+	if method != models.LoadTonnage && method != models.LoadSRPE {
+		verr.Add("method", "must be %s or %s", models.LoadTonnage, models.LoadSRPE)
+	}
+	if _, err := time.Parse(models.DateLayout, start); err != nil {
+		verr.Add("from", "must be a valid date in DD/MM/YYYY format")
+	}
+	if _, err := time.Parse(models.DateLayout, end); err != nil {
+		verr.Add("to", "must be a valid date in DD/MM/YYYY format")
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	if err := updateLoadMetrics(db, athlete); err != nil {
+		return nil, err
+	}
+
+	var first sql.NullString
+	err := db.QueryRow(`SELECT min(`+sortableDay+`) FROM daily_load WHERE athlete = ?`, athlete).Scan(&first)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch training load range: %v", err)
+	}
+	firstDay, _ := time.Parse("20060102", first.String)
+
+	query := `SELECT day, load, sessions, acute, chronic, acwr, monotony, strain FROM daily_load
+		WHERE athlete = ? AND method = ? AND ` + sortableDay + ` BETWEEN ? AND ?
+		ORDER BY ` + sortableDay
+	rows, err := db.Query(query, athlete, method, sortableDate(start), sortableDate(end))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch training load: %v", err)
+	}
+	defer rows.Close()
+
+	series := []models.LoadDay{}
+	for rows.Next() {
+		var d models.LoadDay
+		if err := rows.Scan(&d.Date, &d.Load, &d.Sessions, &d.Acute, &d.Chronic, &d.ACWR, &d.Monotony, &d.Strain); err != nil {
+			return nil, fmt.Errorf("failed to scan training load: %v", err)
+		}
+		day, _ := time.Parse(models.DateLayout, d.Date)
+		d.Zone = acwrZone(d.ACWR, d.Chronic, historyDays(firstDay, day))
+		series = append(series, d)
+	}
+	return series, rows.Err()
+}
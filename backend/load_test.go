@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func TestHistoryDays(t *testing.T) {
+	day := time.Date(2026, 3, 29, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		first time.Time
+		want  int
+	}{
+		{"first day", day, 1},
+		{"one week", day.AddDate(0, 0, -6), 7},
+		{"27 days", day.AddDate(0, 0, -26), 27},
+		{"28 days", day.AddDate(0, 0, -27), chronicDays},
+		{"capped at 28 days", day.AddDate(0, 0, -100), chronicDays},
+		{"first after day", day.AddDate(0, 0, 3), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := historyDays(tt.first, day); got != tt.want {
+				t.Errorf("historyDays() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestACWRZone(t *testing.T) {
+	tests := []struct {
+		acwr, chronic float64
+		history       int
+		want          string
+	}{
+		{0, 0, chronicDays, ""},
+		{1, 100, 10, models.ZoneInsufficient},
+		{1, 100, chronicDays - 1, models.ZoneInsufficient},
+		{0.79, 100, chronicDays, models.ZoneUndertraining},
+		{0.8, 100, chronicDays, models.ZoneOptimal},
+		{1.3, 100, chronicDays, models.ZoneOptimal},
+		{1.31, 100, chronicDays, models.ZoneHigh},
+		{1.5, 100, chronicDays, models.ZoneHigh},
+		{1.51, 100, chronicDays, models.ZoneDanger},
+	}
+	for _, tt := range tests {
+		if got := acwrZone(tt.acwr, tt.chronic, tt.history); got != tt.want {
+			t.Errorf("acwrZone(%v, %v, %d) = %q, want %q", tt.acwr, tt.chronic, tt.history, got, tt.want)
+		}
+	}
+}
+
+func TestRollingMetrics(t *testing.T) {
+	day := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	daily := func(from, to int, load func(i int) float64) map[string]float64 {
+		loads := map[string]float64{}
+		for i := from; i <= to; i++ {
+			loads[day.AddDate(0, 0, -i).Format(models.DateLayout)] = load(i)
+		}
+		return loads
+	}
+	steady := func(int) float64 { return 100 }
+
+	tests := []struct {
+		name  string
+		loads map[string]float64
+		first time.Time
+		want  models.LoadDay
+	}{
+		{
+			name:  "steady load",
+			loads: daily(0, 40, steady),
+			first: day.AddDate(0, 0, -40),
+			want:  models.LoadDay{Acute: 700, Chronic: 700, ACWR: 1},
+		},
+		{
+			// A week of history is averaged over that week, not over 28 days
+			name:  "one week of history",
+			loads: daily(0, 6, steady),
+			first: day.AddDate(0, 0, -6),
+			want:  models.LoadDay{Acute: 700, Chronic: 700, ACWR: 1},
+		},
+		{
+			name:  "spike after a rest",
+			loads: daily(0, 6, func(i int) float64 { return float64(100 * ((i + 1) % 2)) }),
+			first: day.AddDate(0, 0, -60),
+			want:  models.LoadDay{Acute: 400, Chronic: 100, ACWR: 4, Monotony: 1.15, Strain: 461.88},
+		},
+		{
+			name:  "no load",
+			loads: map[string]float64{},
+			first: day.AddDate(0, 0, -60),
+			want:  models.LoadDay{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rollingMetrics(tt.loads, day, tt.first); got != tt.want {
+				t.Errorf("rollingMetrics() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// DefaultTrendAlpha is the share of each day's measurement taken into the
+// trend: 0.1 smooths out day to day water weight swings.
+const DefaultTrendAlpha = 0.1
+
+// poundsPerKilogram converts between the two mass units.
+const poundsPerKilogram = 2.20462262
+
+var metricPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// NormalizeMeasurement tidies user input: metric names are lower case with
+// underscores, DD-MM-YYYY is accepted and common metrics get their default unit.
+func NormalizeMeasurement(m models.Measurement) models.Measurement {
+	m.Athlete = strings.TrimSpace(m.Athlete)
+	m.Date = strings.ReplaceAll(strings.TrimSpace(m.Date), "-", "/")
+	m.Metric = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(m.Metric)), " ", "_")
+	m.Unit = strings.ToLower(strings.TrimSpace(m.Unit))
+	if units, ok := models.MetricUnits[m.Metric]; ok && m.Unit == "" {
+		m.Unit = units[0]
+	}
+	return m
+}
+
+func validateMeasurement(m models.Measurement) error {
+	verr := &ValidationError{}
+	if _, err := time.Parse(models.DateLayout, m.Date); err != nil {
+		verr.Add("date", "must be a valid date in DD/MM/YYYY format")
+	}
+	if !metricPattern.MatchString(m.Metric) {
+		verr.Add("metric", "is required and may only contain letters, digits and underscores")
+	}
+	if m.Value <= 0 || m.Value > MaxWeight {
+		verr.Add("value", "must be between 0 and %g", MaxWeight)
+	}
+	if units, ok := models.MetricUnits[m.Metric]; ok {
+		valid := false
+		for _, u := range units {
+			if u == m.Unit {
+				valid = true
+			}
+		}
+		if !valid {
+			verr.Add("unit", "must be one of %s for %s", strings.Join(units, ", "), m.Metric)
+		}
+	} else if m.Unit == "" {
+		verr.Add("unit", "is required")
+	}
+	if m.Unit == models.UnitPercent && m.Value > 100 {
+		verr.Add("value", "must be at most 100%%")
+	}
+	return verr.OrNil()
+}
+
+// refreshForMetric recomputes an athlete's training loads after their
+// bodyweight changed, as it feeds the effective load of bodyweight exercises.
+func refreshForMetric(tx *sql.Tx, athlete, metric string) error {
+	if metric != models.MetricBodyweight {
+		return nil
+	}
+	return refreshDailyLoads(tx, `athlete = ?`, athlete)
+}
+
+// LogMeasurement records a measurement, replacing any value of the same
+// metric the athlete logged that day, and returns its ID.
+func LogMeasurement(db *sql.DB, m models.Measurement, actor Actor) (int, error) {
+	m = NormalizeMeasurement(m)
+	if err := validateMeasurement(m); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		// A measurement replacing one of the same day is an update
+		var before *models.Measurement
+		existing, err := scanMeasurement(tx.QueryRow(`SELECT `+measurementColumns+` FROM measurements WHERE athlete = ? AND day = ? AND metric = ?`, m.Athlete, m.Date, m.Metric))
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to check for existing measurement: %v", err)
+		}
+		if err == nil {
+			before = &existing
+		}
+
+		query := `INSERT INTO measurements (athlete, day, metric, value, unit) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (athlete, day, metric) DO UPDATE SET value = excluded.value, unit = excluded.unit`
+		if _, err := tx.Exec(query, m.Athlete, m.Date, m.Metric, m.Value, m.Unit); err != nil {
+			return fmt.Errorf("failed to store measurement: %v", err)
+		}
+		err = tx.QueryRow(`SELECT id FROM measurements WHERE athlete = ? AND day = ? AND metric = ?`, m.Athlete, m.Date, m.Metric).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve measurement ID: %v", err)
+		}
+
+		m.ID = id
+		action := ActionCreate
+		if before != nil {
+			action = ActionUpdate
+		}
+		if err := recordAudit(tx, actor, action, EntityMeasurement, id, before, m); err != nil {
+			return err
+		}
+		return refreshForMetric(tx, m.Athlete, m.Metric)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateMeasurement overwrites the measurement with m.ID.
+func UpdateMeasurement(db *sql.DB, m models.Measurement, actor Actor) error {
+	m = NormalizeMeasurement(m)
+	if err := validateMeasurement(m); err != nil {
+		return err
+	}
+
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getMeasurement(tx, m.ID)
+		if err != nil {
+			return err
+		}
+
+		var clash int
+		err = tx.QueryRow(`SELECT count(*) FROM measurements WHERE athlete = ? AND day = ? AND metric = ? AND id != ?`, m.Athlete, m.Date, m.Metric, m.ID).Scan(&clash)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing measurement: %v", err)
+		}
+		if clash > 0 {
+			return &ConflictError{Message: fmt.Sprintf("%s already has a %s measurement on %s", m.Athlete, m.Metric, m.Date)}
+		}
+
+		query := `UPDATE measurements SET athlete = ?, day = ?, metric = ?, value = ?, unit = ? WHERE id = ?`
+		if _, err := tx.Exec(query, m.Athlete, m.Date, m.Metric, m.Value, m.Unit, m.ID); err != nil {
+			return fmt.Errorf("failed to update measurement: %v", err)
+		}
+		if err := recordAudit(tx, actor, ActionUpdate, EntityMeasurement, m.ID, before, m); err != nil {
+			return err
+		}
+		if err := refreshForMetric(tx, before.Athlete, before.Metric); err != nil {
+			return err
+		}
+		if m.Athlete == before.Athlete && m.Metric == before.Metric {
+			return nil
+		}
+		return refreshForMetric(tx, m.Athlete, m.Metric)
+	})
+}
+
+func DeleteMeasurement(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		m, err := getMeasurement(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM measurements WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete measurement: %v", err)
+		}
+		if err := recordAudit(tx, actor, ActionDelete, EntityMeasurement, id, m, nil); err != nil {
+			return err
+		}
+		return refreshForMetric(tx, m.Athlete, m.Metric)
+	})
+}
+
+func GetMeasurement(db *sql.DB, id int) (models.Measurement, error) {
+	return getMeasurement(db, id)
+}
+
+const measurementColumns = `id, athlete, day, metric, value, unit`
+
+func scanMeasurement(row rowScanner) (models.Measurement, error) {
+	var m models.Measurement
+	err := row.Scan(&m.ID, &m.Athlete, &m.Date, &m.Metric, &m.Value, &m.Unit)
+	return m, err
+}
+
+func getMeasurement(q querier, id int) (models.Measurement, error) {
+	m, err := scanMeasurement(q.QueryRow(`SELECT `+measurementColumns+` FROM measurements WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return models.Measurement{}, &NotFoundError{Resource: "measurement", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.Measurement{}, fmt.Errorf("failed to fetch measurement: %v", err)
+	}
+	return m, nil
+}
+
+// GetMeasurements returns an athlete's measurements oldest first. metric,
+// from and to (DD/MM/YYYY, inclusive) narrow the result when not empty.
+func GetMeasurements(db *sql.DB, athlete, metric, from, to string) ([]models.Measurement, error) {
+	query := `SELECT ` + measurementColumns + ` FROM measurements WHERE athlete = ?`
+	args := []interface{}{athlete}
+	if metric != "" {
+		query += ` AND metric = ?`
+		args = append(args, NormalizeMeasurement(models.Measurement{Metric: metric}).Metric)
+	}
+	if from != "" {
+		query += ` AND ` + sortableDay + ` >= ?`
+		args = append(args, sortableDate(strings.ReplaceAll(from, "-", "/")))
+	}
+	if to != "" {
+		query += ` AND ` + sortableDay + ` <= ?`
+		args = append(args, sortableDate(strings.ReplaceAll(to, "-", "/")))
+	}
+	query += ` ORDER BY ` + sortableDay + `, metric`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch measurements: %v", err)
+	}
+	defer rows.Close()
+
+	measurements := []models.Measurement{}
+	for rows.Next() {
+		m, err := scanMeasurement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan measurement row: %v", err)
+		}
+		measurements = append(measurements, m)
+	}
+	return measurements, rows.Err()
+}
+
+// MeasurementTrend returns one metric's history with an exponential moving
+// average in Trend. alpha is the weight of a day's value; over a gap of n
+// days the trend moves as if the value had been seen n times. Values logged
+// in another unit than the latest one are converted where possible.
+func MeasurementTrend(db *sql.DB, athlete, metric string, alpha float64) ([]models.Measurement, error) {
+	// Written so that NaN fails too
+	if !(alpha > 0 && alpha <= 1) {
+		verr := &ValidationError{}
+		verr.Add("alpha", "must be greater than 0 and at most 1")
+		return nil, verr
+	}
+	series, err := GetMeasurements(db, athlete, metric, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return series, nil
+	}
+
+	unit := series[len(series)-1].Unit
+	var trend float64
+	var last time.Time
+	for i := range series {
+		m := &series[i]
+		m.Value = round2(convertUnit(m.Value, m.Unit, unit))
+		m.Unit = unit
+		day, _ := time.Parse(models.DateLayout, m.Date)
+		if i == 0 {
+			trend = m.Value
+		} else {
+			days := math.Max(day.Sub(last).Hours()/24, 1)
+			weight := 1 - math.Pow(1-alpha, days)
+			trend += weight * (m.Value - trend)
+		}
+		last = day
+		m.Trend = round2(trend)
+	}
+	return series, nil
+}
+
+// convertUnit converts between kg and lb and between cm and in. Other unit
+// pairs are returned unchanged.
+func convertUnit(value float64, from, to string) float64 {
+	switch {
+	case from == to:
+		return value
+	case from == models.UnitKilogram && to == models.UnitPound:
+		return value * poundsPerKilogram
+	case from == models.UnitPound && to == models.UnitKilogram:
+		return value / poundsPerKilogram
+	case from == models.UnitCentimetre && to == models.UnitInch:
+		return value / 2.54
+	case from == models.UnitInch && to == models.UnitCentimetre:
+		return value * 2.54
+	}
+	return value
+}
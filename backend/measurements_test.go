@@ -0,0 +1,44 @@
+package backend
+
+import (
+	"testing"
+
+	"fitness-dev/models"
+)
+
+func TestMeasurementTrend(t *testing.T) {
+	db := newTestDB(t)
+	for _, m := range []models.Measurement{
+		{Date: "04/10/2026", Value: 82},
+		{Date: "01/10/2026", Value: 80},
+		{Date: "02/10/2026", Value: 82},
+	} {
+		m.Athlete, m.Metric = "sam", models.MetricBodyweight
+		if _, err := LogMeasurement(db, NormalizeMeasurement(m), testActor); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	series, err := MeasurementTrend(db, "sam", models.MetricBodyweight, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first value seeds the trend. A day later it moves a tenth of the
+	// way to the new value, two days later 1 - 0.9² = 19% of the way.
+	want := []struct {
+		date  string
+		trend float64
+	}{{"01/10/2026", 80}, {"02/10/2026", 80.2}, {"04/10/2026", 80.54}}
+	if len(series) != len(want) {
+		t.Fatalf("got %d measurements, want %d", len(series), len(want))
+	}
+	for i, w := range want {
+		if m := series[i]; m.Date != w.date || m.Trend != w.trend {
+			t.Errorf("measurement %d: trend on %s = %g, want %g on %s", i, m.Date, m.Trend, w.trend, w.date)
+		}
+	}
+
+	if _, err := MeasurementTrend(db, "sam", models.MetricBodyweight, 0); err == nil {
+		t.Error("alpha 0 was accepted")
+	}
+}
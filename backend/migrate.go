@@ -0,0 +1,337 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// column is a column added to an existing table after its initial creation.
+type column struct {
+	table      string
+	name       string
+	definition string
+}
+
+// migrationColumns are applied in order to databases created by older versions.
+// SQLite only allows constant defaults on ALTER TABLE, so timestamps default to an empty string.
+var migrationColumns = []column{
+	{"workouts", "version", "INTEGER NOT NULL DEFAULT 1"},
+	{"workouts", "updated_at", "TEXT NOT NULL DEFAULT ''"},
+	{"workouts", "deleted_at", "TEXT"}, // NULL while the workout is live
+	{"workouts", "athlete", "TEXT NOT NULL DEFAULT ''"},
+	{"workouts", "session_rpe", "REAL NOT NULL DEFAULT 0"},
+	{"workouts", "status", "TEXT NOT NULL DEFAULT 'completed'"}, // every workout logged before was done
+	{"lifts", "rpe", "REAL NOT NULL DEFAULT 0"},
+	{"lifts", "rir", "INTEGER"}, // NULL when not recorded
+	{"lifts", "tempo", "TEXT NOT NULL DEFAULT ''"},
+	{"lifts", "rest", "INTEGER NOT NULL DEFAULT 0"},       // seconds
+	{"lifts", "unit", "TEXT NOT NULL DEFAULT 'kg'"},       // unit the weight was entered in, stored as kg
+	{"lifts", "target_weight", "REAL NOT NULL DEFAULT 0"}, // 0 when the lift had no target
+	{"lifts", "target_reps", "INTEGER NOT NULL DEFAULT 0"},
+	{"lifts", "target_sets", "INTEGER NOT NULL DEFAULT 0"},
+	{"lifts", "warm_up", "INTEGER NOT NULL DEFAULT 0"}, // 1 for warm-up sets
+	{"program_rules", "target_rpe", "REAL NOT NULL DEFAULT 0"},
+	{"user_settings", "leaderboard_opt_out", "INTEGER NOT NULL DEFAULT 0"},
+	{"blocks", "athlete", "TEXT NOT NULL DEFAULT ''"},
+	{"audit_log", "entity", "TEXT NOT NULL DEFAULT 'workout'"}, // every entry written before was a workout change
+	{"audit_log", "entity_key", "TEXT NOT NULL DEFAULT ''"},    // empty for entries that only have a workout_id
+}
+
+// columnBackfills fill in a newly added column for existing rows, keyed by
+// table.column. They only run when the column is first added.
+var columnBackfills = map[string]string{
+	// Existing workouts belong to whoever created them, where the audit log knows
+	"workouts.athlete": `UPDATE workouts SET athlete = coalesce((SELECT actor FROM audit_log a
+		WHERE a.workout_id = workouts.id AND a.action = 'create' ORDER BY a.id LIMIT 1), '')`,
+	// Existing blocks go to the athlete with the most workouts, the only one
+	// on installs used by a single person
+	"blocks.athlete": `UPDATE blocks SET athlete = coalesce((SELECT athlete FROM workouts
+		WHERE deleted_at IS NULL GROUP BY athlete ORDER BY count(*) DESC LIMIT 1), '')`,
+}
+
+// migrationTables are created if they do not exist yet.
+var migrationTables = []string{
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workout_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		source TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		before_json TEXT,
+		after_json TEXT
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_log_workout ON audit_log (workout_id, created_at);`,
+	// The audit log is append-only
+	`CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;`,
+	`CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;`,
+	`CREATE TABLE IF NOT EXISTS templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE
+	);`,
+	`CREATE TABLE IF NOT EXISTS template_exercises (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		template_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		target_weight REAL NOT NULL,
+		target_reps INTEGER NOT NULL,
+		target_sets INTEGER NOT NULL,
+		FOREIGN KEY (template_id) REFERENCES templates(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS programs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		start_date TEXT NOT NULL,
+		active INTEGER NOT NULL DEFAULT 0
+	);`,
+	`CREATE TABLE IF NOT EXISTS program_templates (
+		program_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		template_id INTEGER NOT NULL,
+		PRIMARY KEY (program_id, position),
+		FOREIGN KEY (program_id) REFERENCES programs(id) ON DELETE CASCADE,
+		FOREIGN KEY (template_id) REFERENCES templates(id)
+	);`,
+	`CREATE TABLE IF NOT EXISTS program_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		program_id INTEGER NOT NULL,
+		exercise TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		increment REAL NOT NULL DEFAULT 0,
+		deload_after INTEGER NOT NULL DEFAULT 0,
+		deload_percent REAL NOT NULL DEFAULT 0,
+		rep_min INTEGER NOT NULL DEFAULT 0,
+		rep_max INTEGER NOT NULL DEFAULT 0,
+		training_max REAL NOT NULL DEFAULT 0,
+		start_weight REAL NOT NULL DEFAULT 0,
+		target_rpe REAL NOT NULL DEFAULT 0,
+		FOREIGN KEY (program_id) REFERENCES programs(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS blocks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		athlete TEXT NOT NULL DEFAULT '',
+		name TEXT NOT NULL,
+		start_date TEXT NOT NULL,
+		end_date TEXT NOT NULL,
+		goal TEXT NOT NULL,
+		target_intensity REAL NOT NULL DEFAULT 0,
+		target_tonnage REAL NOT NULL DEFAULT 0
+	);`,
+	// Daily training load and the rolling metrics derived from it, one row per
+	// athlete, day and load method. Kept up to date by recordChange.
+	`CREATE TABLE IF NOT EXISTS daily_load (
+		athlete TEXT NOT NULL,
+		day TEXT NOT NULL,
+		method TEXT NOT NULL,
+		load REAL NOT NULL DEFAULT 0,
+		sessions INTEGER NOT NULL DEFAULT 0,
+		acute REAL NOT NULL DEFAULT 0,
+		chronic REAL NOT NULL DEFAULT 0,
+		acwr REAL NOT NULL DEFAULT 0,
+		monotony REAL NOT NULL DEFAULT 0,
+		strain REAL NOT NULL DEFAULT 0,
+		PRIMARY KEY (athlete, day, method)
+	);`,
+	// How far the rolling metrics are computed per athlete, and the earliest
+	// day whose load changed since.
+	`CREATE TABLE IF NOT EXISTS load_state (
+		athlete TEXT PRIMARY KEY,
+		computed_through TEXT,
+		dirty_from TEXT
+	);`,
+	// How each exercise is loaded; exercises not listed count as external load
+	`CREATE TABLE IF NOT EXISTS exercises (
+		name TEXT PRIMARY KEY COLLATE NOCASE,
+		loading TEXT NOT NULL,
+		bodyweight_fraction REAL NOT NULL DEFAULT 0
+	);`,
+	// Common bodyweight exercises. Existing definitions are left alone, so
+	// PUT /exercises changes stick.
+	`INSERT OR IGNORE INTO exercises (name, loading, bodyweight_fraction) VALUES
+		('Pull-up', 'bodyweight_plus', 1.0), ('Pull-ups', 'bodyweight_plus', 1.0),
+		('Chin-up', 'bodyweight_plus', 1.0), ('Chin-ups', 'bodyweight_plus', 1.0),
+		('Dip', 'bodyweight_plus', 0.95), ('Dips', 'bodyweight_plus', 0.95),
+		('Tricep Dip', 'bodyweight_plus', 0.95),
+		('Push-up', 'bodyweight', 0.64), ('Push-ups', 'bodyweight', 0.64),
+		('Assisted Pull-up', 'assisted', 1.0), ('Assisted Dip', 'assisted', 0.95);`,
+	// Body measurements, bodyweight among them
+	`CREATE TABLE IF NOT EXISTS measurements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		athlete TEXT NOT NULL,
+		day TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		value REAL NOT NULL,
+		unit TEXT NOT NULL,
+		UNIQUE (athlete, day, metric)
+	);`,
+	`CREATE TABLE IF NOT EXISTS cardio (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		workout_id INTEGER NOT NULL,
+		activity TEXT NOT NULL,
+		distance REAL NOT NULL DEFAULT 0,
+		duration INTEGER NOT NULL,
+		avg_hr INTEGER NOT NULL DEFAULT 0,
+		elevation REAL NOT NULL DEFAULT 0,
+		FOREIGN KEY (workout_id) REFERENCES workouts(id) ON DELETE CASCADE
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_cardio_workout ON cardio (workout_id);`,
+	`CREATE TABLE IF NOT EXISTS cardio_intervals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cardio_id INTEGER NOT NULL,
+		duration INTEGER NOT NULL,
+		distance REAL NOT NULL DEFAULT 0,
+		rest INTEGER NOT NULL DEFAULT 0,
+		avg_hr INTEGER NOT NULL DEFAULT 0,
+		FOREIGN KEY (cardio_id) REFERENCES cardio(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS cardio_splits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cardio_id INTEGER NOT NULL,
+		distance REAL NOT NULL,
+		duration INTEGER NOT NULL,
+		avg_hr INTEGER NOT NULL DEFAULT 0,
+		elevation REAL NOT NULL DEFAULT 0,
+		FOREIGN KEY (cardio_id) REFERENCES cardio(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS user_settings (
+		user TEXT PRIMARY KEY,
+		units TEXT NOT NULL DEFAULT 'kg',
+		smallest_plate REAL NOT NULL DEFAULT 0
+	);`,
+	`CREATE TABLE IF NOT EXISTS goals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		athlete TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		exercise TEXT NOT NULL DEFAULT '',
+		target REAL NOT NULL,
+		start_date TEXT NOT NULL,
+		deadline TEXT NOT NULL DEFAULT ''
+	);`,
+	// Equipment profiles for the plate calculator, in the unit the kit is marked in
+	`CREATE TABLE IF NOT EXISTS equipment (
+		user TEXT PRIMARY KEY,
+		units TEXT NOT NULL,
+		dumbbell_increment REAL NOT NULL DEFAULT 0
+	);`,
+	`CREATE TABLE IF NOT EXISTS equipment_bars (
+		user TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		weight REAL NOT NULL,
+		PRIMARY KEY (user, position)
+	);`,
+	`CREATE TABLE IF NOT EXISTS equipment_plates (
+		user TEXT NOT NULL,
+		weight REAL NOT NULL,
+		pairs INTEGER NOT NULL,
+		PRIMARY KEY (user, weight)
+	);`,
+	// Badges earned, keyed by the id of their rule in the achievement rule file
+	`CREATE TABLE IF NOT EXISTS achievements (
+		athlete TEXT NOT NULL,
+		badge TEXT NOT NULL,
+		workout_id INTEGER NOT NULL,
+		earned_at TEXT NOT NULL,
+		PRIMARY KEY (athlete, badge)
+	);`,
+	// Groups of users and the challenges they compete in
+	`CREATE TABLE IF NOT EXISTS groups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE
+	);`,
+	`CREATE TABLE IF NOT EXISTS group_members (
+		group_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		athlete TEXT NOT NULL,
+		PRIMARY KEY (group_id, athlete),
+		FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+	);`,
+	`CREATE TABLE IF NOT EXISTS challenges (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		group_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		metric TEXT NOT NULL,
+		exercise TEXT NOT NULL DEFAULT '',
+		start_date TEXT NOT NULL,
+		end_date TEXT NOT NULL,
+		scoring TEXT NOT NULL DEFAULT 'total',
+		FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+	);`,
+	// Sessions scheduled ahead, from programs or calendar imports
+	`CREATE TABLE IF NOT EXISTS planned_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		athlete TEXT NOT NULL,
+		day TEXT NOT NULL,
+		time_in TEXT NOT NULL DEFAULT '',
+		time_out TEXT NOT NULL DEFAULT '',
+		title TEXT NOT NULL,
+		notes TEXT NOT NULL DEFAULT '',
+		program_id INTEGER NOT NULL DEFAULT 0,
+		template_id INTEGER NOT NULL DEFAULT 0,
+		uid TEXT NOT NULL DEFAULT '',
+		workout_id INTEGER NOT NULL DEFAULT 0
+	);`,
+	`CREATE INDEX IF NOT EXISTS idx_planned_sessions_uid ON planned_sessions (athlete, uid);`,
+}
+
+// migrate creates missing tables first, so that columns can be added to them
+// and backfills can read from them.
+func migrate(db *sql.DB) error {
+	for _, ddl := range migrationTables {
+		if _, err := db.Exec(ddl); err != nil {
+			return fmt.Errorf("failed to apply schema change: %v", err)
+		}
+	}
+
+	for _, c := range migrationColumns {
+		added, err := addColumnIfMissing(db, c)
+		if err != nil {
+			return err
+		}
+		if backfill, ok := columnBackfills[c.table+"."+c.name]; ok && added {
+			if _, err := db.Exec(backfill); err != nil {
+				return fmt.Errorf("failed to backfill %s.%s: %v", c.table, c.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// addColumnIfMissing adds c unless it already exists, reporting whether it did.
+func addColumnIfMissing(db *sql.DB, c column) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", c.table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s schema: %v", c.table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to scan %s schema: %v", c.table, err)
+		}
+		if name == c.name {
+			return false, nil
+		}
+	}
+	rows.Close()
+
+	log.Printf("Adding column %s.%s", c.table, c.name)
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.name, c.definition))
+	if err != nil {
+		return false, fmt.Errorf("failed to add column %s.%s: %v", c.table, c.name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,376 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"fitness-dev/models"
+)
+
+// modifyWorkout loads a workout, lets fn change it, then validates and writes
+// the result back in one transaction. fn sees the weights in units (kg if
+// empty). A non-zero version must match the stored version or a
+// *PreconditionFailedError is returned.
+func modifyWorkout(db *sql.DB, workoutID, version int, units string, actor Actor, fn func(workout *models.Workout) error) (models.Workout, error) {
+	var updated models.Workout
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		current, err := getWorkoutByID(tx, workoutID)
+		if err != nil {
+			return err
+		}
+		if version != 0 && version != current.Version {
+			return &PreconditionFailedError{Current: current.Version, Given: version}
+		}
+
+		workout := workoutInUnits(current, units)
+		workout.EntryUnits = entryUnits(current)
+		if err := fn(&workout); err != nil {
+			return err
+		}
+
+		// Identity and bookkeeping fields are never taken from the caller
+		workout.ID = current.ID
+		workout.Version = current.Version
+		workout.Units = units
+
+		workout = NormalizeWorkout(workout)
+		if workout.Status == "" {
+			workout.Status = current.Status
+		}
+		if err := checkTransition(current, workout.Status); err != nil {
+			return err
+		}
+		if err := ValidateWorkout(workout); err != nil {
+			return err
+		}
+		if err := replaceWorkout(tx, workout); err != nil {
+			return err
+		}
+
+		updated, err = getWorkoutByID(tx, workoutID)
+		if err != nil {
+			return err
+		}
+		return recordChange(tx, actor, ActionUpdate, workoutID, &current, &updated)
+	})
+	if err != nil {
+		return models.Workout{}, err
+	}
+	return updated, nil
+}
+
+// PatchWorkout applies a JSON Merge Patch (RFC 7396) to a workout. Fields set
+// to null are cleared and arrays are replaced wholesale. Weights in the patch
+// are in units.
+func PatchWorkout(db *sql.DB, workoutID, version int, patch []byte, units string, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, units, actor, func(workout *models.Workout) error {
+		return applyMergePatch(workout, patch)
+	})
+}
+
+// AddLift appends a lift, weighed in units, to a workout.
+func AddLift(db *sql.DB, workoutID, version int, lift models.Lift, units string, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, units, actor, func(workout *models.Workout) error {
+		setLift(workout, len(workout.Lifts), lift)
+		return nil
+	})
+}
+
+// ReplaceLift overwrites the lift at index with one weighed in units.
+func ReplaceLift(db *sql.DB, workoutID, version, index int, lift models.Lift, units string, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, units, actor, func(workout *models.Workout) error {
+		if err := checkLiftIndex(*workout, index); err != nil {
+			return err
+		}
+		setLift(workout, index, lift)
+		return nil
+	})
+}
+
+// PatchLift applies a JSON Merge Patch to the lift at index, whose weight is
+// seen in units.
+func PatchLift(db *sql.DB, workoutID, version, index int, patch []byte, units string, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, units, actor, func(workout *models.Workout) error {
+		if err := checkLiftIndex(*workout, index); err != nil {
+			return err
+		}
+		lift := GetLift(*workout, index)
+		if err := applyMergePatch(&lift, patch); err != nil {
+			return err
+		}
+		setLift(workout, index, lift)
+		return nil
+	})
+}
+
+// DeleteLift removes the lift at index.
+func DeleteLift(db *sql.DB, workoutID, version, index int, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, "", actor, func(workout *models.Workout) error {
+		if err := checkLiftIndex(*workout, index); err != nil {
+			return err
+		}
+		workout.Lifts = append(workout.Lifts[:index:index], workout.Lifts[index+1:]...)
+		workout.Weight = append(workout.Weight[:index:index], workout.Weight[index+1:]...)
+		workout.Reps = append(workout.Reps[:index:index], workout.Reps[index+1:]...)
+		workout.Sets = append(workout.Sets[:index:index], workout.Sets[index+1:]...)
+
+		padExtras(workout, len(workout.Lifts)+1)
+		workout.RPE = append(workout.RPE[:index:index], workout.RPE[index+1:]...)
+		workout.RIR = append(workout.RIR[:index:index], workout.RIR[index+1:]...)
+		workout.Tempo = append(workout.Tempo[:index:index], workout.Tempo[index+1:]...)
+		workout.Rest = append(workout.Rest[:index:index], workout.Rest[index+1:]...)
+		workout.EntryUnits = append(workout.EntryUnits[:index:index], workout.EntryUnits[index+1:]...)
+		workout.TargetWeight = append(workout.TargetWeight[:index:index], workout.TargetWeight[index+1:]...)
+		workout.TargetReps = append(workout.TargetReps[:index:index], workout.TargetReps[index+1:]...)
+		workout.TargetSets = append(workout.TargetSets[:index:index], workout.TargetSets[index+1:]...)
+		workout.WarmUp = append(workout.WarmUp[:index:index], workout.WarmUp[index+1:]...)
+		compactExtras(workout)
+		return nil
+	})
+}
+
+// GetLift returns the lift at index from a workout's parallel slices.
+func GetLift(workout models.Workout, index int) models.Lift {
+	lift := models.Lift{
+		Name:   workout.Lifts[index],
+		Weight: workout.Weight[index],
+		Reps:   workout.Reps[index],
+		Sets:   workout.Sets[index],
+	}
+	if index < len(workout.RPE) {
+		lift.RPE = workout.RPE[index]
+	}
+	if index < len(workout.RIR) {
+		lift.RIR = workout.RIR[index]
+	}
+	if index < len(workout.Tempo) {
+		lift.Tempo = workout.Tempo[index]
+	}
+	if index < len(workout.Rest) {
+		lift.Rest = workout.Rest[index]
+	}
+	if index < len(workout.TargetWeight) {
+		lift.TargetWeight = workout.TargetWeight[index]
+	}
+	if index < len(workout.TargetReps) {
+		lift.TargetReps = workout.TargetReps[index]
+	}
+	if index < len(workout.TargetSets) {
+		lift.TargetSets = workout.TargetSets[index]
+	}
+	if index < len(workout.WarmUp) {
+		lift.WarmUp = workout.WarmUp[index]
+	}
+	return lift
+}
+
+func checkLiftIndex(workout models.Workout, index int) error {
+	if index < 0 || index >= len(workout.Lifts) || index >= len(workout.Weight) ||
+		index >= len(workout.Reps) || index >= len(workout.Sets) {
+		return &NotFoundError{Resource: "lift", Key: fmt.Sprintf("%d of workout %d", index, workout.ID)}
+	}
+	return nil
+}
+
+// AppendLift adds lift to the end of a workout's parallel slices.
+func AppendLift(workout *models.Workout, lift models.Lift) {
+	setLift(workout, len(workout.Lifts), lift)
+}
+
+// setLift writes lift at index, appending when index is one past the end.
+// The slices are copied so the caller's workout is not aliased.
+func setLift(workout *models.Workout, index int, lift models.Lift) {
+	workout.Lifts = append([]string(nil), workout.Lifts...)
+	workout.Weight = append([]float64(nil), workout.Weight...)
+	workout.Reps = append([]int(nil), workout.Reps...)
+	workout.Sets = append([]int(nil), workout.Sets...)
+	workout.RPE = append([]float64(nil), workout.RPE...)
+	workout.RIR = append([]*int(nil), workout.RIR...)
+	workout.Tempo = append([]string(nil), workout.Tempo...)
+	workout.Rest = append([]int(nil), workout.Rest...)
+	workout.EntryUnits = append([]string(nil), workout.EntryUnits...)
+	workout.TargetWeight = append([]float64(nil), workout.TargetWeight...)
+	workout.TargetReps = append([]int(nil), workout.TargetReps...)
+	workout.TargetSets = append([]int(nil), workout.TargetSets...)
+	workout.WarmUp = append([]bool(nil), workout.WarmUp...)
+	padExtras(workout, len(workout.Lifts))
+
+	if index == len(workout.Lifts) {
+		workout.Lifts = append(workout.Lifts, lift.Name)
+		workout.Weight = append(workout.Weight, lift.Weight)
+		workout.Reps = append(workout.Reps, lift.Reps)
+		workout.Sets = append(workout.Sets, lift.Sets)
+		workout.RPE = append(workout.RPE, lift.RPE)
+		workout.RIR = append(workout.RIR, lift.RIR)
+		workout.Tempo = append(workout.Tempo, lift.Tempo)
+		workout.Rest = append(workout.Rest, lift.Rest)
+		workout.EntryUnits = append(workout.EntryUnits, "")
+		workout.TargetWeight = append(workout.TargetWeight, lift.TargetWeight)
+		workout.TargetReps = append(workout.TargetReps, lift.TargetReps)
+		workout.TargetSets = append(workout.TargetSets, lift.TargetSets)
+		workout.WarmUp = append(workout.WarmUp, lift.WarmUp)
+	} else {
+		workout.Lifts[index] = lift.Name
+		workout.Weight[index] = lift.Weight
+		workout.Reps[index] = lift.Reps
+		workout.Sets[index] = lift.Sets
+		workout.RPE[index] = lift.RPE
+		workout.RIR[index] = lift.RIR
+		workout.Tempo[index] = lift.Tempo
+		workout.Rest[index] = lift.Rest
+		workout.EntryUnits[index] = "" // entered now, in the request's unit
+		workout.TargetWeight[index] = lift.TargetWeight
+		workout.TargetReps[index] = lift.TargetReps
+		workout.TargetSets[index] = lift.TargetSets
+		workout.WarmUp[index] = lift.WarmUp
+	}
+	compactExtras(workout)
+}
+
+// insertLift places lift at index, moving the lifts from index onwards along
+// by one.
+func insertLift(workout *models.Workout, index int, lift models.Lift) {
+	workout.Lifts = slices.Insert(slices.Clone(workout.Lifts), index, lift.Name)
+	workout.Weight = slices.Insert(slices.Clone(workout.Weight), index, lift.Weight)
+	workout.Reps = slices.Insert(slices.Clone(workout.Reps), index, lift.Reps)
+	workout.Sets = slices.Insert(slices.Clone(workout.Sets), index, lift.Sets)
+	workout.RPE = slices.Clone(workout.RPE)
+	workout.RIR = slices.Clone(workout.RIR)
+	workout.Tempo = slices.Clone(workout.Tempo)
+	workout.Rest = slices.Clone(workout.Rest)
+	workout.EntryUnits = slices.Clone(workout.EntryUnits)
+	workout.TargetWeight = slices.Clone(workout.TargetWeight)
+	workout.TargetReps = slices.Clone(workout.TargetReps)
+	workout.TargetSets = slices.Clone(workout.TargetSets)
+	workout.WarmUp = slices.Clone(workout.WarmUp)
+	padExtras(workout, len(workout.Lifts)-1)
+
+	workout.RPE = slices.Insert(workout.RPE, index, lift.RPE)
+	workout.RIR = slices.Insert(workout.RIR, index, lift.RIR)
+	workout.Tempo = slices.Insert(workout.Tempo, index, lift.Tempo)
+	workout.Rest = slices.Insert(workout.Rest, index, lift.Rest)
+	workout.EntryUnits = slices.Insert(workout.EntryUnits, index, "") // entered now, in the request's unit
+	workout.TargetWeight = slices.Insert(workout.TargetWeight, index, lift.TargetWeight)
+	workout.TargetReps = slices.Insert(workout.TargetReps, index, lift.TargetReps)
+	workout.TargetSets = slices.Insert(workout.TargetSets, index, lift.TargetSets)
+	workout.WarmUp = slices.Insert(workout.WarmUp, index, lift.WarmUp)
+	compactExtras(workout)
+}
+
+// padExtras extends the optional per-lift slices (RPE, RIR, tempo, rest,
+// entry units, targets and warm-up flags) with unset values up to n entries.
+func padExtras(workout *models.Workout, n int) {
+	for len(workout.RPE) < n {
+		workout.RPE = append(workout.RPE, 0)
+	}
+	for len(workout.RIR) < n {
+		workout.RIR = append(workout.RIR, nil)
+	}
+	for len(workout.Tempo) < n {
+		workout.Tempo = append(workout.Tempo, "")
+	}
+	for len(workout.Rest) < n {
+		workout.Rest = append(workout.Rest, 0)
+	}
+	for len(workout.EntryUnits) < n {
+		workout.EntryUnits = append(workout.EntryUnits, "")
+	}
+	for len(workout.TargetWeight) < n {
+		workout.TargetWeight = append(workout.TargetWeight, 0)
+	}
+	for len(workout.TargetReps) < n {
+		workout.TargetReps = append(workout.TargetReps, 0)
+	}
+	for len(workout.TargetSets) < n {
+		workout.TargetSets = append(workout.TargetSets, 0)
+	}
+	for len(workout.WarmUp) < n {
+		workout.WarmUp = append(workout.WarmUp, false)
+	}
+}
+
+// compactExtras drops optional per-lift slices that hold no values, so
+// workouts without them serialise as before.
+func compactExtras(workout *models.Workout) {
+	if !slices.ContainsFunc(workout.RPE, func(v float64) bool { return v != 0 }) {
+		workout.RPE = nil
+	}
+	if !slices.ContainsFunc(workout.RIR, func(v *int) bool { return v != nil }) {
+		workout.RIR = nil
+	}
+	if !slices.ContainsFunc(workout.Tempo, func(v string) bool { return v != "" }) {
+		workout.Tempo = nil
+	}
+	if !slices.ContainsFunc(workout.Rest, func(v int) bool { return v != 0 }) {
+		workout.Rest = nil
+	}
+	if !slices.ContainsFunc(workout.EntryUnits, func(v string) bool { return v != models.UnitKilogram }) {
+		workout.EntryUnits = nil
+	}
+	if !slices.ContainsFunc(workout.TargetWeight, func(v float64) bool { return v != 0 }) &&
+		!slices.ContainsFunc(workout.TargetReps, func(v int) bool { return v != 0 }) &&
+		!slices.ContainsFunc(workout.TargetSets, func(v int) bool { return v != 0 }) {
+		workout.TargetWeight, workout.TargetReps, workout.TargetSets = nil, nil, nil
+	}
+	if !slices.Contains(workout.WarmUp, true) {
+		workout.WarmUp = nil
+	}
+}
+
+// applyMergePatch merges patch into v (a pointer to a struct) by round-tripping
+// it through its JSON representation.
+func applyMergePatch(v interface{}, patch []byte) error {
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return &ValidationError{Fields: []FieldError{{Field: "body", Message: "must be valid JSON"}}}
+	}
+	if _, ok := patchDoc.(map[string]interface{}); !ok {
+		return &ValidationError{Fields: []FieldError{{Field: "body", Message: "must be a JSON object"}}}
+	}
+
+	original, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode document: %v", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(original, &doc); err != nil {
+		return fmt.Errorf("failed to decode document: %v", err)
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patchDoc))
+	if err != nil {
+		return fmt.Errorf("failed to encode patched document: %v", err)
+	}
+
+	// Decode into a zeroed value so removed keys are cleared
+	elem := reflect.ValueOf(v).Elem()
+	elem.Set(reflect.Zero(elem.Type()))
+	if err := json.Unmarshal(merged, v); err != nil {
+		return &ValidationError{Fields: []FieldError{{Field: "body", Message: err.Error()}}}
+	}
+	return nil
+}
+
+// mergePatch implements the RFC 7396 merge algorithm on decoded JSON values.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+		} else {
+			targetObj[key] = mergePatch(targetObj[key], value)
+		}
+	}
+	return targetObj
+}
@@ -0,0 +1,141 @@
+package backend
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"fitness-dev/models"
+)
+
+func TestMergePatch(t *testing.T) {
+	// The examples of RFC 7396, appendix A
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		var target, patch interface{}
+		if err := json.Unmarshal([]byte(tt.target), &target); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(mergePatch(target, patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("mergePatch(%s, %s) = %s, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		patch   string
+		invalid bool
+		check   func(w models.Workout) bool
+	}{
+		{name: "changes a field", patch: `{"mood_in":"Tired"}`,
+			check: func(w models.Workout) bool { return w.MoodIn == "Tired" && w.MoodOut == "Great" }},
+		{name: "null clears a field", patch: `{"session_rpe":null}`,
+			check: func(w models.Workout) bool { return w.SessionRPE == 0 && w.MoodIn == "Good" }},
+		{name: "replaces an array", patch: `{"lifts":["Deadlift"],"weight":[180],"reps":[3],"sets":[1]}`,
+			check: func(w models.Workout) bool {
+				return reflect.DeepEqual(w.Lifts, []string{"Deadlift"}) && w.Weight[0] == 180
+			}},
+		{name: "empty patch", patch: `{}`,
+			check: func(w models.Workout) bool { return reflect.DeepEqual(w, patchTarget()) }},
+		{name: "not JSON", patch: `{"mood_in":`, invalid: true},
+		{name: "not an object", patch: `["mood_in"]`, invalid: true},
+		{name: "wrong type", patch: `{"reps":"five"}`, invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := patchTarget()
+			err := applyMergePatch(&w, []byte(tt.patch))
+			if tt.invalid {
+				if fields := invalidFields(t, err); !reflect.DeepEqual(fields, []string{"body"}) {
+					t.Errorf("invalid fields = %v, want [body]", fields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyMergePatch() failed: %v", err)
+			}
+			if !tt.check(w) {
+				t.Errorf("patched workout = %+v", w)
+			}
+		})
+	}
+}
+
+func patchTarget() models.Workout {
+	w := validWorkout()
+	w.ID, w.Version, w.SessionRPE = 7, 2, 7
+	return w
+}
+
+func TestInsertLift(t *testing.T) {
+	eight := 8
+	original := validWorkout()
+	original.RPE = []float64{8, 0}
+	w := validWorkout()
+	w.RPE = []float64{8, 0}
+	source := w
+
+	insertLift(&w, 1, models.Lift{Name: "Row", Weight: 60, Reps: 10, Sets: 3, RIR: &eight})
+	if !reflect.DeepEqual(w.Lifts, []string{"Squat", "Row", "Bench Press"}) {
+		t.Fatalf("lifts = %v", w.Lifts)
+	}
+	if got := GetLift(w, 1); got.Weight != 60 || got.RIR == nil || *got.RIR != 8 || got.RPE != 0 {
+		t.Errorf("inserted lift = %+v", got)
+	}
+	if got := GetLift(w, 2); got.Name != "Bench Press" || got.Weight != 80 || got.RIR != nil {
+		t.Errorf("moved lift = %+v", got)
+	}
+	if !reflect.DeepEqual(w.RPE, []float64{8, 0, 0}) {
+		t.Errorf("rpe = %v, want the extras moved along", w.RPE)
+	}
+	if !reflect.DeepEqual(source, original) {
+		t.Errorf("insertLift changed the workout it was copied from: %+v", source)
+	}
+}
+
+func TestSetLift(t *testing.T) {
+	w := validWorkout()
+	before := validWorkout()
+	copied := w
+
+	AppendLift(&copied, models.Lift{Name: "Curl", Weight: 15, Reps: 12, Sets: 2, WarmUp: true})
+	if !reflect.DeepEqual(w, before) {
+		t.Errorf("AppendLift changed the workout it was copied from: %+v", w)
+	}
+	if len(copied.Lifts) != 3 || !reflect.DeepEqual(copied.WarmUp, []bool{false, false, true}) {
+		t.Errorf("appended workout = %+v", copied)
+	}
+
+	// Replacing the only warm-up drops the flags altogether
+	setLift(&copied, 2, models.Lift{Name: "Curl", Weight: 17.5, Reps: 10, Sets: 2})
+	if copied.Weight[2] != 17.5 || copied.WarmUp != nil {
+		t.Errorf("replaced lift = %+v, warm-ups %v", GetLift(copied, 2), copied.WarmUp)
+	}
+}
@@ -0,0 +1,525 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func normalizePlanned(p models.PlannedSession) models.PlannedSession {
+	p.Athlete = strings.TrimSpace(p.Athlete)
+	p.Date = strings.ReplaceAll(strings.TrimSpace(p.Date), "-", "/")
+	p.TimeIn = strings.TrimSpace(p.TimeIn)
+	p.TimeOut = strings.TrimSpace(p.TimeOut)
+	p.Title = strings.TrimSpace(p.Title)
+	p.Notes = strings.TrimSpace(p.Notes)
+	p.UID = strings.TrimSpace(p.UID)
+	return p
+}
+
+func validatePlanned(p models.PlannedSession) error {
+	verr := &ValidationError{}
+	if p.Athlete == "" {
+		verr.Add("athlete", "is required")
+	}
+	if p.Title == "" {
+		verr.Add("title", "is required")
+	}
+	if _, err := time.Parse(models.DateLayout, p.Date); err != nil {
+		verr.Add("date", "must be a valid date in DD/MM/YYYY format")
+	}
+	if p.TimeIn != "" || p.TimeOut != "" {
+		if _, err := parseClock(p.TimeIn); err != nil {
+			verr.Add("time_in", "must be a valid time in HH:MM format, or empty with time_out for the whole day")
+		}
+		if _, err := parseClock(p.TimeOut); err != nil {
+			verr.Add("time_out", "must be a valid time in HH:MM format, or empty with time_in for the whole day")
+		}
+	}
+	return verr.OrNil()
+}
+
+// planLifts returns what a session planned from a program or template is
+// to perform: the program's next workout, or the template's targets. Weights
+// are in kg. templateID is the template the program has come to; lifts is
+// empty for sessions planned from neither.
+func planLifts(db *sql.DB, p models.PlannedSession, settings models.Settings) (name string, templateID int, lifts []models.Lift, err error) {
+	switch {
+	case p.ProgramID != 0:
+		prescription, err := NextWorkout(db, p.ProgramID, p.Athlete, settings)
+		if err != nil {
+			return "", 0, nil, err
+		}
+		for i := range prescription.Workout.Lifts {
+			lifts = append(lifts, GetLift(prescription.Workout, i))
+		}
+		return prescription.Template, prescription.TemplateID, lifts, nil
+	case p.TemplateID != 0:
+		template, err := GetTemplate(db, p.TemplateID)
+		if err != nil {
+			return "", 0, nil, err
+		}
+		for _, e := range template.Exercises {
+			lifts = append(lifts, models.Lift{Name: e.Name, Weight: e.Weight, Reps: e.Reps, Sets: e.Sets})
+		}
+		return template.Name, template.ID, lifts, nil
+	}
+	return "", 0, nil, nil
+}
+
+// fillPlan fills in the template, title and notes of a session planned
+// from a program with the program's next workout, and those of a session
+// planned from a template with its targets. Weights in the notes are in the
+// athlete's unit, rounded to their plates.
+func fillPlan(db *sql.DB, p *models.PlannedSession) error {
+	if p.ProgramID == 0 && p.TemplateID == 0 {
+		return nil
+	}
+	settings, err := GetSettings(db, p.Athlete)
+	if err != nil {
+		return err
+	}
+	name, templateID, lifts, err := planLifts(db, *p, settings)
+	if err != nil {
+		return err
+	}
+	p.TemplateID = templateID
+	if p.Title == "" {
+		p.Title = name
+	}
+	if p.Notes == "" {
+		var notes []string
+		for _, lift := range lifts {
+			notes = append(notes, fmt.Sprintf("%s %d x %d @ %s", lift.Name, lift.Sets, lift.Reps, FormatLoad(lift.Weight, settings)))
+		}
+		p.Notes = strings.Join(notes, "\n")
+	}
+	return nil
+}
+
+// CreatePlannedSession schedules a session and returns its ID. Sessions
+// planned from a program or template take their title and notes from it
+// unless given. The session's planned workout is created with it.
+func CreatePlannedSession(db *sql.DB, p models.PlannedSession, actor Actor) (int, error) {
+	p = normalizePlanned(p)
+	if err := fillPlan(db, &p); err != nil {
+		return 0, err
+	}
+	if err := validatePlanned(p); err != nil {
+		return 0, err
+	}
+	workout, err := plannedWorkout(db, p)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = executeInTransaction(db, func(tx *sql.Tx) error {
+		var err error
+		id, err = insertPlanned(tx, p, workout, actor)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// insertPlanned stores a planned session together with its workout, which
+// is stored as planned and linked to it. The workout is what the session
+// becomes: it shows in date range queries, counts towards adherence, and is
+// started, skipped or completed when the session is.
+func insertPlanned(tx *sql.Tx, p models.PlannedSession, workout models.Workout, actor Actor) (int, error) {
+	workout.Status = models.StatusPlanned
+	workoutID, err := insertWorkout(tx, workout, actor)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO planned_sessions (athlete, day, time_in, time_out, title, notes, program_id, template_id, uid, workout_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, p.Athlete, p.Date, p.TimeIn, p.TimeOut, p.Title, p.Notes, p.ProgramID, p.TemplateID, p.UID, workoutID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert planned session: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve planned session ID: %v", err)
+	}
+
+	after, err := getPlannedSession(tx, int(id))
+	if err != nil {
+		return 0, err
+	}
+	return int(id), recordAudit(tx, actor, ActionCreate, EntityPlannedSession, int(id), nil, after)
+}
+
+// linkedStatus is the status of a planned session's workout, empty without
+// one or while it is in the trash.
+const linkedStatus = `coalesce((SELECT status FROM workouts w WHERE w.id = planned_sessions.workout_id AND w.deleted_at IS NULL), '')`
+
+// plannedColumns ends with the status of the linked workout, if any.
+const plannedColumns = `id, athlete, day, time_in, time_out, title, notes, program_id, template_id, uid, workout_id, ` + linkedStatus
+
+func scanPlanned(row rowScanner) (models.PlannedSession, error) {
+	var p models.PlannedSession
+	var status string
+	err := row.Scan(&p.ID, &p.Athlete, &p.Date, &p.TimeIn, &p.TimeOut, &p.Title, &p.Notes, &p.ProgramID, &p.TemplateID, &p.UID, &p.WorkoutID, &status)
+	p.Status = models.StatusPlanned
+	if p.WorkoutID != 0 && status != "" {
+		p.Status = status
+	}
+	return p, err
+}
+
+func GetPlannedSession(db *sql.DB, id int) (models.PlannedSession, error) {
+	return getPlannedSession(db, id)
+}
+
+func getPlannedSession(q querier, id int) (models.PlannedSession, error) {
+	p, err := scanPlanned(q.QueryRow(`SELECT `+plannedColumns+` FROM planned_sessions WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return models.PlannedSession{}, &NotFoundError{Resource: "planned session", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.PlannedSession{}, fmt.Errorf("failed to fetch planned session: %v", err)
+	}
+	return p, nil
+}
+
+// GetPlannedSessions returns an athlete's planned sessions from one day to
+// another (DD/MM/YYYY, either may be empty), soonest first. With pending
+// set, only sessions still planned are returned.
+func GetPlannedSessions(db *sql.DB, athlete, from, to string, pending bool) ([]models.PlannedSession, error) {
+	verr := &ValidationError{}
+	if _, err := time.Parse(models.DateLayout, from); from != "" && err != nil {
+		verr.Add("from", "must be a valid date in DD/MM/YYYY format")
+	}
+	if _, err := time.Parse(models.DateLayout, to); to != "" && err != nil {
+		verr.Add("to", "must be a valid date in DD/MM/YYYY format")
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + plannedColumns + ` FROM planned_sessions WHERE athlete = ?`
+	args := []interface{}{athlete}
+	if from != "" {
+		query += ` AND ` + sortableDay + ` >= ?`
+		args = append(args, sortableDate(from))
+	}
+	if to != "" {
+		query += ` AND ` + sortableDay + ` <= ?`
+		args = append(args, sortableDate(to))
+	}
+	if pending {
+		query += ` AND ` + linkedStatus + ` IN ('', 'planned')`
+	}
+	rows, err := db.Query(query+` ORDER BY `+sortableDay+`, time_in, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch planned sessions: %v", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.PlannedSession{}
+	for rows.Next() {
+		p, err := scanPlanned(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan planned session row: %v", err)
+		}
+		sessions = append(sessions, p)
+	}
+	return sessions, rows.Err()
+}
+
+// DeletePlannedSession removes a planned session. Its workout goes to the
+// trash with it while still planned, and is kept once started or done.
+func DeletePlannedSession(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getPlannedSession(tx, id)
+		if err != nil {
+			return err
+		}
+		live, err := liveWorkout(tx, before)
+		if err != nil {
+			return err
+		}
+		if live != 0 && before.Status == models.StatusPlanned {
+			if err := deleteWorkout(tx, live, actor); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`DELETE FROM planned_sessions WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete planned session: %v", err)
+		}
+		return recordAudit(tx, actor, ActionDelete, EntityPlannedSession, id, before, nil)
+	})
+}
+
+// CompletePlannedSession marks a planned session as done by linking the
+// workout logged for it, in place of its planned workout, which goes to the
+// trash. A session whose workout was started or done cannot be relinked, and
+// only a workout of the athlete it was planned for can be linked.
+func CompletePlannedSession(db *sql.DB, id, workoutID int, actor Actor) (models.PlannedSession, error) {
+	p, err := GetPlannedSession(db, id)
+	if err != nil {
+		return models.PlannedSession{}, err
+	}
+	live, err := liveWorkout(db, p)
+	if err != nil {
+		return models.PlannedSession{}, err
+	}
+	if live != 0 && live != workoutID && p.Status != models.StatusPlanned {
+		return models.PlannedSession{}, &ConflictError{Message: fmt.Sprintf("planned session %d is already linked to workout %d", id, p.WorkoutID)}
+	}
+	workout, err := GetWorkoutByID(db, workoutID)
+	if err != nil {
+		return models.PlannedSession{}, err
+	}
+	if workout.Athlete != p.Athlete {
+		verr := &ValidationError{}
+		verr.Add("workout_id", "belongs to %s, not %s", workout.Athlete, p.Athlete)
+		return models.PlannedSession{}, verr
+	}
+
+	if p.WorkoutID != workoutID {
+		err := executeInTransaction(db, func(tx *sql.Tx) error {
+			if err := linkPlanned(tx, id, p.WorkoutID, workoutID, actor); err != nil {
+				return err
+			}
+			if live == 0 {
+				return nil
+			}
+			return deleteWorkout(tx, live, actor)
+		})
+		if err != nil {
+			return models.PlannedSession{}, err
+		}
+	}
+	p.WorkoutID, p.Status = workoutID, workout.Status
+	return p, nil
+}
+
+// plannedWorkout builds the workout for a planned session, on its day and
+// at its times, with the program's or template's lifts as targets.
+func plannedWorkout(db *sql.DB, p models.PlannedSession) (models.Workout, error) {
+	settings, err := GetSettings(db, p.Athlete)
+	if err != nil {
+		return models.Workout{}, err
+	}
+	_, _, lifts, err := planLifts(db, p, settings)
+	if err != nil {
+		return models.Workout{}, err
+	}
+	workout := models.Workout{Athlete: p.Athlete, Date: p.Date, TimeIn: p.TimeIn, TimeOut: p.TimeOut}
+	for _, lift := range lifts {
+		setLift(&workout, len(workout.Lifts), models.Lift{Name: lift.Name, TargetWeight: lift.Weight, TargetReps: lift.Reps, TargetSets: lift.Sets})
+	}
+	return workout, nil
+}
+
+// StartPlannedSession starts a planned session's workout now, moving it to
+// today as StartWorkout does.
+func StartPlannedSession(db *sql.DB, id int, actor Actor) (models.Workout, error) {
+	return workoutForPlanned(db, id, actor, StartWorkout, func(workout *models.Workout) {
+		now := time.Now()
+		workout.Date, workout.TimeIn, workout.TimeOut = now.Format(models.DateLayout), now.Format(models.TimeLayout), ""
+		workout.Status = models.StatusInProgress
+	})
+}
+
+// SkipPlannedSession marks a planned session's workout as skipped.
+func SkipPlannedSession(db *sql.DB, id int, actor Actor) (models.Workout, error) {
+	return workoutForPlanned(db, id, actor, SkipWorkout, func(workout *models.Workout) {
+		workout.Status = models.StatusSkipped
+	})
+}
+
+// workoutForPlanned moves a planned session's workout on with advance. A
+// session left without a workout, because it was planned before sessions
+// came with one or its workout was deleted, gets a new one adjusted by fn.
+// It is created and linked in one transaction, so a session raced by another
+// request is left with a single workout.
+func workoutForPlanned(db *sql.DB, id int, actor Actor, advance func(db *sql.DB, workoutID, version int, actor Actor) (models.Workout, error), fn func(workout *models.Workout)) (models.Workout, error) {
+	p, err := GetPlannedSession(db, id)
+	if err != nil {
+		return models.Workout{}, err
+	}
+	live, err := liveWorkout(db, p)
+	if err != nil {
+		return models.Workout{}, err
+	}
+	if live != 0 {
+		if p.Status != models.StatusPlanned {
+			return models.Workout{}, &ConflictError{Message: fmt.Sprintf("planned session %d is already linked to workout %d", id, p.WorkoutID)}
+		}
+		return advance(db, live, 0, actor)
+	}
+	workout, err := plannedWorkout(db, p)
+	if err != nil {
+		return models.Workout{}, err
+	}
+	fn(&workout)
+
+	var workoutID int
+	err = executeInTransaction(db, func(tx *sql.Tx) error {
+		var err error
+		if workoutID, err = insertWorkout(tx, workout, actor); err != nil {
+			return err
+		}
+		return linkPlanned(tx, id, p.WorkoutID, workoutID, actor)
+	})
+	if err != nil {
+		return models.Workout{}, err
+	}
+	return GetWorkoutByID(db, workoutID)
+}
+
+// liveWorkout returns the ID of a planned session's workout, or 0 if it has
+// none or it is in the trash.
+func liveWorkout(q querier, p models.PlannedSession) (int, error) {
+	if p.WorkoutID == 0 {
+		return 0, nil
+	}
+	var n int
+	if err := q.QueryRow(`SELECT count(*) FROM workouts WHERE id = ? AND deleted_at IS NULL`, p.WorkoutID).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to fetch planned workout: %v", err)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return p.WorkoutID, nil
+}
+
+// linkPlanned links a workout to a planned session in place of the workout
+// from (0 for none), unless another workout was linked to it first.
+func linkPlanned(tx *sql.Tx, id, from, workoutID int, actor Actor) error {
+	before, err := getPlannedSession(tx, id)
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(`UPDATE planned_sessions SET workout_id = ? WHERE id = ? AND workout_id = ?`, workoutID, id, from)
+	if err != nil {
+		return fmt.Errorf("failed to link planned session: %v", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return &ConflictError{Message: fmt.Sprintf("planned session %d is already linked to workout %d", id, before.WorkoutID)}
+	}
+
+	after, err := getPlannedSession(tx, id)
+	if err != nil {
+		return err
+	}
+	return recordAudit(tx, actor, ActionUpdate, EntityPlannedSession, id, before, after)
+}
+
+// ImportPlannedSessions stores sessions read from a calendar. A session
+// whose UID was imported before for the same athlete updates it instead,
+// moving its workout along, unless that workout has been started, skipped or
+// completed. Nothing is stored if any session is invalid.
+func ImportPlannedSessions(db *sql.DB, sessions []models.PlannedSession, actor Actor) (models.PlannedImport, error) {
+	result := models.PlannedImport{Sessions: []models.PlannedSession{}}
+	verr := &ValidationError{}
+	for i := range sessions {
+		sessions[i] = normalizePlanned(sessions[i])
+		if err, ok := validatePlanned(sessions[i]).(*ValidationError); ok {
+			for _, f := range err.Fields {
+				verr.Add(fmt.Sprintf("events[%d].%s", i, f.Field), "%s", f.Message)
+			}
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		return result, err
+	}
+	workouts := make([]models.Workout, len(sessions))
+	for i, p := range sessions {
+		workout, err := plannedWorkout(db, p)
+		if err != nil {
+			return result, err
+		}
+		workouts[i] = workout
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var ids []int
+	for i, p := range sessions {
+		existing := models.PlannedSession{}
+		if p.UID != "" {
+			existing, err = scanPlanned(tx.QueryRow(`SELECT `+plannedColumns+` FROM planned_sessions WHERE athlete = ? AND uid = ?`, p.Athlete, p.UID))
+			if err != nil && err != sql.ErrNoRows {
+				return result, fmt.Errorf("failed to fetch planned session: %v", err)
+			}
+		}
+		switch {
+		case existing.ID == 0:
+			id, err := insertPlanned(tx, p, workouts[i], actor)
+			if err != nil {
+				return result, err
+			}
+			ids = append(ids, id)
+			result.Created++
+		case existing.Status != models.StatusPlanned:
+			result.Skipped++
+		default:
+			query := `UPDATE planned_sessions SET day = ?, time_in = ?, time_out = ?, title = ?, notes = ? WHERE id = ?`
+			if _, err := tx.Exec(query, p.Date, p.TimeIn, p.TimeOut, p.Title, p.Notes, existing.ID); err != nil {
+				return result, fmt.Errorf("failed to update planned session: %v", err)
+			}
+			after, err := getPlannedSession(tx, existing.ID)
+			if err != nil {
+				return result, err
+			}
+			if err := recordAudit(tx, actor, ActionUpdate, EntityPlannedSession, existing.ID, existing, after); err != nil {
+				return result, err
+			}
+			if err := reschedulePlanned(tx, existing, p, actor); err != nil {
+				return result, err
+			}
+			ids = append(ids, existing.ID)
+			result.Updated++
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit import: %v", err)
+	}
+
+	for _, id := range ids {
+		p, err := GetPlannedSession(db, id)
+		if err != nil {
+			return result, err
+		}
+		result.Sessions = append(result.Sessions, p)
+	}
+	return result, nil
+}
+
+// reschedulePlanned moves the workout of an existing planned session to the
+// day and times of p.
+func reschedulePlanned(tx *sql.Tx, existing, p models.PlannedSession, actor Actor) error {
+	live, err := liveWorkout(tx, existing)
+	if err != nil || live == 0 {
+		return err
+	}
+	before, err := getWorkoutByID(tx, live)
+	if err != nil {
+		return err
+	}
+	workout := before
+	workout.Date, workout.TimeIn, workout.TimeOut = p.Date, p.TimeIn, p.TimeOut
+	if err := replaceWorkout(tx, workout); err != nil {
+		return err
+	}
+	after, err := getWorkoutByID(tx, live)
+	if err != nil {
+		return err
+	}
+	return recordChange(tx, actor, ActionUpdate, live, &before, &after)
+}
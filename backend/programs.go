@@ -0,0 +1,271 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func validateProgram(program models.Program) error {
+	verr := &ValidationError{}
+	if strings.TrimSpace(program.Name) == "" {
+		verr.Add("name", "is required")
+	}
+	if _, err := time.Parse(models.DateLayout, program.StartDate); err != nil {
+		verr.Add("start_date", "must be a valid date in DD/MM/YYYY format")
+	}
+	if len(program.TemplateIDs) == 0 {
+		verr.Add("template_ids", "at least one template is required")
+	}
+
+	for i, r := range program.Rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		if strings.TrimSpace(r.Exercise) == "" {
+			verr.Add(field+".exercise", "is required")
+		}
+		if r.Increment < 0 {
+			verr.Add(field+".increment", "must not be negative")
+		}
+		if r.DeloadAfter < 0 {
+			verr.Add(field+".deload_after", "must not be negative")
+		}
+		if r.DeloadPercent < 0 || r.DeloadPercent >= 100 {
+			verr.Add(field+".deload_percent", "must be between 0 and 100")
+		}
+		if r.TargetRPE != 0 && (r.TargetRPE < 1 || r.TargetRPE > MaxRPE) {
+			verr.Add(field+".target_rpe", "must be between 1 and %g", MaxRPE)
+		}
+		switch r.Kind {
+		case models.RuleLinear:
+		case models.RuleDouble:
+			if r.RepMin < 1 || r.RepMax < r.RepMin || r.RepMax > MaxReps {
+				verr.Add(field+".rep_min", "rep_min and rep_max must form a range within 1 and %d", MaxReps)
+			}
+		case models.RulePercentage:
+			if r.TrainingMax <= 0 || r.TrainingMax > MaxWeight {
+				verr.Add(field+".training_max", "must be between 0 and %g", MaxWeight)
+			}
+		default:
+			verr.Add(field+".kind", "must be one of %s, %s, %s", models.RuleLinear, models.RuleDouble, models.RulePercentage)
+		}
+	}
+	return verr.OrNil()
+}
+
+// CreateProgram stores a new program and returns its ID. The first program
+// created becomes the active one.
+func CreateProgram(db *sql.DB, program models.Program, actor Actor) (int, error) {
+	program.Name = strings.TrimSpace(program.Name)
+	program.StartDate = strings.ReplaceAll(strings.TrimSpace(program.StartDate), "-", "/")
+	if err := validateProgram(program); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		verr := &ValidationError{}
+		for i, templateID := range program.TemplateIDs {
+			var exists int
+			if err := tx.QueryRow(`SELECT count(*) FROM templates WHERE id = ?`, templateID).Scan(&exists); err != nil {
+				return fmt.Errorf("failed to check template: %v", err)
+			}
+			if exists == 0 {
+				verr.Add(fmt.Sprintf("template_ids[%d]", i), "template %d does not exist", templateID)
+			}
+		}
+		if err := verr.OrNil(); err != nil {
+			return err
+		}
+
+		var existing, programs int
+		err := tx.QueryRow(`SELECT count(*), coalesce(sum(name = ? COLLATE NOCASE), 0) FROM programs`, program.Name).Scan(&programs, &existing)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing program: %v", err)
+		}
+		if existing > 0 {
+			return &ConflictError{Message: fmt.Sprintf("a program named %q already exists", program.Name)}
+		}
+
+		result, err := tx.Exec(`INSERT INTO programs (name, start_date, active) VALUES (?, ?, ?)`, program.Name, program.StartDate, programs == 0)
+		if err != nil {
+			return fmt.Errorf("failed to insert program: %v", err)
+		}
+		programID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve program ID: %v", err)
+		}
+		id = int(programID)
+
+		for i, templateID := range program.TemplateIDs {
+			_, err := tx.Exec(`INSERT INTO program_templates (program_id, position, template_id) VALUES (?, ?, ?)`, id, i, templateID)
+			if err != nil {
+				return fmt.Errorf("failed to insert program template: %v", err)
+			}
+		}
+
+		ruleQuery := `INSERT INTO program_rules (program_id, exercise, kind, increment, deload_after, deload_percent, rep_min, rep_max, training_max, start_weight, target_rpe) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		for _, r := range program.Rules {
+			_, err := tx.Exec(ruleQuery, id, strings.TrimSpace(r.Exercise), r.Kind, r.Increment, r.DeloadAfter, r.DeloadPercent, r.RepMin, r.RepMax, r.TrainingMax, r.StartWeight, r.TargetRPE)
+			if err != nil {
+				return fmt.Errorf("failed to insert progression rule: %v", err)
+			}
+		}
+
+		after, err := getProgram(tx, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, ActionCreate, EntityProgram, id, nil, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func GetPrograms(db *sql.DB) ([]models.Program, error) {
+	rows, err := db.Query(`SELECT id FROM programs ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch programs: %v", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan program row: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	var programs []models.Program
+	for _, id := range ids {
+		program, err := GetProgram(db, id)
+		if err != nil {
+			return nil, err
+		}
+		programs = append(programs, program)
+	}
+	return programs, nil
+}
+
+func GetProgram(db *sql.DB, id int) (models.Program, error) {
+	return getProgram(db, id)
+}
+
+func getProgram(q querier, id int) (models.Program, error) {
+	var program models.Program
+	err := q.QueryRow(`SELECT id, name, start_date, active FROM programs WHERE id = ?`, id).Scan(&program.ID, &program.Name, &program.StartDate, &program.Active)
+	if err == sql.ErrNoRows {
+		return models.Program{}, &NotFoundError{Resource: "program", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.Program{}, fmt.Errorf("failed to fetch program: %v", err)
+	}
+
+	rows, err := q.Query(`SELECT template_id FROM program_templates WHERE program_id = ? ORDER BY position`, id)
+	if err != nil {
+		return models.Program{}, fmt.Errorf("failed to fetch program templates: %v", err)
+	}
+	for rows.Next() {
+		var templateID int
+		if err := rows.Scan(&templateID); err != nil {
+			rows.Close()
+			return models.Program{}, fmt.Errorf("failed to scan program template: %v", err)
+		}
+		program.TemplateIDs = append(program.TemplateIDs, templateID)
+	}
+	rows.Close()
+
+	ruleQuery := `SELECT exercise, kind, increment, deload_after, deload_percent, rep_min, rep_max, training_max, start_weight, target_rpe FROM program_rules WHERE program_id = ? ORDER BY id`
+	rows, err = q.Query(ruleQuery, id)
+	if err != nil {
+		return models.Program{}, fmt.Errorf("failed to fetch progression rules: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r models.ProgressionRule
+		if err := rows.Scan(&r.Exercise, &r.Kind, &r.Increment, &r.DeloadAfter, &r.DeloadPercent, &r.RepMin, &r.RepMax, &r.TrainingMax, &r.StartWeight, &r.TargetRPE); err != nil {
+			return models.Program{}, fmt.Errorf("failed to scan progression rule: %v", err)
+		}
+		program.Rules = append(program.Rules, r)
+	}
+
+	return program, rows.Err()
+}
+
+// GetActiveProgram returns the program currently being followed.
+func GetActiveProgram(db *sql.DB) (models.Program, error) {
+	var id int
+	err := db.QueryRow(`SELECT id FROM programs WHERE active = 1`).Scan(&id)
+	if err == sql.ErrNoRows {
+		return models.Program{}, &NotFoundError{Resource: "program", Key: "active"}
+	}
+	if err != nil {
+		return models.Program{}, fmt.Errorf("failed to fetch active program: %v", err)
+	}
+	return GetProgram(db, id)
+}
+
+// ActivateProgram makes id the active program, deactivating any other.
+func ActivateProgram(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getProgram(tx, id)
+		if err != nil {
+			return err
+		}
+		if before.Active {
+			return nil
+		}
+
+		var previous int
+		err = tx.QueryRow(`SELECT id FROM programs WHERE active = 1`).Scan(&previous)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to fetch active program: %v", err)
+		}
+		var deactivated models.Program
+		if previous != 0 {
+			if deactivated, err = getProgram(tx, previous); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`UPDATE programs SET active = (id = ?)`, id); err != nil {
+			return fmt.Errorf("failed to activate program: %v", err)
+		}
+
+		if previous != 0 {
+			after := deactivated
+			after.Active = false
+			if err := recordAudit(tx, actor, ActionUpdate, EntityProgram, previous, deactivated, after); err != nil {
+				return err
+			}
+		}
+		after := before
+		after.Active = true
+		return recordAudit(tx, actor, ActionUpdate, EntityProgram, id, before, after)
+	})
+}
+
+func DeleteProgram(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getProgram(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM program_rules WHERE program_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete progression rules: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM program_templates WHERE program_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete program templates: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM programs WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete program: %v", err)
+		}
+		return recordAudit(tx, actor, ActionDelete, EntityProgram, id, before, nil)
+	})
+}
@@ -0,0 +1,319 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// ProgramRounding is the increment prescribed weights are rounded to (kg).
+const ProgramRounding = 2.5
+
+// percentageWeeks is the four week wave used by percentage rules: three
+// loading weeks of heavier, lower rep top sets followed by a deload.
+var percentageWeeks = []struct {
+	percent float64
+	reps    int
+}{
+	{0.85, 5},
+	{0.90, 3},
+	{0.95, 1},
+	{0.60, 5},
+}
+
+// exerciseSession is the best set of one exercise within a single workout.
+type exerciseSession struct {
+	Date   string
+	Weight float64
+	Reps   int
+	Sets   int
+	RPE    float64 // from RPE or RIR, 0 if neither was logged
+	Unit   string  // unit the weight was entered in
+}
+
+// exerciseHistory returns one entry per workout of athlete containing
+// exercise, on or after since (DD/MM/YYYY), oldest first. When an exercise appears more than
+// once in a workout the heaviest entry is used.
+func exerciseHistory(db *sql.DB, athlete, exercise, since string) ([]exerciseSession, error) {
+	query := `SELECT w.id, w.day, l.weight, l.reps, l.sets, l.rpe, l.rir, l.unit FROM lifts l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE l.name = ? COLLATE NOCASE AND w.athlete = ? AND w.deleted_at IS NULL AND w.status = 'completed' AND l.warm_up = 0 AND ` + sortableDay + ` >= ?
+		ORDER BY ` + sortableDay + `, w.time_in, l.id`
+	rows, err := db.Query(query, strings.TrimSpace(exercise), athlete, sortableDate(since))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exercise history: %v", err)
+	}
+	defer rows.Close()
+
+	var sessions []exerciseSession
+	lastWorkout := -1
+	for rows.Next() {
+		var workoutID int
+		var s exerciseSession
+		var rir sql.NullInt64
+		if err := rows.Scan(&workoutID, &s.Date, &s.Weight, &s.Reps, &s.Sets, &s.RPE, &rir, &s.Unit); err != nil {
+			return nil, fmt.Errorf("failed to scan exercise history: %v", err)
+		}
+		if s.Unit == "" {
+			s.Unit = models.UnitKilogram
+		}
+		if rir.Valid {
+			s.RPE = MaxRPE - float64(rir.Int64)
+		}
+		if workoutID == lastWorkout {
+			best := &sessions[len(sessions)-1]
+			if s.Weight > best.Weight || (s.Weight == best.Weight && s.Reps > best.Reps) {
+				*best = s
+			}
+			continue
+		}
+		sessions = append(sessions, s)
+		lastWorkout = workoutID
+	}
+	return sessions, rows.Err()
+}
+
+// sortableDate turns DD/MM/YYYY into YYYYMMDD for comparison with sortableDay.
+func sortableDate(day string) string {
+	t, err := time.Parse(models.DateLayout, day)
+	if err != nil {
+		return ""
+	}
+	return t.Format("20060102")
+}
+
+func roundWeight(weight, step float64) float64 {
+	if step <= 0 {
+		return weight
+	}
+	return math.Round(weight/step) * step
+}
+
+// prescriptionUnits are the settings a prescription is worked out with.
+// Prescribed weights are in kg, but athletes load them in their own unit and
+// plates, and read the notes in whatever unit they asked for.
+type prescriptionUnits struct {
+	athlete models.Settings // the plates logged sets were loaded with
+	display models.Settings // the unit and plates notes are shown in
+}
+
+// reached reports whether a logged set was at least weight (kg). Both are
+// compared as loaded: in the set's entry unit, the prescription rounded to
+// the plates as its draft was. 220 lb therefore reaches a 100 kg prescription
+// although it is stored as 99.79 kg.
+func (u prescriptionUnits) reached(s exerciseSession, weight float64) bool {
+	plates := InUnits(u.athlete, s.Unit)
+	prescribed := RoundToPlates(ConvertWeight(weight, models.UnitKilogram, plates.Units), plates)
+	return round2(ConvertWeight(s.Weight, models.UnitKilogram, plates.Units)) >= prescribed-weightEpsilon
+}
+
+func (u prescriptionUnits) format(kg float64) string {
+	return FormatLoad(kg, u.display)
+}
+
+// prescribe works through the logged history of an exercise under rule and
+// returns what should be lifted next, with a short explanation.
+func prescribe(rule models.ProgressionRule, target models.TemplateExercise, history []exerciseSession, units prescriptionUnits) (models.Lift, string) {
+	switch rule.Kind {
+	case models.RuleDouble:
+		return prescribeDouble(rule, target, history, units)
+	case models.RulePercentage:
+		return prescribePercentage(rule, target, history, units)
+	default:
+		return prescribeLinear(rule, target, history, units)
+	}
+}
+
+func startWeight(rule models.ProgressionRule, target models.TemplateExercise) float64 {
+	if rule.StartWeight > 0 {
+		return rule.StartWeight
+	}
+	return target.Weight
+}
+
+// autoregulate scales a weight increase by how hard a successful session was
+// compared to the rule's target RPE: a grinder holds the weight, an easy
+// session doubles the increase. It returns the multiplier and a note suffix.
+func autoregulate(rule models.ProgressionRule, s exerciseSession) (float64, string) {
+	if rule.TargetRPE == 0 || s.RPE == 0 {
+		return 1, ""
+	}
+	switch {
+	case s.RPE >= rule.TargetRPE+1:
+		return 0, fmt.Sprintf(" but RPE %g was above the target %g, holding weight", s.RPE, rule.TargetRPE)
+	case s.RPE <= rule.TargetRPE-1:
+		return 2, fmt.Sprintf(" at RPE %g, below the target %g, doubling the increase", s.RPE, rule.TargetRPE)
+	default:
+		return 1, ""
+	}
+}
+
+// deload reduces weight by the rule's deload percentage.
+func deload(rule models.ProgressionRule, weight float64) float64 {
+	return roundWeight(weight*(1-rule.DeloadPercent/100), ProgramRounding)
+}
+
+func prescribeLinear(rule models.ProgressionRule, target models.TemplateExercise, history []exerciseSession, units prescriptionUnits) (models.Lift, string) {
+	weight := startWeight(rule, target)
+	failures := 0
+	note := fmt.Sprintf("%s: starting weight", target.Name)
+
+	for _, s := range history {
+		if units.reached(s, weight) && s.Reps >= target.Reps && s.Sets >= target.Sets {
+			factor, effort := autoregulate(rule, s)
+			weight = roundWeight(s.Weight+rule.Increment*factor, ProgramRounding)
+			failures = 0
+			note = fmt.Sprintf("%s: completed %s %dx%d on %s", target.Name, units.format(s.Weight), s.Sets, s.Reps, s.Date)
+			if effort != "" {
+				note += effort
+			} else {
+				note += fmt.Sprintf(", adding %s", units.format(rule.Increment))
+			}
+			continue
+		}
+
+		failures++
+		if rule.DeloadAfter > 0 && failures >= rule.DeloadAfter {
+			weight = deload(rule, weight)
+			failures = 0
+			note = fmt.Sprintf("%s: missed %d sessions in a row, deloading %g%%", target.Name, rule.DeloadAfter, rule.DeloadPercent)
+		} else {
+			note = fmt.Sprintf("%s: missed target on %s, repeating weight (%d failure(s))", target.Name, s.Date, failures)
+		}
+	}
+
+	return models.Lift{Name: target.Name, Weight: weight, Reps: target.Reps, Sets: target.Sets}, note
+}
+
+func prescribeDouble(rule models.ProgressionRule, target models.TemplateExercise, history []exerciseSession, units prescriptionUnits) (models.Lift, string) {
+	weight := startWeight(rule, target)
+	reps := rule.RepMin
+	failures := 0
+	note := fmt.Sprintf("%s: starting at the bottom of the %d-%d rep range", target.Name, rule.RepMin, rule.RepMax)
+
+	for _, s := range history {
+		switch {
+		case units.reached(s, weight) && s.Reps >= rule.RepMax && s.Sets >= target.Sets:
+			failures = 0
+			factor, effort := autoregulate(rule, s)
+			if factor == 0 {
+				reps = rule.RepMax
+				note = fmt.Sprintf("%s: reached %d reps at %s%s", target.Name, rule.RepMax, units.format(s.Weight), effort)
+				break
+			}
+			weight = roundWeight(s.Weight+rule.Increment*factor, ProgramRounding)
+			reps = rule.RepMin
+			note = fmt.Sprintf("%s: reached %d reps at %s, adding %s and dropping to %d reps", target.Name, rule.RepMax, units.format(s.Weight), units.format(rule.Increment*factor), rule.RepMin)
+		case units.reached(s, weight) && s.Reps >= rule.RepMin:
+			reps = s.Reps + 1
+			if reps > rule.RepMax {
+				reps = rule.RepMax
+			}
+			failures = 0
+			note = fmt.Sprintf("%s: did %d reps at %s, aim for %d", target.Name, s.Reps, units.format(s.Weight), reps)
+		default:
+			failures++
+			if rule.DeloadAfter > 0 && failures >= rule.DeloadAfter {
+				weight = deload(rule, weight)
+				reps = rule.RepMin
+				failures = 0
+				note = fmt.Sprintf("%s: below %d reps %d times, deloading %g%%", target.Name, rule.RepMin, rule.DeloadAfter, rule.DeloadPercent)
+			} else {
+				note = fmt.Sprintf("%s: below the rep range on %s, repeating", target.Name, s.Date)
+			}
+		}
+	}
+
+	return models.Lift{Name: target.Name, Weight: weight, Reps: reps, Sets: target.Sets}, note
+}
+
+func prescribePercentage(rule models.ProgressionRule, target models.TemplateExercise, history []exerciseSession, units prescriptionUnits) (models.Lift, string) {
+	done := len(history)
+	cycle := done / len(percentageWeeks)
+	week := percentageWeeks[done%len(percentageWeeks)]
+
+	trainingMax := rule.TrainingMax + float64(cycle)*rule.Increment
+	weight := roundWeight(trainingMax*week.percent, ProgramRounding)
+	note := fmt.Sprintf("%s: cycle %d week %d, %g%% of %s training max", target.Name, cycle+1, done%len(percentageWeeks)+1, week.percent*100, units.format(trainingMax))
+
+	return models.Lift{Name: target.Name, Weight: weight, Reps: week.reps, Sets: target.Sets}, note
+}
+
+// NextWorkout works out an athlete's next session of a program. Templates
+// are performed in rotation, counting every workout the athlete logged since
+// the program's start date. Exercises with a progression rule get a weight
+// computed from the athlete's history; the rest are pre-filled from their
+// last performance. The notes show weights in display's unit.
+func NextWorkout(db *sql.DB, programID int, athlete string, display models.Settings) (models.Prescription, error) {
+	program, err := GetProgram(db, programID)
+	if err != nil {
+		return models.Prescription{}, err
+	}
+	settings, err := GetSettings(db, athlete)
+	if err != nil {
+		return models.Prescription{}, err
+	}
+	units := prescriptionUnits{athlete: settings, display: display}
+
+	var logged int
+	query := `SELECT count(*) FROM workouts WHERE athlete = ? AND deleted_at IS NULL AND status = 'completed' AND ` + sortableDay + ` >= ?`
+	if err := db.QueryRow(query, athlete, sortableDate(program.StartDate)).Scan(&logged); err != nil {
+		return models.Prescription{}, fmt.Errorf("failed to count program workouts: %v", err)
+	}
+	templateID := program.TemplateIDs[logged%len(program.TemplateIDs)]
+
+	template, err := GetTemplate(db, templateID)
+	if err != nil {
+		return models.Prescription{}, err
+	}
+	draft, err := WorkoutFromTemplate(db, templateID, true)
+	if err != nil {
+		return models.Prescription{}, err
+	}
+
+	prescription := models.Prescription{
+		ProgramID:  program.ID,
+		TemplateID: template.ID,
+		Template:   template.Name,
+	}
+	for i, exercise := range template.Exercises {
+		rule, ok := findRule(program.Rules, exercise.Name)
+		if !ok {
+			continue
+		}
+
+		history, err := exerciseHistory(db, athlete, exercise.Name, program.StartDate)
+		if err != nil {
+			return models.Prescription{}, err
+		}
+		lift, note := prescribe(rule, exercise, history, units)
+		lift.RPE = rule.TargetRPE
+		setLift(&draft, i, lift)
+		prescription.Notes = append(prescription.Notes, note)
+	}
+
+	prescription.Workout = draft
+	return prescription, nil
+}
+
+// NextActiveWorkout is NextWorkout for the active program.
+func NextActiveWorkout(db *sql.DB, athlete string, display models.Settings) (models.Prescription, error) {
+	program, err := GetActiveProgram(db)
+	if err != nil {
+		return models.Prescription{}, err
+	}
+	return NextWorkout(db, program.ID, athlete, display)
+}
+
+func findRule(rules []models.ProgressionRule, exercise string) (models.ProgressionRule, bool) {
+	for _, r := range rules {
+		if strings.EqualFold(strings.TrimSpace(r.Exercise), strings.TrimSpace(exercise)) {
+			return r, true
+		}
+	}
+	return models.ProgressionRule{}, false
+}
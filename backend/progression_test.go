@@ -0,0 +1,180 @@
+package backend
+
+import (
+	"testing"
+
+	"fitness-dev/models"
+)
+
+var (
+	kgPlates = models.Settings{Units: models.UnitKilogram, SmallestPlate: 1.25}
+	lbPlates = models.Settings{Units: models.UnitPound, SmallestPlate: 2.5}
+	kgUnits  = prescriptionUnits{athlete: kgPlates, display: kgPlates}
+)
+
+// loggedSession is a session of sets x reps at a weight entered in units,
+// stored in kg to the gram as workouts are.
+func loggedSession(day string, weight float64, units string, sets, reps int) exerciseSession {
+	kg := roundWeight(ConvertWeight(weight, units, models.UnitKilogram), 0.001)
+	return exerciseSession{Date: day, Weight: kg, Reps: reps, Sets: sets, Unit: units}
+}
+
+func TestPrescribeInEntryUnits(t *testing.T) {
+	rule := models.ProgressionRule{Kind: models.RuleLinear, Increment: 2.5, DeloadAfter: 2, DeloadPercent: 10, StartWeight: 100}
+	target := models.TemplateExercise{Name: "Squat", Reps: 5, Sets: 3}
+
+	tests := []struct {
+		name    string
+		units   prescriptionUnits
+		history []exerciseSession
+		weight  float64
+		note    string
+	}{
+		{
+			// 100 kg is loaded as 220 lb, stored as 99.79 kg
+			name:  "lb sets reach kg prescriptions",
+			units: prescriptionUnits{athlete: lbPlates, display: lbPlates},
+			history: []exerciseSession{
+				loggedSession("05/10/2026", 220, models.UnitPound, 3, 5),
+				loggedSession("08/10/2026", 225, models.UnitPound, 3, 5),
+			},
+			weight: 105,
+			note:   "Squat: completed 225lb 3x5 on 08/10/2026, adding 5lb",
+		},
+		{
+			name:  "a plate short is a miss",
+			units: prescriptionUnits{athlete: kgPlates, display: kgPlates},
+			history: []exerciseSession{
+				loggedSession("05/10/2026", 97.5, models.UnitKilogram, 3, 5),
+				loggedSession("08/10/2026", 97.5, models.UnitKilogram, 3, 5),
+			},
+			weight: 90,
+			note:   "Squat: missed 2 sessions in a row, deloading 10%",
+		},
+		{
+			name:  "notes in the display unit",
+			units: prescriptionUnits{athlete: kgPlates, display: lbPlates},
+			history: []exerciseSession{
+				loggedSession("05/10/2026", 100, models.UnitKilogram, 3, 5),
+			},
+			weight: 102.5,
+			note:   "Squat: completed 220lb 3x5 on 05/10/2026, adding 5lb",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lift, note := prescribeLinear(rule, target, tt.history, tt.units)
+			if lift.Weight != tt.weight {
+				t.Errorf("weight = %g, want %g", lift.Weight, tt.weight)
+			}
+			if note != tt.note {
+				t.Errorf("note = %q, want %q", note, tt.note)
+			}
+		})
+	}
+}
+
+// prescriptionTest is the prescription expected after a history.
+type prescriptionTest struct {
+	name    string
+	history []exerciseSession
+	weight  float64
+	reps    int
+	note    string
+}
+
+func checkPrescriptions(t *testing.T, prescribe func([]exerciseSession) (models.Lift, string), tests []prescriptionTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lift, note := prescribe(tt.history)
+			if lift.Weight != tt.weight || lift.Reps != tt.reps || lift.Sets != 3 {
+				t.Errorf("prescribed %g x %d x %d, want %g x %d x 3", lift.Weight, lift.Sets, lift.Reps, tt.weight, tt.reps)
+			}
+			if note != tt.note {
+				t.Errorf("note = %q, want %q", note, tt.note)
+			}
+		})
+	}
+}
+
+func TestPrescribeLinear(t *testing.T) {
+	rule := models.ProgressionRule{Kind: models.RuleLinear, Increment: 2.5, DeloadAfter: 2, DeloadPercent: 10, StartWeight: 100}
+	target := models.TemplateExercise{Name: "Squat", Reps: 5, Sets: 3}
+	checkPrescriptions(t, func(history []exerciseSession) (models.Lift, string) {
+		return prescribeLinear(rule, target, history, kgUnits)
+	}, []prescriptionTest{
+		{"no history", nil, 100, 5, "Squat: starting weight"},
+		{"success", []exerciseSession{
+			loggedSession("05/10/2026", 100, models.UnitKilogram, 3, 5),
+		}, 102.5, 5, "Squat: completed 100kg 3x5 on 05/10/2026, adding 2.5kg"},
+		{"one miss", []exerciseSession{
+			loggedSession("05/10/2026", 100, models.UnitKilogram, 3, 5),
+			loggedSession("08/10/2026", 102.5, models.UnitKilogram, 3, 4),
+		}, 102.5, 5, "Squat: missed target on 08/10/2026, repeating weight (1 failure(s))"},
+		{"a set short", []exerciseSession{
+			loggedSession("05/10/2026", 100, models.UnitKilogram, 2, 5),
+		}, 100, 5, "Squat: missed target on 05/10/2026, repeating weight (1 failure(s))"},
+		{"deload after two misses", []exerciseSession{
+			loggedSession("05/10/2026", 100, models.UnitKilogram, 3, 5),
+			loggedSession("08/10/2026", 102.5, models.UnitKilogram, 3, 4),
+			loggedSession("10/10/2026", 102.5, models.UnitKilogram, 3, 3),
+		}, 92.5, 5, "Squat: missed 2 sessions in a row, deloading 10%"},
+		{"a success resets misses", []exerciseSession{
+			loggedSession("05/10/2026", 100, models.UnitKilogram, 3, 4),
+			loggedSession("08/10/2026", 100, models.UnitKilogram, 3, 5),
+			loggedSession("10/10/2026", 102.5, models.UnitKilogram, 3, 4),
+		}, 102.5, 5, "Squat: missed target on 10/10/2026, repeating weight (1 failure(s))"},
+	})
+}
+
+func TestPrescribeDouble(t *testing.T) {
+	rule := models.ProgressionRule{Kind: models.RuleDouble, Increment: 2.5, DeloadAfter: 2, DeloadPercent: 10, RepMin: 8, RepMax: 12, StartWeight: 60}
+	target := models.TemplateExercise{Name: "Row", Reps: 8, Sets: 3}
+	checkPrescriptions(t, func(history []exerciseSession) (models.Lift, string) {
+		return prescribeDouble(rule, target, history, kgUnits)
+	}, []prescriptionTest{
+		{"no history", nil, 60, 8, "Row: starting at the bottom of the 8-12 rep range"},
+		{"within the range", []exerciseSession{
+			loggedSession("05/10/2026", 60, models.UnitKilogram, 3, 9),
+		}, 60, 10, "Row: did 9 reps at 60kg, aim for 10"},
+		{"top of the range", []exerciseSession{
+			loggedSession("05/10/2026", 60, models.UnitKilogram, 3, 11),
+			loggedSession("08/10/2026", 60, models.UnitKilogram, 3, 12),
+		}, 62.5, 8, "Row: reached 12 reps at 60kg, adding 2.5kg and dropping to 8 reps"},
+		{"top of the range a set short", []exerciseSession{
+			loggedSession("05/10/2026", 60, models.UnitKilogram, 2, 12),
+		}, 60, 12, "Row: did 12 reps at 60kg, aim for 12"},
+		{"below the range", []exerciseSession{
+			loggedSession("05/10/2026", 60, models.UnitKilogram, 3, 7),
+		}, 60, 8, "Row: below the rep range on 05/10/2026, repeating"},
+		{"deload after two misses", []exerciseSession{
+			loggedSession("05/10/2026", 60, models.UnitKilogram, 3, 10),
+			loggedSession("08/10/2026", 60, models.UnitKilogram, 3, 7),
+			loggedSession("10/10/2026", 60, models.UnitKilogram, 3, 6),
+		}, 55, 8, "Row: below 8 reps 2 times, deloading 10%"},
+	})
+}
+
+func TestPrescribePercentage(t *testing.T) {
+	rule := models.ProgressionRule{Kind: models.RulePercentage, Increment: 5, TrainingMax: 140}
+	target := models.TemplateExercise{Name: "Bench", Reps: 5, Sets: 3}
+	sessions := func(n int) []exerciseSession {
+		history := make([]exerciseSession, n)
+		for i := range history {
+			history[i] = loggedSession("05/10/2026", 100, models.UnitKilogram, 3, 5)
+		}
+		return history
+	}
+	// Weights are rounded to the nearest 2.5 kg: 85% of 140 is 119, 90% is
+	// 126 and 95% is 133.
+	checkPrescriptions(t, func(history []exerciseSession) (models.Lift, string) {
+		return prescribePercentage(rule, target, history, kgUnits)
+	}, []prescriptionTest{
+		{"week 1", sessions(0), 120, 5, "Bench: cycle 1 week 1, 85% of 140kg training max"},
+		{"week 2", sessions(1), 125, 3, "Bench: cycle 1 week 2, 90% of 140kg training max"},
+		{"week 3", sessions(2), 132.5, 1, "Bench: cycle 1 week 3, 95% of 140kg training max"},
+		{"deload week", sessions(3), 85, 5, "Bench: cycle 1 week 4, 60% of 140kg training max"},
+		{"next cycle", sessions(4), 122.5, 5, "Bench: cycle 2 week 1, 85% of 145kg training max"},
+	})
+}
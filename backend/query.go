@@ -4,75 +4,203 @@ import (
 	"database/sql"
 	"fmt"
 	"fitness-dev/models"
+	"strings"
+	"time"
 )
 
-func GetWorkoutByDay(db *sql.DB, day string) (models.Workout, error) {
-	query := `SELECT id, day, time_in, time_out, mood_in, mood_out FROM workouts WHERE day = ?`
-	row := db.QueryRow(query, day)
+// querier is satisfied by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// sortableDay rewrites the DD/MM/YYYY day column as YYYYMMDD so it orders by date.
+const sortableDay = `(substr(day, 7, 4) || substr(day, 4, 2) || substr(day, 1, 2))`
 
+const workoutColumns = `id, day, time_in, time_out, mood_in, mood_out, athlete, session_rpe, status, version, updated_at, deleted_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWorkout(row rowScanner) (models.Workout, error) {
 	var workout models.Workout
-	err := row.Scan(&workout.ID, &workout.Date, &workout.TimeIn, &workout.TimeOut, &workout.MoodIn, &workout.MoodOut)
-	if err != nil {
-		return models.Workout{}, fmt.Errorf("failed to fetch workout: %v", err)
-	}
+	var deletedAt sql.NullString
+	err := row.Scan(&workout.ID, &workout.Date, &workout.TimeIn, &workout.TimeOut, &workout.MoodIn, &workout.MoodOut, &workout.Athlete, &workout.SessionRPE, &workout.Status, &workout.Version, &workout.UpdatedAt, &deletedAt)
+	workout.DeletedAt = deletedAt.String
+	return workout, err
+}
 
-	// Fetch lifts for this workout
-	query = `SELECT name, weight, reps, sets FROM lifts WHERE workout_id = ?`
-	rows, err := db.Query(query, workout.ID)
+func loadLifts(q querier, workout *models.Workout) error {
+	query := `SELECT name, weight, reps, sets, rpe, rir, tempo, rest, unit, target_weight, target_reps, target_sets, warm_up FROM lifts WHERE workout_id = ? ORDER BY id`
+	rows, err := q.Query(query, workout.ID)
 	if err != nil {
-		return models.Workout{}, fmt.Errorf("failed to fetch lifts: %v", err)
+		return fmt.Errorf("failed to fetch lifts: %v", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var lift models.Lift
-		if err := rows.Scan(&lift.Name, &lift.Weight, &lift.Reps, &lift.Sets); err != nil {
-			return models.Workout{}, fmt.Errorf("failed to scan row: %v", err)
+		var rir sql.NullInt64
+		var unit string
+		if err := rows.Scan(&lift.Name, &lift.Weight, &lift.Reps, &lift.Sets, &lift.RPE, &rir, &lift.Tempo, &lift.Rest, &unit, &lift.TargetWeight, &lift.TargetReps, &lift.TargetSets, &lift.WarmUp); err != nil {
+			return fmt.Errorf("failed to scan lift row: %v", err)
 		}
 		workout.Lifts = append(workout.Lifts, lift.Name)
 		workout.Weight = append(workout.Weight, lift.Weight)
 		workout.Reps = append(workout.Reps, lift.Reps)
 		workout.Sets = append(workout.Sets, lift.Sets)
+		workout.RPE = append(workout.RPE, lift.RPE)
+		workout.RIR = append(workout.RIR, nullableInt(rir))
+		workout.Tempo = append(workout.Tempo, lift.Tempo)
+		workout.Rest = append(workout.Rest, lift.Rest)
+		workout.EntryUnits = append(workout.EntryUnits, unit)
+		workout.TargetWeight = append(workout.TargetWeight, lift.TargetWeight)
+		workout.TargetReps = append(workout.TargetReps, lift.TargetReps)
+		workout.TargetSets = append(workout.TargetSets, lift.TargetSets)
+		workout.WarmUp = append(workout.WarmUp, lift.WarmUp)
+	}
+
+	compactExtras(workout)
+	return rows.Err()
+}
+
+func nullableInt(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	n := int(v.Int64)
+	return &n
+}
+
+func GetWorkoutByDay(db *sql.DB, day string) (models.Workout, error) {
+	query := `SELECT ` + workoutColumns + ` FROM workouts WHERE day = ? AND deleted_at IS NULL`
+	workout, err := scanWorkout(db.QueryRow(query, day))
+	if err == sql.ErrNoRows {
+		return models.Workout{}, &NotFoundError{Resource: "workout", Key: day}
+	}
+	if err != nil {
+		return models.Workout{}, fmt.Errorf("failed to fetch workout: %v", err)
+	}
+
+	// Fetch lifts for this workout
+	if err := loadDetails(db, &workout); err != nil {
+		return models.Workout{}, err
 	}
 
 	return workout, nil
 }
 
+func GetWorkoutByID(db *sql.DB, id int) (models.Workout, error) {
+	return getWorkoutByID(db, id)
+}
+
+func getWorkoutByID(q querier, id int) (models.Workout, error) {
+	return getWorkout(q, id, false)
+}
+
+// getWorkout loads a workout and its lifts. Workouts in the trash are only
+// returned when includeDeleted is set.
+func getWorkout(q querier, id int, includeDeleted bool) (models.Workout, error) {
+	query := `SELECT ` + workoutColumns + ` FROM workouts WHERE id = ?`
+	if !includeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+	workout, err := scanWorkout(q.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return models.Workout{}, &NotFoundError{Resource: "workout", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.Workout{}, fmt.Errorf("failed to fetch workout: %v", err)
+	}
+
+	if err := loadDetails(q, &workout); err != nil {
+		return models.Workout{}, err
+	}
+
+	return workout, nil
+}
+
+// GetWorkoutsByDateRange returns the workouts from startDate to endDate
+// (DD/MM/YYYY or DD-MM-YYYY, inclusive), oldest first.
 func GetWorkoutsByDateRange(db *sql.DB, startDate, endDate string) ([]models.Workout, error) {
-	query := `SELECT id, day, time_in, time_out, mood_in, mood_out FROM workouts WHERE day BETWEEN ? AND ?`
-	rows, err := db.Query(query, startDate, endDate)
+	startDate = strings.ReplaceAll(strings.TrimSpace(startDate), "-", "/")
+	endDate = strings.ReplaceAll(strings.TrimSpace(endDate), "-", "/")
+	verr := &ValidationError{}
+	start, err := time.Parse(models.DateLayout, startDate)
+	if err != nil {
+		verr.Add("startDate", "must be a valid date in DD/MM/YYYY format")
+	}
+	end, endErr := time.Parse(models.DateLayout, endDate)
+	if endErr != nil {
+		verr.Add("endDate", "must be a valid date in DD/MM/YYYY format")
+	}
+	if err == nil && endErr == nil && end.Before(start) {
+		verr.Add("endDate", "must not be before startDate")
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + workoutColumns + ` FROM workouts
+		WHERE ` + sortableDay + ` BETWEEN ? AND ? AND deleted_at IS NULL
+		ORDER BY ` + sortableDay + `, time_in, id`
+	rows, err := db.Query(query, sortableDate(startDate), sortableDate(endDate))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch workouts: %v", err)
 	}
 	defer rows.Close()
 
-	var workouts []models.Workout
+	workouts := []models.Workout{}
 	for rows.Next() {
-		var workout models.Workout
-		if err := rows.Scan(&workout.ID, &workout.Date, &workout.TimeIn, &workout.TimeOut, &workout.MoodIn, &workout.MoodOut); err != nil {
+		workout, err := scanWorkout(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %v", err)
 		}
+		workouts = append(workouts, workout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts: %v", err)
+	}
+	rows.Close()
 
-		liftsQuery := `SELECT name, weight, reps, sets FROM lifts WHERE workout_id = ?`
-		liftsRows, err := db.Query(liftsQuery, workout.ID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch lifts: %v", err)
-		}
-		defer liftsRows.Close()
-
-		for liftsRows.Next() {
-			var lift models.Lift
-			if err := liftsRows.Scan(&lift.Name, &lift.Weight, &lift.Reps, &lift.Sets); err != nil {
-				return nil, fmt.Errorf("failed to scan lift row: %v", err)
-			}
-			workout.Lifts = append(workout.Lifts, lift.Name)
-			workout.Weight = append(workout.Weight, lift.Weight)
-			workout.Reps = append(workout.Reps, lift.Reps)
-			workout.Sets = append(workout.Sets, lift.Sets)
+	for i := range workouts {
+		if err := loadDetails(db, &workouts[i]); err != nil {
+			return nil, err
 		}
+	}
 
+	return workouts, nil
+}
+
+// GetAthleteWorkouts returns every live workout of an athlete, oldest first.
+func GetAthleteWorkouts(db *sql.DB, athlete string) ([]models.Workout, error) {
+	query := `SELECT ` + workoutColumns + ` FROM workouts WHERE athlete = ? AND deleted_at IS NULL
+		ORDER BY ` + sortableDay + `, time_in`
+	rows, err := db.Query(query, athlete)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts: %v", err)
+	}
+	defer rows.Close()
+
+	workouts := []models.Workout{}
+	for rows.Next() {
+		workout, err := scanWorkout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
 		workouts = append(workouts, workout)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch workouts: %v", err)
+	}
+	rows.Close()
 
+	for i := range workouts {
+		if err := loadDetails(db, &workouts[i]); err != nil {
+			return nil, err
+		}
+	}
 	return workouts, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,119 @@
+package backend
+
+import (
+	"database/sql"
+	"slices"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// GetMonthlyReport gathers an athlete's sessions, weekly tonnage, personal
+// records and goal progress for a month given as MM/YYYY, this month if
+// empty. Weights are in kg.
+func GetMonthlyReport(db *sql.DB, athlete, month string) (models.MonthlyReport, error) {
+	athlete = strings.TrimSpace(athlete)
+	month = strings.ReplaceAll(strings.TrimSpace(month), "-", "/")
+	verr := &ValidationError{}
+	if athlete == "" {
+		verr.Add("athlete", "is required")
+	}
+	today := truncateDay(time.Now())
+	first := today.AddDate(0, 0, 1-today.Day())
+	if month != "" {
+		var err error
+		if first, err = time.Parse(models.MonthLayout, month); err != nil {
+			verr.Add("month", "must be a valid month in MM/YYYY format")
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		return models.MonthlyReport{}, err
+	}
+	last := first.AddDate(0, 1, -1)
+
+	report := models.MonthlyReport{
+		Athlete:   athlete,
+		Month:     first.Format(models.MonthLayout),
+		StartDate: first.Format(models.DateLayout),
+		EndDate:   last.Format(models.DateLayout),
+		Sessions:  []models.ReportSession{},
+		Records:   []models.ReportRecord{},
+		Goals:     []models.GoalProgress{},
+	}
+	monday := weekStart(first)
+	for day := monday; !day.After(last); day = day.AddDate(0, 0, 7) {
+		report.Weeks = append(report.Weeks, models.SeriesPoint{Date: day.Format(models.DateLayout)})
+	}
+
+	lc, err := newLoadingContext(db)
+	if err != nil {
+		return models.MonthlyReport{}, err
+	}
+	workouts, err := workoutsBetween(db, athlete, report.StartDate, report.EndDate)
+	if err != nil {
+		return models.MonthlyReport{}, err
+	}
+	records, err := RecordLifts(db, workouts)
+	if err != nil {
+		return models.MonthlyReport{}, err
+	}
+
+	for _, w := range workouts {
+		s := models.ReportSession{WorkoutID: w.ID, Date: w.Date, TimeIn: w.TimeIn, TimeOut: w.TimeOut,
+			MoodIn: w.MoodIn, MoodOut: w.MoodOut, Exercises: []string{}}
+		in, inErr := parseClock(w.TimeIn)
+		out, outErr := parseClock(w.TimeOut)
+		if inErr == nil && outErr == nil {
+			s.Minutes = SessionDuration(in, out).Minutes()
+		}
+		for i := range w.Lifts {
+			lift := lc.effectiveLift(athlete, w.Date, GetLift(w, i))
+			if lift.WarmUp {
+				continue
+			}
+			if name := strings.TrimSpace(lift.Name); !slices.ContainsFunc(s.Exercises, func(e string) bool { return strings.EqualFold(e, name) }) {
+				s.Exercises = append(s.Exercises, name)
+			}
+			s.Sets += lift.Sets
+			s.Tonnage += liftTonnage(lift)
+			if records[w.ID][i] {
+				s.Records++
+				report.Records = append(report.Records, models.ReportRecord{Date: w.Date, Exercise: lift.Name,
+					Weight: round2(lift.Weight), Reps: lift.Reps, E1RM: round2(LiftOneRepMax(lift))})
+			}
+		}
+		s.Tonnage = round2(s.Tonnage)
+		report.Sessions = append(report.Sessions, s)
+		report.Minutes += s.Minutes
+		report.Tonnage += s.Tonnage
+
+		day, _ := time.Parse(models.DateLayout, w.Date)
+		report.Weeks[weekIndex(monday, day)].Value += s.Tonnage
+	}
+	report.Tonnage = round2(report.Tonnage)
+	for i := range report.Weeks {
+		report.Weeks[i].Value = round2(report.Weeks[i].Value)
+	}
+
+	// Goals are measured as they stood at the end of the month
+	asOf := today
+	if last.Before(asOf) {
+		asOf = last
+	}
+	goals, err := GetGoals(db, athlete)
+	if err != nil {
+		return models.MonthlyReport{}, err
+	}
+	for _, goal := range goals {
+		if start, err := time.Parse(models.DateLayout, goal.StartDate); err != nil || start.After(last) {
+			continue
+		}
+		progress, err := goalProgress(db, goal, asOf)
+		if err != nil {
+			return models.MonthlyReport{}, err
+		}
+		report.Goals = append(report.Goals, progress)
+	}
+	return report, nil
+}
@@ -0,0 +1,109 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// E1RMHistory returns an athlete's best effective e1RM of an exercise in
+// each workout since a day, oldest first, in kg.
+func E1RMHistory(db *sql.DB, athlete, exercise string, since time.Time) ([]models.SeriesPoint, error) {
+	points, err := e1rmSeries(db, athlete, exercise, since)
+	if err != nil {
+		return nil, err
+	}
+	series := make([]models.SeriesPoint, len(points))
+	for i, p := range points {
+		series[i] = models.SeriesPoint{Date: p.day.Format(models.DateLayout), Value: p.value}
+	}
+	return series, nil
+}
+
+// ExerciseTrends returns the e1RM history of every exercise an athlete has
+// logged since a day, ordered by exercise name.
+func ExerciseTrends(db *sql.DB, athlete string, since time.Time) ([]models.ExerciseTrend, error) {
+	query := `SELECT min(l.name) FROM lifts l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE w.athlete = ? AND w.deleted_at IS NULL AND w.status = 'completed' AND l.warm_up = 0 AND ` + sortableDay + ` >= ?
+		GROUP BY lower(trim(l.name))
+		ORDER BY lower(trim(l.name))`
+	rows, err := db.Query(query, athlete, since.Format("20060102"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch exercises: %v", err)
+	}
+	var exercises []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan exercise: %v", err)
+		}
+		exercises = append(exercises, strings.TrimSpace(name))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	trends := []models.ExerciseTrend{}
+	for _, exercise := range exercises {
+		points, err := E1RMHistory(db, athlete, exercise, since)
+		if err != nil {
+			return nil, err
+		}
+		if len(points) == 0 {
+			continue
+		}
+		t := models.ExerciseTrend{Exercise: exercise, Sessions: len(points), Latest: points[len(points)-1].Value, Points: points}
+		for _, p := range points {
+			t.Best = max(t.Best, p.Value)
+		}
+		trends = append(trends, t)
+	}
+	return trends, nil
+}
+
+// WeeklyTonnage sums an athlete's effective tonnage in each of the last
+// weeks (Monday to Sunday), oldest first and ending with this week, in kg.
+// Points are dated on the Monday of their week.
+func WeeklyTonnage(db *sql.DB, athlete string, weeks int) ([]models.SeriesPoint, error) {
+	if weeks < 1 || weeks > MaxAttendanceWeeks {
+		verr := &ValidationError{}
+		verr.Add("weeks", "must be between 1 and %d", MaxAttendanceWeeks)
+		return nil, verr
+	}
+	first := weekStart(truncateDay(time.Now())).AddDate(0, 0, -7*(weeks-1))
+	lc, err := newLoadingContext(db)
+	if err != nil {
+		return nil, err
+	}
+	workouts, err := workoutsBetween(db, athlete, first.Format(models.DateLayout), first.AddDate(0, 0, 7*weeks-1).Format(models.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]models.SeriesPoint, weeks)
+	for i := range series {
+		series[i].Date = first.AddDate(0, 0, 7*i).Format(models.DateLayout)
+	}
+	for _, w := range workouts {
+		day, err := time.Parse(models.DateLayout, w.Date)
+		if err != nil {
+			continue
+		}
+		i := weekIndex(first, day)
+		for j := range w.Lifts {
+			if lift := GetLift(w, j); !lift.WarmUp {
+				series[i].Value += liftTonnage(lc.effectiveLift(athlete, w.Date, lift))
+			}
+		}
+	}
+	for i := range series {
+		series[i].Value = round2(series[i].Value)
+	}
+	return series, nil
+}
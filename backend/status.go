@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// A workout is planned, then in progress once started, and finally
+// completed or skipped. Workouts logged after the fact start out completed.
+
+// nextStatuses lists the statuses a workout may move to from each status.
+// Completed and skipped workouts keep their status.
+var nextStatuses = map[string][]string{
+	models.StatusPlanned:    {models.StatusInProgress, models.StatusCompleted, models.StatusSkipped},
+	models.StatusInProgress: {models.StatusCompleted, models.StatusSkipped},
+}
+
+// checkTransition returns a *ConflictError unless workout may move to
+// status. An empty status leaves it unchanged.
+func checkTransition(workout models.Workout, status string) error {
+	if status == "" || status == workout.Status || slices.Contains(nextStatuses[workout.Status], status) {
+		return nil
+	}
+	return &ConflictError{Message: fmt.Sprintf("workout %d is %s and cannot become %s", workout.ID, workout.Status, status)}
+}
+
+// StartWorkout starts a planned workout now: it moves to today, with the
+// current time as its time in.
+func StartWorkout(db *sql.DB, workoutID, version int, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, "", actor, func(workout *models.Workout) error {
+		if workout.Status != models.StatusPlanned {
+			return statusConflict(*workout, "started")
+		}
+		now := time.Now()
+		workout.Date, workout.TimeIn, workout.TimeOut = now.Format(models.DateLayout), now.Format(models.TimeLayout), ""
+		workout.Status = models.StatusInProgress
+		return nil
+	})
+}
+
+// SkipWorkout marks a planned or started workout as skipped.
+func SkipWorkout(db *sql.DB, workoutID, version int, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, "", actor, func(workout *models.Workout) error {
+		if workout.Status != models.StatusPlanned && workout.Status != models.StatusInProgress {
+			return statusConflict(*workout, "skipped")
+		}
+		workout.Status = models.StatusSkipped
+		return nil
+	})
+}
+
+// CompleteWorkout replaces a planned or started workout with what was done,
+// weighed in units, and marks it completed. done keeps the targets it was
+// given, so they should be carried over from the stored workout.
+func CompleteWorkout(db *sql.DB, workoutID, version int, done models.Workout, units string, actor Actor) (models.Workout, error) {
+	return modifyWorkout(db, workoutID, version, units, actor, func(workout *models.Workout) error {
+		if workout.Status != models.StatusPlanned && workout.Status != models.StatusInProgress {
+			return statusConflict(*workout, "completed")
+		}
+		*workout = done
+		workout.Status = models.StatusCompleted
+		return nil
+	})
+}
+
+func statusConflict(workout models.Workout, action string) error {
+	return &ConflictError{Message: fmt.Sprintf("workout %d is %s and cannot be %s", workout.ID, workout.Status, action)}
+}
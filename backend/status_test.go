@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+
+	"fitness-dev/models"
+)
+
+func TestCheckTransition(t *testing.T) {
+	allowed := map[string][]string{
+		models.StatusPlanned:    {models.StatusPlanned, models.StatusInProgress, models.StatusCompleted, models.StatusSkipped},
+		models.StatusInProgress: {models.StatusInProgress, models.StatusCompleted, models.StatusSkipped},
+		models.StatusCompleted:  {models.StatusCompleted},
+		models.StatusSkipped:    {models.StatusSkipped},
+	}
+	for _, from := range models.Statuses {
+		for _, to := range append([]string{""}, models.Statuses...) {
+			err := checkTransition(models.Workout{ID: 1, Status: from}, to)
+			want := to == ""
+			for _, s := range allowed[from] {
+				want = want || s == to
+			}
+			var conflict *ConflictError
+			switch {
+			case want && err != nil:
+				t.Errorf("%s -> %q: got %v, want it allowed", from, to, err)
+			case !want && !errors.As(err, &conflict):
+				t.Errorf("%s -> %q: got %v, want a conflict", from, to, err)
+			}
+		}
+	}
+}
+
+func TestPlannedSessionWorkout(t *testing.T) {
+	db := newTestDB(t)
+	id, err := CreatePlannedSession(db, models.PlannedSession{Athlete: "sam", Date: "21/10/2026", TimeIn: "18:00", TimeOut: "19:00", Title: "Squats"}, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := GetPlannedSession(db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planned, err := GetWorkoutByID(db, p.WorkoutID)
+	if err != nil {
+		t.Fatalf("planned session %+v has no workout: %v", p, err)
+	}
+	if planned.Status != models.StatusPlanned || planned.Date != p.Date || planned.TimeIn != p.TimeIn || planned.Athlete != "sam" {
+		t.Errorf("planned workout = %+v, want it planned on the session's day and time", planned)
+	}
+
+	started, err := StartPlannedSession(db, id, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.ID != p.WorkoutID || started.Status != models.StatusInProgress {
+		t.Errorf("started workout %d is %s, want workout %d in progress", started.ID, started.Status, p.WorkoutID)
+	}
+	var conflict *ConflictError
+	if _, err := StartPlannedSession(db, id, testActor); !errors.As(err, &conflict) {
+		t.Errorf("starting a started session: got %v, want a conflict", err)
+	}
+	if _, err := SkipPlannedSession(db, id, testActor); !errors.As(err, &conflict) {
+		t.Errorf("skipping a started session: got %v, want a conflict", err)
+	}
+	if p, err = GetPlannedSession(db, id); err != nil || p.Status != models.StatusInProgress {
+		t.Errorf("planned session = %+v, %v, want it in progress", p, err)
+	}
+}
+
+func TestCompletePlannedSessionReplacesWorkout(t *testing.T) {
+	db := newTestDB(t)
+	id, err := CreatePlannedSession(db, models.PlannedSession{Athlete: "sam", Date: "12/10/2026", Title: "Squats"}, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := GetPlannedSession(db, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workoutID := logSquat(t, db, "sam", "12/10/2026", 100, 5)
+
+	p, err := CompletePlannedSession(db, id, workoutID, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.WorkoutID != workoutID || p.Status != models.StatusCompleted {
+		t.Errorf("planned session = %+v, want it completed by workout %d", p, workoutID)
+	}
+	var notFound *NotFoundError
+	if _, err := GetWorkoutByID(db, before.WorkoutID); !errors.As(err, &notFound) {
+		t.Errorf("planned workout %d: got %v, want it deleted", before.WorkoutID, err)
+	}
+
+	other := logSquat(t, db, "sam", "12/10/2026", 100, 5)
+	var conflict *ConflictError
+	if _, err := CompletePlannedSession(db, id, other, testActor); !errors.As(err, &conflict) {
+		t.Errorf("completing a completed session again: got %v, want a conflict", err)
+	}
+}
@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"math"
+	"strings"
+
+	"fitness-dev/models"
+)
+
+// Sexes accepted for strength scores, which use separate coefficients.
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
+// wilksCoefficients are a-f of the original Wilks formula, per sex.
+var wilksCoefficients = map[string][6]float64{
+	SexMale:   {-216.0475144, 16.2606339, -0.002388645, -0.00113732, 7.01863e-06, -1.291e-08},
+	SexFemale: {594.31747775582, -27.23842536447, 0.82112226871, -0.00930733913, 4.731582e-05, -9.054e-08},
+}
+
+// dotsCoefficients are a-e of the DOTS formula, per sex.
+var dotsCoefficients = map[string][5]float64{
+	SexMale:   {-307.75076, 24.0900756, -0.1918759221, 0.0007391293, -0.000001093},
+	SexFemale: {-57.96288, 13.6175032, -0.1126655495, 0.0005158568, -0.0000010706},
+}
+
+// Bodyweight ranges the formulas are defined for; lighter or heavier lifters
+// are scored at the nearest bound.
+var (
+	wilksRange = map[string][2]float64{SexMale: {40, 201.9}, SexFemale: {26.51, 154.53}}
+	dotsRange  = map[string][2]float64{SexMale: {40, 210}, SexFemale: {40, 150}}
+)
+
+// ValidateSex checks an optional sex parameter, returning it in lower case.
+func ValidateSex(sex string) (string, error) {
+	sex = strings.ToLower(strings.TrimSpace(sex))
+	if sex != "" && sex != SexMale && sex != SexFemale {
+		verr := &ValidationError{}
+		verr.Add("sex", "must be %s or %s", SexMale, SexFemale)
+		return "", verr
+	}
+	return sex, nil
+}
+
+func polynomial(coefficients []float64, x float64) float64 {
+	var sum float64
+	for i, c := range coefficients {
+		sum += c * math.Pow(x, float64(i))
+	}
+	return sum
+}
+
+// Wilks scores a lift (or total) in kg at a bodyweight in kg.
+func Wilks(lifted, bodyweight float64, sex string) float64 {
+	c, ok := wilksCoefficients[sex]
+	if !ok || bodyweight <= 0 {
+		return 0
+	}
+	bw := math.Min(math.Max(bodyweight, wilksRange[sex][0]), wilksRange[sex][1])
+	return round2(lifted * 500 / polynomial(c[:], bw))
+}
+
+// DOTS scores a lift (or total) in kg at a bodyweight in kg.
+func DOTS(lifted, bodyweight float64, sex string) float64 {
+	c, ok := dotsCoefficients[sex]
+	if !ok || bodyweight <= 0 {
+		return 0
+	}
+	bw := math.Min(math.Max(bodyweight, dotsRange[sex][0]), dotsRange[sex][1])
+	return round2(lifted * 500 / polynomial(c[:], bw))
+}
+
+// scoreRecords adds relative strength and, when sex is known, Wilks and DOTS
+// scores to records, using the athlete's bodyweight on the day of each e1RM.
+func scoreRecords(lc *loadingContext, athlete, sex string, records []models.PersonalRecord) {
+	for i := range records {
+		r := &records[i]
+		bw, ok := lc.bodyweightOn(athlete, r.E1RMDate)
+		if !ok || r.E1RM == 0 {
+			continue
+		}
+		r.Bodyweight = round2(bw)
+		r.Relative = round2(r.E1RM / bw)
+		r.Wilks = Wilks(r.E1RM, bw, sex)
+		r.DOTS = DOTS(r.E1RM, bw, sex)
+	}
+}
@@ -0,0 +1,50 @@
+package backend
+
+import "testing"
+
+func TestStrengthScores(t *testing.T) {
+	// Totals at bodyweights whose Wilks coefficients appear in the published
+	// tables (0.8529 for a 60 kg man, 0.6086 at 100 kg, 1.0740 for a 63 kg
+	// woman), scored with the original Wilks and the 2019 DOTS formulas.
+	tests := []struct {
+		sex               string
+		bodyweight, total float64
+		wilks, dots       float64
+	}{
+		{SexMale, 60, 500, 426.44, 422.02},
+		{SexMale, 75, 600, 427.54, 430.45},
+		{SexMale, 100, 700, 426.01, 430.86},
+		{SexMale, 120, 800, 459.94, 459.44},
+		{SexFemale, 52, 300, 373.99, 365.67},
+		{SexFemale, 63, 400, 429.58, 430.21},
+		{SexFemale, 84, 450, 401.28, 414.07},
+	}
+	for _, tt := range tests {
+		if got := Wilks(tt.total, tt.bodyweight, tt.sex); got != tt.wilks {
+			t.Errorf("Wilks(%g, %g, %s) = %g, want %g", tt.total, tt.bodyweight, tt.sex, got, tt.wilks)
+		}
+		if got := DOTS(tt.total, tt.bodyweight, tt.sex); got != tt.dots {
+			t.Errorf("DOTS(%g, %g, %s) = %g, want %g", tt.total, tt.bodyweight, tt.sex, got, tt.dots)
+		}
+	}
+}
+
+func TestStrengthScoreBounds(t *testing.T) {
+	if got, want := Wilks(900, 250, SexMale), Wilks(900, 201.9, SexMale); got != want {
+		t.Errorf("Wilks at 250 kg = %g, want %g as at 201.9 kg", got, want)
+	}
+	if got, want := DOTS(300, 35, SexFemale), DOTS(300, 40, SexFemale); got != want {
+		t.Errorf("DOTS at 35 kg = %g, want %g as at 40 kg", got, want)
+	}
+	for _, sex := range []string{"", "other"} {
+		if got := Wilks(500, 80, sex); got != 0 {
+			t.Errorf("Wilks with sex %q = %g, want 0", sex, got)
+		}
+		if got := DOTS(500, 80, sex); got != 0 {
+			t.Errorf("DOTS with sex %q = %g, want 0", sex, got)
+		}
+	}
+	if got := DOTS(500, 0, SexMale); got != 0 {
+		t.Errorf("DOTS without a bodyweight = %g, want 0", got)
+	}
+}
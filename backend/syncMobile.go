@@ -14,5 +14,6 @@ func SyncMobile(db *sql.DB, jsonData []byte) error {
         return fmt.Errorf("failed to extract data from json: %v", err)
     }
 
-    return InsertWorkout(db, workout)
+    _, err = InsertWorkout(db, workout, Actor{Name: "mobile", Source: SourceSync})
+    return err
 }
\ No newline at end of file
@@ -0,0 +1,286 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func validateTemplate(template models.Template) error {
+	verr := &ValidationError{}
+	if strings.TrimSpace(template.Name) == "" {
+		verr.Add("name", "is required")
+	}
+	if len(template.Exercises) == 0 {
+		verr.Add("exercises", "at least one exercise is required")
+	}
+	for i, e := range template.Exercises {
+		field := fmt.Sprintf("exercises[%d]", i)
+		if strings.TrimSpace(e.Name) == "" {
+			verr.Add(field+".name", "is required")
+		}
+		if e.Weight < 0 || e.Weight > MaxWeight {
+			verr.Add(field+".weight", "must be between 0 and %g", MaxWeight)
+		}
+		if e.Reps < 1 || e.Reps > MaxReps {
+			verr.Add(field+".reps", "must be between 1 and %d", MaxReps)
+		}
+		if e.Sets < 1 || e.Sets > MaxSets {
+			verr.Add(field+".sets", "must be between 1 and %d", MaxSets)
+		}
+	}
+	return verr.OrNil()
+}
+
+// CreateTemplate stores a new template and returns its ID. Template names are
+// unique, ignoring case.
+func CreateTemplate(db *sql.DB, template models.Template, actor Actor) (int, error) {
+	template.Name = strings.TrimSpace(template.Name)
+	if err := validateTemplate(template); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		var existing int
+		err := tx.QueryRow(`SELECT count(*) FROM templates WHERE name = ?`, template.Name).Scan(&existing)
+		if err != nil {
+			return fmt.Errorf("failed to check for existing template: %v", err)
+		}
+		if existing > 0 {
+			return &ConflictError{Message: fmt.Sprintf("a template named %q already exists", template.Name)}
+		}
+
+		result, err := tx.Exec(`INSERT INTO templates (name) VALUES (?)`, template.Name)
+		if err != nil {
+			return fmt.Errorf("failed to insert template: %v", err)
+		}
+		templateID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to retrieve template ID: %v", err)
+		}
+		id = int(templateID)
+
+		exerciseQuery := `INSERT INTO template_exercises (template_id, position, name, target_weight, target_reps, target_sets) VALUES (?, ?, ?, ?, ?, ?)`
+		for i, e := range template.Exercises {
+			_, err := tx.Exec(exerciseQuery, id, i, strings.TrimSpace(e.Name), e.Weight, e.Reps, e.Sets)
+			if err != nil {
+				return fmt.Errorf("failed to insert template exercise: %v", err)
+			}
+		}
+
+		after, err := getTemplate(tx, id)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, ActionCreate, EntityTemplate, id, nil, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// CreateTemplateFromWorkout saves the lifts of an existing workout as a new
+// template. Warm-up sets are left out.
+func CreateTemplateFromWorkout(db *sql.DB, workoutID int, name string, actor Actor) (int, error) {
+	workout, err := GetWorkoutByID(db, workoutID)
+	if err != nil {
+		return 0, err
+	}
+
+	template := models.Template{Name: name}
+	for i := range workout.Lifts {
+		lift := GetLift(workout, i)
+		if lift.WarmUp {
+			continue
+		}
+		template.Exercises = append(template.Exercises, models.TemplateExercise{
+			Name:   lift.Name,
+			Weight: lift.Weight,
+			Reps:   lift.Reps,
+			Sets:   lift.Sets,
+		})
+	}
+
+	return CreateTemplate(db, template, actor)
+}
+
+// GetTemplates returns all templates ordered by name.
+func GetTemplates(db *sql.DB) ([]models.Template, error) {
+	rows, err := db.Query(`SELECT id, name FROM templates ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch templates: %v", err)
+	}
+	defer rows.Close()
+
+	var templates []models.Template
+	for rows.Next() {
+		var template models.Template
+		if err := rows.Scan(&template.ID, &template.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan template row: %v", err)
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch templates: %v", err)
+	}
+	rows.Close()
+
+	for i := range templates {
+		if err := loadTemplateExercises(db, &templates[i]); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+func GetTemplate(db *sql.DB, id int) (models.Template, error) {
+	return getTemplate(db, id)
+}
+
+func getTemplate(q querier, id int) (models.Template, error) {
+	var template models.Template
+	err := q.QueryRow(`SELECT id, name FROM templates WHERE id = ?`, id).Scan(&template.ID, &template.Name)
+	if err == sql.ErrNoRows {
+		return models.Template{}, &NotFoundError{Resource: "template", Key: fmt.Sprint(id)}
+	}
+	if err != nil {
+		return models.Template{}, fmt.Errorf("failed to fetch template: %v", err)
+	}
+
+	if err := loadTemplateExercises(q, &template); err != nil {
+		return models.Template{}, err
+	}
+	return template, nil
+}
+
+func loadTemplateExercises(q querier, template *models.Template) error {
+	query := `SELECT name, target_weight, target_reps, target_sets FROM template_exercises WHERE template_id = ? ORDER BY position`
+	rows, err := q.Query(query, template.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch template exercises: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.TemplateExercise
+		if err := rows.Scan(&e.Name, &e.Weight, &e.Reps, &e.Sets); err != nil {
+			return fmt.Errorf("failed to scan template exercise: %v", err)
+		}
+		template.Exercises = append(template.Exercises, e)
+	}
+	return rows.Err()
+}
+
+// DeleteTemplate removes a template. Templates still scheduled by a program
+// cannot be deleted.
+func DeleteTemplate(db *sql.DB, id int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getTemplate(tx, id)
+		if err != nil {
+			return err
+		}
+
+		var programs int
+		if err := tx.QueryRow(`SELECT count(*) FROM program_templates WHERE template_id = ?`, id).Scan(&programs); err != nil {
+			return fmt.Errorf("failed to check template usage: %v", err)
+		}
+		if programs > 0 {
+			return &ConflictError{Message: fmt.Sprintf("template %d is used by a program", id)}
+		}
+		if _, err := tx.Exec(`DELETE FROM template_exercises WHERE template_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete template exercises: %v", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM templates WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete template: %v", err)
+		}
+		return recordAudit(tx, actor, ActionDelete, EntityTemplate, id, before, nil)
+	})
+}
+
+// LastPerformance returns the most recent logged lift with the given name
+// (ignoring case). ok is false if the exercise has never been logged.
+func LastPerformance(db *sql.DB, exercise string) (lift models.Lift, ok bool, err error) {
+	query := `SELECT l.name, l.weight, l.reps, l.sets FROM lifts l
+		JOIN workouts w ON w.id = l.workout_id
+		WHERE l.name = ? COLLATE NOCASE AND w.deleted_at IS NULL AND w.status = 'completed' AND l.warm_up = 0
+		ORDER BY ` + sortableDay + ` DESC, w.time_in DESC, l.id DESC LIMIT 1`
+	err = db.QueryRow(query, strings.TrimSpace(exercise)).Scan(&lift.Name, &lift.Weight, &lift.Reps, &lift.Sets)
+	if err == sql.ErrNoRows {
+		return models.Lift{}, false, nil
+	}
+	if err != nil {
+		return models.Lift{}, false, fmt.Errorf("failed to fetch last performance: %v", err)
+	}
+	return lift, true, nil
+}
+
+// newDraft returns an unsaved workout dated today. Drafts are handed to the
+// client to complete (time out, moods, actual numbers) and then created as usual.
+func newDraft() models.Workout {
+	t := time.Now()
+	return models.Workout{
+		Date:   t.Format(models.DateLayout),
+		TimeIn: t.Format(models.TimeLayout),
+	}
+}
+
+// WorkoutFromTemplate builds a draft workout from a template. With prefill set,
+// each exercise's weight is taken from the last time it was performed, falling
+// back to the template's target weight.
+func WorkoutFromTemplate(db *sql.DB, templateID int, prefill bool) (models.Workout, error) {
+	template, err := GetTemplate(db, templateID)
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	draft := newDraft()
+	for _, e := range template.Exercises {
+		lift := models.Lift{Name: e.Name, Weight: e.Weight, Reps: e.Reps, Sets: e.Sets}
+		if prefill {
+			last, ok, err := LastPerformance(db, e.Name)
+			if err != nil {
+				return models.Workout{}, err
+			}
+			if ok {
+				lift.Weight = last.Weight
+			}
+		}
+		setLift(&draft, len(draft.Lifts), lift)
+	}
+	return draft, nil
+}
+
+// RepeatLastSession builds a draft workout copying the lifts of the most recent
+// workout that included exercise.
+func RepeatLastSession(db *sql.DB, exercise string) (models.Workout, error) {
+	query := `SELECT w.id FROM workouts w
+		JOIN lifts l ON l.workout_id = w.id
+		WHERE l.name = ? COLLATE NOCASE AND w.deleted_at IS NULL AND w.status = 'completed'
+		ORDER BY ` + sortableDay + ` DESC, w.time_in DESC LIMIT 1`
+	var workoutID int
+	err := db.QueryRow(query, strings.TrimSpace(exercise)).Scan(&workoutID)
+	if err == sql.ErrNoRows {
+		return models.Workout{}, &NotFoundError{Resource: "workout with exercise", Key: exercise}
+	}
+	if err != nil {
+		return models.Workout{}, fmt.Errorf("failed to find last session: %v", err)
+	}
+
+	last, err := GetWorkoutByID(db, workoutID)
+	if err != nil {
+		return models.Workout{}, err
+	}
+
+	draft := newDraft()
+	draft.Lifts = last.Lifts
+	draft.Weight = last.Weight
+	draft.Reps = last.Reps
+	draft.Sets = last.Sets
+	draft.WarmUp = last.WarmUp
+	return draft, nil
+}
@@ -0,0 +1,199 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// DefaultTrashRetention is how long deleted workouts are kept before being purged.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashRetention reads the retention period from FITNESS_TRASH_RETENTION_DAYS,
+// falling back to DefaultTrashRetention.
+func TrashRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("FITNESS_TRASH_RETENTION_DAYS"))
+	if err != nil || days < 0 {
+		return DefaultTrashRetention
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ListTrash returns deleted workouts, most recently deleted first.
+func ListTrash(db *sql.DB) ([]models.Workout, error) {
+	query := `SELECT ` + workoutColumns + ` FROM workouts WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trash: %v", err)
+	}
+	defer rows.Close()
+
+	var workouts []models.Workout
+	for rows.Next() {
+		workout, err := scanWorkout(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %v", err)
+		}
+		workouts = append(workouts, workout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch trash: %v", err)
+	}
+	rows.Close()
+
+	for i := range workouts {
+		if err := loadDetails(db, &workouts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return workouts, nil
+}
+
+// RestoreWorkout takes a workout out of the trash.
+func RestoreWorkout(db *sql.DB, workoutID int, actor Actor) error {
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		var deleted int
+		err := tx.QueryRow(`SELECT count(*) FROM workouts WHERE id = ? AND deleted_at IS NOT NULL`, workoutID).Scan(&deleted)
+		if err != nil {
+			return fmt.Errorf("failed to fetch deleted workout: %v", err)
+		}
+		if deleted == 0 {
+			return &NotFoundError{Resource: "deleted workout", Key: fmt.Sprint(workoutID)}
+		}
+
+		before, err := auditSnapshot(tx, workoutID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE workouts SET deleted_at = NULL, version = version + 1, updated_at = ? WHERE id = ?`, now(), workoutID)
+		if err != nil {
+			return fmt.Errorf("failed to restore workout: %v", err)
+		}
+
+		after, err := auditSnapshot(tx, workoutID)
+		if err != nil {
+			return err
+		}
+		return recordChange(tx, actor, ActionRestore, workoutID, before, after)
+	})
+}
+
+// PurgeWorkout permanently removes a workout that is in the trash.
+func PurgeWorkout(db *sql.DB, workoutID int, actor Actor) error {
+	n, err := purge(db, actor, `id = ? AND deleted_at IS NOT NULL`, workoutID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return &NotFoundError{Resource: "deleted workout", Key: fmt.Sprint(workoutID)}
+	}
+	return nil
+}
+
+// EmptyTrash permanently removes every deleted workout and returns how many were purged.
+func EmptyTrash(db *sql.DB, actor Actor) (int, error) {
+	return purge(db, actor, `deleted_at IS NOT NULL`)
+}
+
+// PurgeExpired permanently removes workouts that have been in the trash for
+// longer than retention.
+func PurgeExpired(db *sql.DB, retention time.Duration) (int, error) {
+	cutoff := time.Now().Add(-retention).UTC().Format(time.RFC3339)
+	return purge(db, SystemActor, `deleted_at IS NOT NULL AND deleted_at < ?`, cutoff)
+}
+
+// StartTrashPurger purges expired workouts now and then once every interval
+// until the process exits.
+func StartTrashPurger(db *sql.DB, retention, interval time.Duration) {
+	run := func() {
+		n, err := PurgeExpired(db, retention)
+		if err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		} else if n > 0 {
+			log.Printf("Purged %d workout(s) from the trash", n)
+		}
+	}
+
+	run()
+	go func() {
+		for range time.Tick(interval) {
+			run()
+		}
+	}()
+}
+
+// purge hard-deletes the workouts matching where, along with their lifts.
+// Planned sessions and badges pointing at them are kept but lose the link.
+func purge(db *sql.DB, actor Actor, where string, args ...interface{}) (int, error) {
+	var purged int
+	err := executeInTransaction(db, func(tx *sql.Tx) error {
+		// Snapshot everything first so the audit log keeps the final state
+		var ids []int
+		rows, err := tx.Query(`SELECT id FROM workouts WHERE `+where, args...)
+		if err != nil {
+			return fmt.Errorf("failed to find workouts to purge: %v", err)
+		}
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan workout ID: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+
+		for _, id := range ids {
+			before, err := auditSnapshot(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := recordChange(tx, actor, ActionPurge, id, before, nil); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(`DELETE FROM lifts WHERE workout_id IN (SELECT id FROM workouts WHERE `+where+`)`, args...)
+		if err != nil {
+			return fmt.Errorf("failed to purge lifts: %v", err)
+		}
+		if err := deleteCardio(tx, where, args...); err != nil {
+			return err
+		}
+		if err := unlinkWorkouts(tx, where, args...); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(`DELETE FROM workouts WHERE `+where, args...)
+		if err != nil {
+			return fmt.Errorf("failed to purge workouts: %v", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to count purged workouts: %v", err)
+		}
+		purged = int(n)
+		return nil
+	})
+	return purged, err
+}
+
+// unlinkWorkouts clears the workout_id of planned sessions and badges that
+// refer to the workouts matching where. A planned session goes back to not
+// being done, and a badge stays earned without the workout that earned it.
+func unlinkWorkouts(tx *sql.Tx, where string, args ...interface{}) error {
+	for _, table := range []string{"planned_sessions", "achievements"} {
+		_, err := tx.Exec(`UPDATE `+table+` SET workout_id = 0 WHERE workout_id IN (SELECT id FROM workouts WHERE `+where+`)`, args...)
+		if err != nil {
+			return fmt.Errorf("failed to unlink %s: %v", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"testing"
+
+	"fitness-dev/models"
+)
+
+func TestPurgeUnlinksWorkout(t *testing.T) {
+	db := newTestDB(t)
+	plannedID, err := CreatePlannedSession(db, models.PlannedSession{Athlete: "sam", Date: "12/10/2026", Title: "Squats"}, testActor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workoutID := logSquat(t, db, "sam", "12/10/2026", 100, 5)
+	if _, err := CompletePlannedSession(db, plannedID, workoutID, testActor); err != nil {
+		t.Fatal(err)
+	}
+	if badges, err := GetWorkoutBadges(db, workoutID); err != nil || len(badges) == 0 {
+		t.Fatalf("workout badges = %v, %v, want the first session badge", badges, err)
+	}
+
+	if err := DeleteWorkout(db, workoutID, testActor); err != nil {
+		t.Fatal(err)
+	}
+	if err := PurgeWorkout(db, workoutID, testActor); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := GetPlannedSession(db, plannedID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.WorkoutID != 0 || p.Status != models.StatusPlanned {
+		t.Errorf("planned session = %+v, want it planned again", p)
+	}
+	achievements, err := GetAchievements(db, "sam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(achievements.Earned) == 0 {
+		t.Fatal("badges were removed with the workout")
+	}
+	for _, b := range achievements.Earned {
+		if b.WorkoutID != 0 {
+			t.Errorf("badge %s still points at purged workout %d", b.ID, b.WorkoutID)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+
+	"fitness-dev/models"
+)
+
+// Weights are stored in kg. Requests may send and receive them in lb instead;
+// each lift also records the unit it was entered in.
+
+// DefaultSmallestPlate is the lightest plate assumed per unit until a user
+// sets their own.
+var DefaultSmallestPlate = map[string]float64{
+	models.UnitKilogram: 1.25,
+	models.UnitPound:    2.5,
+}
+
+// NormalizeUnits lower-cases a unit and accepts the plural spellings.
+func NormalizeUnits(units string) string {
+	units = strings.ToLower(strings.TrimSpace(units))
+	switch units {
+	case "kgs", "kilogram", "kilograms":
+		return models.UnitKilogram
+	case "lbs", "pound", "pounds":
+		return models.UnitPound
+	}
+	return units
+}
+
+// ValidateUnits checks a weight unit, returning it normalised. Empty is
+// accepted and left empty so callers can fall back to a default.
+func ValidateUnits(units string) (string, error) {
+	units = NormalizeUnits(units)
+	if units != "" && units != models.UnitKilogram && units != models.UnitPound {
+		verr := &ValidationError{}
+		verr.Add("units", "must be %s or %s", models.UnitKilogram, models.UnitPound)
+		return "", verr
+	}
+	return units, nil
+}
+
+// ConvertWeight converts a weight between kg and lb. An empty unit means kg.
+func ConvertWeight(weight float64, from, to string) float64 {
+	if from == "" {
+		from = models.UnitKilogram
+	}
+	if to == "" {
+		to = models.UnitKilogram
+	}
+	return convertUnit(weight, from, to)
+}
+
+// toKilograms rewrites a workout sent in workout.Units into kg, recording
+// the unit on every lift that does not have an entry unit yet. Entry units
+// missing at the end are taken to be new lifts. Unknown units are left in
+// place for validation to report.
+func toKilograms(workout *models.Workout) {
+	units := NormalizeUnits(workout.Units)
+	if units == "" {
+		units = models.UnitKilogram
+	}
+	if units != models.UnitKilogram && units != models.UnitPound {
+		workout.Units = units
+		return
+	}
+
+	entry := make([]string, len(workout.Lifts))
+	for i := range entry {
+		if i < len(workout.EntryUnits) {
+			entry[i] = NormalizeUnits(workout.EntryUnits[i])
+		}
+		if entry[i] == "" {
+			entry[i] = units
+		}
+	}
+	workout.EntryUnits = entry
+
+	if units == models.UnitPound {
+		weights := make([]float64, len(workout.Weight))
+		for i, w := range workout.Weight {
+			// Gram precision keeps values sent back in lb from drifting
+			weights[i] = math.Round(ConvertWeight(w, units, models.UnitKilogram)*1000) / 1000
+		}
+		workout.Weight = weights
+
+		targets := make([]float64, len(workout.TargetWeight))
+		for i, w := range workout.TargetWeight {
+			targets[i] = math.Round(ConvertWeight(w, units, models.UnitKilogram)*1000) / 1000
+		}
+		workout.TargetWeight = targets
+	}
+	workout.Units = ""
+	compactExtras(workout)
+}
+
+// entryUnits returns the unit each of a stored workout's lifts was entered in.
+func entryUnits(workout models.Workout) []string {
+	units := make([]string, len(workout.Lifts))
+	for i := range units {
+		units[i] = models.UnitKilogram
+		if i < len(workout.EntryUnits) && workout.EntryUnits[i] != "" {
+			units[i] = workout.EntryUnits[i]
+		}
+	}
+	return units
+}
+
+// WorkoutInUnits returns a copy of a stored workout with its weights in
+// units, rounded to two decimals.
+func WorkoutInUnits(workout models.Workout, units string) models.Workout {
+	workout = workoutInUnits(workout, units)
+	for i, w := range workout.Weight {
+		workout.Weight[i] = round2(w)
+	}
+	for i, w := range workout.TargetWeight {
+		workout.TargetWeight[i] = round2(w)
+	}
+	return workout
+}
+
+// workoutInUnits converts without rounding, so that weights left untouched
+// by an edit come back to the same kg.
+func workoutInUnits(workout models.Workout, units string) models.Workout {
+	if units == "" {
+		units = models.UnitKilogram
+	}
+	if workout.Weight != nil {
+		weights := make([]float64, len(workout.Weight))
+		for i, w := range workout.Weight {
+			weights[i] = ConvertWeight(w, models.UnitKilogram, units)
+		}
+		workout.Weight = weights
+	}
+	if workout.TargetWeight != nil {
+		targets := make([]float64, len(workout.TargetWeight))
+		for i, w := range workout.TargetWeight {
+			targets[i] = ConvertWeight(w, models.UnitKilogram, units)
+		}
+		workout.TargetWeight = targets
+	}
+	workout.Units = units
+	return workout
+}
+
+// GetSettings returns a user's preferences, with defaults for users who
+// never saved any.
+func GetSettings(db *sql.DB, user string) (models.Settings, error) {
+	return getSettings(db, user)
+}
+
+func getSettings(q querier, user string) (models.Settings, error) {
+	s := models.Settings{User: user, Units: models.UnitKilogram}
+	err := q.QueryRow(`SELECT units, smallest_plate, leaderboard_opt_out FROM user_settings WHERE user = ?`, user).Scan(&s.Units, &s.SmallestPlate, &s.LeaderboardOptOut)
+	if err != nil && err != sql.ErrNoRows {
+		return models.Settings{}, fmt.Errorf("failed to fetch settings: %v", err)
+	}
+	if s.SmallestPlate <= 0 {
+		s.SmallestPlate = DefaultSmallestPlate[s.Units]
+	}
+	return s, nil
+}
+
+// SaveSettings stores a user's preferences. A smallest plate of 0 resets it
+// to the default for the unit.
+func SaveSettings(db *sql.DB, s models.Settings, actor Actor) error {
+	verr := &ValidationError{}
+	units, err := ValidateUnits(s.Units)
+	if err != nil || units == "" {
+		verr.Add("units", "must be %s or %s", models.UnitKilogram, models.UnitPound)
+	}
+	if s.SmallestPlate < 0 || s.SmallestPlate > MaxWeight {
+		verr.Add("smallest_plate", "must be between 0 and %g", MaxWeight)
+	}
+	if strings.TrimSpace(s.User) == "" {
+		verr.Add("user", "is required")
+	}
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO user_settings (user, units, smallest_plate, leaderboard_opt_out) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user) DO UPDATE SET units = excluded.units, smallest_plate = excluded.smallest_plate,
+			leaderboard_opt_out = excluded.leaderboard_opt_out`
+	user := strings.TrimSpace(s.User)
+	return executeInTransaction(db, func(tx *sql.Tx) error {
+		before, err := getSettings(tx, user)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, user, units, s.SmallestPlate, s.LeaderboardOptOut); err != nil {
+			return fmt.Errorf("failed to save settings: %v", err)
+		}
+		after, err := getSettings(tx, user)
+		if err != nil {
+			return err
+		}
+		return recordAudit(tx, actor, ActionUpdate, EntitySettings, user, before, after)
+	})
+}
+
+// LoadableIncrement is the smallest change in load the user can make on a
+// barbell: one of their smallest plates on each side.
+func LoadableIncrement(s models.Settings) float64 {
+	return 2 * s.SmallestPlate
+}
+
+// RoundToPlates rounds a weight in the settings' unit to the nearest load the
+// user's plates can make.
+func RoundToPlates(weight float64, s models.Settings) float64 {
+	return round2(roundWeight(weight, LoadableIncrement(s)))
+}
+
+// FormatLoad shows a weight (kg) in the settings' unit, rounded to their
+// plates as drafts are.
+func FormatLoad(kg float64, s models.Settings) string {
+	units := s.Units
+	if units == "" {
+		units = models.UnitKilogram
+	}
+	return fmt.Sprintf("%g%s", RoundToPlates(ConvertWeight(kg, models.UnitKilogram, units), s), units)
+}
+
+// DraftInUnits converts a prescribed or drafted workout into the settings'
+// unit and rounds every weight to the user's plates. Its lifts have not been
+// entered yet, so any entry units copied from past sessions are dropped.
+func DraftInUnits(draft models.Workout, s models.Settings) models.Workout {
+	draft = WorkoutInUnits(draft, s.Units)
+	draft.EntryUnits = nil
+	for i, w := range draft.Weight {
+		draft.Weight[i] = RoundToPlates(w, s)
+	}
+	for i, w := range draft.TargetWeight {
+		draft.TargetWeight[i] = RoundToPlates(w, s)
+	}
+	return draft
+}
+
+// InUnits returns settings for showing weights in units, keeping the user's
+// plates when the unit is their own and assuming the default plates otherwise.
+func InUnits(s models.Settings, units string) models.Settings {
+	if units == "" || units == s.Units {
+		return s
+	}
+	return models.Settings{User: s.User, Units: units, SmallestPlate: DefaultSmallestPlate[units], LeaderboardOptOut: s.LeaderboardOptOut}
+}
@@ -0,0 +1,315 @@
+package backend
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// Limits applied to individual lifts and sessions.
+const (
+	MaxReps            = 100
+	MaxSets            = 50
+	MaxWeight          = 1000.0 // kg
+	MaxSessionDuration = 8 * time.Hour
+	MaxRPE             = 10.0
+	MaxRIR             = 10
+	MaxRest            = 3600   // seconds
+	MaxDistance        = 1000.0 // km
+	MinHeartRate       = 30
+	MaxHeartRate       = 250
+	MaxElevation       = 10000.0 // metres
+)
+
+// NormalizeWorkout tidies user input before validation: it trims whitespace,
+// accepts DD-MM-YYYY as well as DD/MM/YYYY, and canonicalises mood and status
+// casing. Lifts of a workout not done yet may leave out their actual numbers.
+func NormalizeWorkout(workout models.Workout) models.Workout {
+	workout.Date = strings.ReplaceAll(strings.TrimSpace(workout.Date), "-", "/")
+	workout.TimeIn = strings.TrimSpace(workout.TimeIn)
+	workout.TimeOut = strings.TrimSpace(workout.TimeOut)
+	workout.MoodIn = canonicalMood(workout.MoodIn)
+	workout.MoodOut = canonicalMood(workout.MoodOut)
+	workout.Athlete = strings.TrimSpace(workout.Athlete)
+	workout.Status = strings.ToLower(strings.TrimSpace(workout.Status))
+
+	lifts := make([]string, len(workout.Lifts))
+	for i, name := range workout.Lifts {
+		lifts[i] = strings.TrimSpace(name)
+	}
+	workout.Lifts = lifts
+	if !workoutDone(workout) {
+		if workout.Weight == nil {
+			workout.Weight = make([]float64, len(lifts))
+		}
+		if workout.Reps == nil {
+			workout.Reps = make([]int, len(lifts))
+		}
+		if workout.Sets == nil {
+			workout.Sets = make([]int, len(lifts))
+		}
+	}
+
+	if workout.Tempo != nil {
+		tempo := make([]string, len(workout.Tempo))
+		for i, t := range workout.Tempo {
+			tempo[i] = strings.ToUpper(strings.TrimSpace(t))
+		}
+		workout.Tempo = tempo
+	}
+	workout.Cardio = normalizeCardio(workout.Cardio)
+	toKilograms(&workout)
+	return workout
+}
+
+// tempoPattern matches tempo notation: eccentric, bottom pause, concentric
+// and top pause in seconds, X for explosive, e.g. "3-1-X-0" or "31X0".
+var tempoPattern = regexp.MustCompile(`^[0-9X]-?[0-9X]-?[0-9X]-?[0-9X]$`)
+
+func canonicalMood(mood string) string {
+	mood = strings.TrimSpace(mood)
+	for _, m := range models.Moods {
+		if strings.EqualFold(m, mood) {
+			return m
+		}
+	}
+	return mood
+}
+
+// workoutDone reports whether a workout was completed. Workouts without a
+// status were logged after the fact, so they are.
+func workoutDone(workout models.Workout) bool {
+	return workout.Status == "" || workout.Status == models.StatusCompleted
+}
+
+// ValidateWorkout checks a complete workout, as required for inserts.
+// All problems are collected and returned together as a *ValidationError.
+// Workouts not done yet only need a date, and a time in once started.
+func ValidateWorkout(workout models.Workout) error {
+	verr := &ValidationError{}
+
+	if workout.Date == "" {
+		verr.Add("date", "is required")
+	}
+	if workout.TimeIn == "" && (workoutDone(workout) || workout.Status == models.StatusInProgress) {
+		verr.Add("time_in", "is required")
+	}
+	if workoutDone(workout) {
+		if workout.TimeOut == "" {
+			verr.Add("time_out", "is required")
+		}
+		if workout.MoodIn == "" {
+			verr.Add("mood_in", "is required")
+		}
+		if workout.MoodOut == "" {
+			verr.Add("mood_out", "is required")
+		}
+		if len(workout.Lifts) == 0 && len(workout.Cardio) == 0 {
+			verr.Add("lifts", "at least one lift or cardio activity is required")
+		}
+	}
+
+	validateFields(workout, verr)
+	return verr.OrNil()
+}
+
+// ValidateWorkoutUpdate checks a partial workout, as sent to UpdateWorkout.
+// Empty fields are left untouched by the update so only set fields are checked,
+// but at least one field must be present.
+func ValidateWorkoutUpdate(workout models.Workout) error {
+	verr := &ValidationError{}
+
+	if workout.Date == "" && workout.TimeIn == "" && workout.TimeOut == "" &&
+		workout.MoodIn == "" && workout.MoodOut == "" && len(workout.Lifts) == 0 &&
+		workout.Athlete == "" && workout.SessionRPE == 0 && len(workout.Cardio) == 0 && workout.Status == "" {
+		verr.Add("body", "at least one field must be provided")
+	}
+
+	validateFields(workout, verr)
+	return verr.OrNil()
+}
+
+func validateFields(workout models.Workout, verr *ValidationError) {
+	if workout.Date != "" {
+		if _, err := time.Parse(models.DateLayout, workout.Date); err != nil {
+			verr.Add("date", "must be a valid date in DD/MM/YYYY format")
+		}
+	}
+
+	timeIn, inErr := parseClock(workout.TimeIn)
+	if workout.TimeIn != "" && inErr != nil {
+		verr.Add("time_in", "must be a valid time in HH:MM format")
+	}
+	timeOut, outErr := parseClock(workout.TimeOut)
+	if workout.TimeOut != "" && outErr != nil {
+		verr.Add("time_out", "must be a valid time in HH:MM format")
+	}
+	if inErr == nil && outErr == nil {
+		if d := SessionDuration(timeIn, timeOut); d == 0 {
+			verr.Add("time_out", "must be after time_in")
+		} else if d > MaxSessionDuration {
+			verr.Add("time_out", "session cannot be longer than %v", MaxSessionDuration)
+		}
+	}
+
+	if workout.Units != "" {
+		verr.Add("units", "must be %s or %s", models.UnitKilogram, models.UnitPound)
+	}
+	if rpe := workout.SessionRPE; rpe != 0 && (rpe < 1 || rpe > MaxRPE) {
+		verr.Add("session_rpe", "must be between 1 and %g", MaxRPE)
+	}
+	if workout.Status != "" && !slices.Contains(models.Statuses, workout.Status) {
+		verr.Add("status", "must be one of %s", strings.Join(models.Statuses, ", "))
+	}
+
+	validateMood("mood_in", workout.MoodIn, verr)
+	validateMood("mood_out", workout.MoodOut, verr)
+	validateLifts(workout, verr)
+	validateCardio(workout.Cardio, verr)
+}
+
+func parseClock(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty time")
+	}
+	return time.Parse(models.TimeLayout, s)
+}
+
+// SessionDuration returns the time between in and out. A time out earlier
+// than the time in is treated as a session that ran past midnight.
+func SessionDuration(in, out time.Time) time.Duration {
+	d := out.Sub(in)
+	if d < 0 {
+		d += 24 * time.Hour
+	}
+	return d
+}
+
+func validateMood(field, mood string, verr *ValidationError) {
+	if mood == "" {
+		return
+	}
+	for _, m := range models.Moods {
+		if m == mood {
+			return
+		}
+	}
+	verr.Add(field, "must be one of %s", strings.Join(models.Moods, ", "))
+}
+
+func validateLifts(workout models.Workout, verr *ValidationError) {
+	n := len(workout.Lifts)
+	lengthsMatch := true
+	if len(workout.Weight) != n {
+		verr.Add("weight", "expected %d values to match lifts, got %d", n, len(workout.Weight))
+		lengthsMatch = false
+	}
+	if len(workout.Reps) != n {
+		verr.Add("reps", "expected %d values to match lifts, got %d", n, len(workout.Reps))
+		lengthsMatch = false
+	}
+	if len(workout.Sets) != n {
+		verr.Add("sets", "expected %d values to match lifts, got %d", n, len(workout.Sets))
+		lengthsMatch = false
+	}
+
+	for i, name := range workout.Lifts {
+		if name == "" {
+			verr.Add(fmt.Sprintf("lifts[%d]", i), "name is required")
+		}
+		if !lengthsMatch {
+			continue
+		}
+		// Lifts not done yet, and planned lifts left out of a completed
+		// workout, have no actual reps or sets
+		least := 1
+		if !workoutDone(workout) || (workout.Reps[i] == 0 && workout.Sets[i] == 0 && hasTarget(GetLift(workout, i))) {
+			least = 0
+		}
+		if w := workout.Weight[i]; w < 0 || w > MaxWeight {
+			verr.Add(fmt.Sprintf("weight[%d]", i), "must be between 0 and %g", MaxWeight)
+		}
+		if r := workout.Reps[i]; r < least || r > MaxReps {
+			verr.Add(fmt.Sprintf("reps[%d]", i), "must be between %d and %d", least, MaxReps)
+		}
+		if s := workout.Sets[i]; s < least || s > MaxSets {
+			verr.Add(fmt.Sprintf("sets[%d]", i), "must be between %d and %d", least, MaxSets)
+		}
+	}
+
+	validateExtras(workout, verr)
+}
+
+// hasTarget reports whether a lift was planned with a target.
+func hasTarget(lift models.Lift) bool {
+	return lift.TargetWeight != 0 || lift.TargetReps != 0 || lift.TargetSets != 0
+}
+
+// validateExtras checks the optional per-lift RPE, RIR, tempo, rest and target values.
+// Each may be omitted entirely, but if sent needs one entry per lift.
+func validateExtras(workout models.Workout, verr *ValidationError) {
+	n := len(workout.Lifts)
+	lengths := []struct {
+		field  string
+		length int
+	}{
+		{"rpe", len(workout.RPE)},
+		{"rir", len(workout.RIR)},
+		{"tempo", len(workout.Tempo)},
+		{"rest", len(workout.Rest)},
+		{"target_weight", len(workout.TargetWeight)},
+		{"target_reps", len(workout.TargetReps)},
+		{"target_sets", len(workout.TargetSets)},
+		{"warm_up", len(workout.WarmUp)},
+	}
+	for _, l := range lengths {
+		if l.length != 0 && l.length != n {
+			verr.Add(l.field, "expected %d values to match lifts, got %d", n, l.length)
+		}
+	}
+
+	for i, rpe := range workout.RPE {
+		if rpe != 0 && (rpe < 1 || rpe > MaxRPE) {
+			verr.Add(fmt.Sprintf("rpe[%d]", i), "must be between 1 and %g", MaxRPE)
+		}
+	}
+	for i, rir := range workout.RIR {
+		if rir != nil && (*rir < 0 || *rir > MaxRIR) {
+			verr.Add(fmt.Sprintf("rir[%d]", i), "must be between 0 and %d", MaxRIR)
+		}
+	}
+	for i, tempo := range workout.Tempo {
+		if tempo != "" && !tempoPattern.MatchString(tempo) {
+			verr.Add(fmt.Sprintf("tempo[%d]", i), "must be four digits or X, e.g. 3-1-X-0")
+		}
+	}
+	for i, rest := range workout.Rest {
+		if rest < 0 || rest > MaxRest {
+			verr.Add(fmt.Sprintf("rest[%d]", i), "must be between 0 and %d seconds", MaxRest)
+		}
+	}
+	for i, w := range workout.TargetWeight {
+		if w < 0 || w > MaxWeight {
+			verr.Add(fmt.Sprintf("target_weight[%d]", i), "must be between 0 and %g", MaxWeight)
+		}
+	}
+	for i, r := range workout.TargetReps {
+		if r < 0 || r > MaxReps {
+			verr.Add(fmt.Sprintf("target_reps[%d]", i), "must be between 0 and %d", MaxReps)
+		}
+	}
+	for i, s := range workout.TargetSets {
+		if s < 0 || s > MaxSets {
+			verr.Add(fmt.Sprintf("target_sets[%d]", i), "must be between 0 and %d", MaxSets)
+		}
+	}
+	for i, unit := range workout.EntryUnits {
+		if unit != models.UnitKilogram && unit != models.UnitPound {
+			verr.Add(fmt.Sprintf("entry_units[%d]", i), "must be %s or %s", models.UnitKilogram, models.UnitPound)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// validWorkout is a completed workout that passes validation.
+func validWorkout() models.Workout {
+	return models.Workout{
+		Date: "12/10/2026", TimeIn: "10:00", TimeOut: "11:00", MoodIn: "Good", MoodOut: "Great",
+		Lifts: []string{"Squat", "Bench Press"}, Weight: []float64{100, 80}, Reps: []int{5, 8}, Sets: []int{3, 3},
+	}
+}
+
+// invalidFields returns the fields named by a *ValidationError, in order.
+func invalidFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error %v is not a *ValidationError", err)
+	}
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+func TestValidateWorkout(t *testing.T) {
+	three := 3
+	eleven := 11
+	tests := []struct {
+		name   string
+		modify func(w *models.Workout)
+		want   []string
+	}{
+		{"valid", func(w *models.Workout) {}, nil},
+		{"missing everything", func(w *models.Workout) { *w = models.Workout{} },
+			[]string{"date", "time_in", "time_out", "mood_in", "mood_out", "lifts"}},
+		{"cardio only", func(w *models.Workout) {
+			w.Lifts, w.Weight, w.Reps, w.Sets = nil, nil, nil, nil
+			w.Cardio = []models.Cardio{{Activity: models.ActivityRun, Distance: 5, Duration: 1500}}
+		}, nil},
+		{"bad date", func(w *models.Workout) { w.Date = "31/02/2026" }, []string{"date"}},
+		{"bad time", func(w *models.Workout) { w.TimeIn = "25:00" }, []string{"time_in"}},
+		{"same time in and out", func(w *models.Workout) { w.TimeOut = w.TimeIn }, []string{"time_out"}},
+		{"past midnight", func(w *models.Workout) { w.TimeIn, w.TimeOut = "23:30", "00:30" }, nil},
+		{"too long", func(w *models.Workout) { w.TimeIn, w.TimeOut = "06:00", "15:00" }, []string{"time_out"}},
+		{"unknown mood", func(w *models.Workout) { w.MoodOut = "Elated" }, []string{"mood_out"}},
+		{"session RPE", func(w *models.Workout) { w.SessionRPE = 11 }, []string{"session_rpe"}},
+		{"unknown status", func(w *models.Workout) { w.Status = "done" }, []string{"status"}},
+		{"lengths differ", func(w *models.Workout) { w.Reps = []int{5} }, []string{"reps"}},
+		{"empty name", func(w *models.Workout) { w.Lifts[1] = "" }, []string{"lifts[1]"}},
+		{"out of range", func(w *models.Workout) { w.Weight[0], w.Reps[1], w.Sets[0] = -1, 101, 0 },
+			[]string{"weight[0]", "sets[0]", "reps[1]"}},
+		{"extras", func(w *models.Workout) {
+			w.RPE = []float64{8, 0.5}
+			w.RIR = []*int{&three, &eleven}
+			w.Tempo = []string{"3-1-X-0", "fast"}
+			w.Rest = []int{90}
+		}, []string{"rest", "rpe[1]", "rir[1]", "tempo[1]"}},
+		{"planned needs only a date", func(w *models.Workout) {
+			*w = models.Workout{Date: "12/10/2026", Status: models.StatusPlanned, Lifts: []string{"Squat"},
+				Weight: []float64{0}, Reps: []int{0}, Sets: []int{0}}
+		}, nil},
+		{"in progress needs a time in", func(w *models.Workout) {
+			*w = models.Workout{Date: "12/10/2026", Status: models.StatusInProgress}
+		}, []string{"time_in"}},
+		{"planned lift left out", func(w *models.Workout) {
+			w.Reps[1], w.Sets[1] = 0, 0
+			w.TargetWeight, w.TargetReps, w.TargetSets = []float64{0, 80}, []int{0, 8}, []int{0, 3}
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := validWorkout()
+			tt.modify(&w)
+			got := invalidFields(t, ValidateWorkout(w))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("invalid fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWorkoutUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		update models.Workout
+		want   []string
+	}{
+		{"empty", models.Workout{}, []string{"body"}},
+		{"one field", models.Workout{MoodIn: "Tired"}, nil},
+		{"one bad field", models.Workout{Date: "2026-10-12"}, []string{"date"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := invalidFields(t, ValidateWorkoutUpdate(tt.update))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("invalid fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeWorkout(t *testing.T) {
+	in := models.Workout{
+		Date: " 12-10-2026 ", TimeIn: " 10:00", TimeOut: "11:00 ", MoodIn: "good", MoodOut: " GREAT ",
+		Athlete: " sam ", Status: " Completed ",
+		Lifts: []string{" Squat "}, Weight: []float64{100}, Reps: []int{5}, Sets: []int{3}, Tempo: []string{" 31x0 "},
+	}
+	got := NormalizeWorkout(in)
+	if got.Date != "12/10/2026" || got.TimeIn != "10:00" || got.TimeOut != "11:00" {
+		t.Errorf("date and times = %q %q %q", got.Date, got.TimeIn, got.TimeOut)
+	}
+	if got.MoodIn != "Good" || got.MoodOut != "Great" {
+		t.Errorf("moods = %q, %q, want Good, Great", got.MoodIn, got.MoodOut)
+	}
+	if got.Athlete != "sam" || got.Status != models.StatusCompleted {
+		t.Errorf("athlete and status = %q, %q", got.Athlete, got.Status)
+	}
+	if got.Lifts[0] != "Squat" || got.Tempo[0] != "31X0" {
+		t.Errorf("lift and tempo = %q, %q", got.Lifts[0], got.Tempo[0])
+	}
+	if err := ValidateWorkout(got); err != nil {
+		t.Errorf("normalized workout is invalid: %v", err)
+	}
+
+	planned := NormalizeWorkout(models.Workout{Date: "12/10/2026", Status: models.StatusPlanned, Lifts: []string{"Squat", "Row"}})
+	if len(planned.Weight) != 2 || len(planned.Reps) != 2 || len(planned.Sets) != 2 {
+		t.Errorf("planned lifts were not padded: %+v", planned)
+	}
+}
+
+func TestSessionDuration(t *testing.T) {
+	tests := []struct {
+		in, out string
+		want    time.Duration
+	}{
+		{"10:00", "11:30", 90 * time.Minute},
+		{"23:30", "00:15", 45 * time.Minute},
+		{"10:00", "10:00", 0},
+	}
+	for _, tt := range tests {
+		in, _ := parseClock(tt.in)
+		out, _ := parseClock(tt.out)
+		if got := SessionDuration(in, out); got != tt.want {
+			t.Errorf("SessionDuration(%s, %s) = %v, want %v", tt.in, tt.out, got, tt.want)
+		}
+	}
+}
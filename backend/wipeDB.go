@@ -5,14 +5,26 @@ import (
 	"fmt"
 )
 
-func WipeDB(db *sql.DB) error {
-	queries := []string{
-		`DELETE FROM workouts`,
-		`DELETE FROM lifts`,
+// WipeDB moves every workout to the trash. Use EmptyTrash to remove them for good.
+func WipeDB(db *sql.DB, actor Actor) error {
+	rows, err := db.Query(`SELECT id FROM workouts WHERE deleted_at IS NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to wipe database: %v", err)
 	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to wipe database: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
 
-	for _, query := range queries {
-		if _, err := db.Exec(query); err != nil {
+	// Delete one at a time so each workout gets its own audit entry
+	for _, id := range ids {
+		if err := DeleteWorkout(db, id, actor); err != nil {
 			return fmt.Errorf("failed to wipe database: %v", err)
 		}
 	}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"fitness-dev/backend"
+)
+
+func showAchievements(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	achievements, err := backend.GetAchievements(db, cliActor.Name)
+	if err != nil {
+		fmt.Printf("Failed to fetch achievements: %v\n", err)
+		return
+	}
+	units := cliSettings(db).Units
+
+	fmt.Printf("Earned (%d)\n", len(achievements.Earned))
+	for _, b := range achievements.Earned {
+		fmt.Printf("  ★ %-22s %s, workout %d\n", b.Name, b.EarnedAt[:10], b.WorkoutID)
+	}
+	fmt.Printf("Locked (%d)\n", len(achievements.Locked))
+	for _, b := range achievements.Locked {
+		fmt.Printf("  ☆ %-22s %s\n", b.Name, backend.BadgeInUnits(b, units).Description)
+	}
+
+	fmt.Println("Press Enter to continue...")
+	reader.ReadString('\n')
+}
+
+// printEarnedBadges announces the badges a newly logged workout earned.
+func printEarnedBadges(db *sql.DB, workoutID int) {
+	badges, err := backend.GetWorkoutBadges(db, workoutID)
+	if err != nil {
+		return
+	}
+	units := cliSettings(db).Units
+	for _, b := range badges {
+		fmt.Printf("★ Badge earned: %s - %s\n", b.Name, backend.BadgeInUnits(b, units).Description)
+	}
+}
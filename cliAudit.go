@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"fitness-dev/backend"
+)
+
+// cliActor is recorded in the audit log for changes made from the CLI.
+var cliActor = backend.Actor{Name: cliUser(), Source: backend.SourceCLI}
+
+func cliUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "cli"
+}
+
+func viewAuditLog(db *sql.DB) {
+	var filter backend.AuditFilter
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	fmt.Print("Workout ID (blank for all): ")
+	if s := readLine(reader); s != "" {
+		fmt.Sscan(s, &filter.WorkoutID)
+	}
+
+	var err error
+	fmt.Print("From (DD/MM/YYYY, blank for no limit): ")
+	if filter.From, err = backend.ParseAuditTime(readLine(reader), false); err != nil {
+		fmt.Printf("Invalid date: %v\n", err)
+		return
+	}
+	fmt.Print("To (DD/MM/YYYY, blank for no limit): ")
+	if filter.To, err = backend.ParseAuditTime(readLine(reader), true); err != nil {
+		fmt.Printf("Invalid date: %v\n", err)
+		return
+	}
+
+	entries, err := backend.GetAuditLog(db, filter)
+	if err != nil {
+		fmt.Printf("Failed to fetch audit log: %v\n", err)
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No audit entries found.")
+	}
+	for _, e := range entries {
+		fmt.Printf("%s  %s %-4s %-8s by %s (%s)\n", e.CreatedAt, e.Entity, e.Key, e.Action, e.Actor, e.Source)
+		if e.Before != nil {
+			fmt.Printf("    before: %s\n", e.Before)
+		}
+		if e.After != nil {
+			fmt.Printf("    after:  %s\n", e.After)
+		}
+	}
+
+	fmt.Println("Press Enter to continue...")
+	reader.ReadString('\n')
+}
+
+func readLine(reader *bufio.Reader) string {
+	line, _ := reader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fitness-dev/backend"
+	"fitness-dev/importer"
+	"fitness-dev/render"
+)
+
+// importActivity creates a workout from a GPX, TCX or FIT file.
+func importActivity(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	fmt.Print("Path to GPX, TCX or FIT file: ")
+	path := readLine(reader)
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("Failed to open file: %v\n", err)
+		return
+	}
+	track, err := importer.Parse(filepath.Base(path), f)
+	f.Close()
+	if err != nil {
+		fmt.Printf("Failed to import file: %v\n", err)
+		return
+	}
+
+	activity := promptDefault(reader, "Activity (run, row, bike, swim)", track.Activity)
+	workout := track.Workout(activity)
+	cardio := workout.Cardio[0]
+	fmt.Printf("%s on %s %s-%s: %.2fkm in %s", cardio.Activity, workout.Date, workout.TimeIn, workout.TimeOut, cardio.Distance, render.Seconds(cardio.Duration))
+	if cardio.AvgHR > 0 {
+		fmt.Printf(", avg HR %d", cardio.AvgHR)
+	}
+	fmt.Println()
+	for i, s := range cardio.Splits {
+		fmt.Printf("  %2d  %.2fkm  %s  %s/km\n", i+1, s.Distance, render.Seconds(s.Duration), render.Seconds(int(s.Pace)))
+	}
+
+	workout.MoodIn = promptDefault(reader, "Mood in", "")
+	workout.MoodOut = promptDefault(reader, "Mood out", "")
+
+	id, err := backend.InsertWorkout(db, workout, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to create workout: %v\n", err)
+		return
+	}
+
+	fmt.Println("Workout imported successfully!")
+	printEarnedBadges(db, id)
+	recordUndo(fmt.Sprintf("import workout on %s", workout.Date), func(db *sql.DB) error {
+		return backend.DeleteWorkout(db, id, cliActor)
+	})
+}
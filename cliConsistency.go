@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+// calendarShades draws calendar levels 0 (rest) to 4.
+var calendarShades = []string{"·", "░", "▒", "▓", "█"}
+
+func showConsistency(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	year := promptInt(reader, "Year", time.Now().Year())
+	attendance, err := backend.GetAttendance(db, cliActor.Name, backend.DefaultAttendanceWeeks)
+	if err != nil {
+		fmt.Printf("Failed to fetch attendance: %v\n", err)
+		return
+	}
+	calendar, err := backend.GetCalendar(db, cliActor.Name, year)
+	if err != nil {
+		fmt.Printf("Failed to fetch the calendar: %v\n", err)
+		return
+	}
+
+	printCalendar(calendar)
+	fmt.Println()
+	fmt.Printf("Sessions: %d, average %.0f minutes\n", attendance.Sessions, attendance.AverageDuration)
+	fmt.Printf("Daily streak: %d (longest %d)\n", attendance.DailyStreak.Current, attendance.DailyStreak.Longest)
+	fmt.Printf("Weekly streak: %d (longest %d)\n", attendance.WeeklyStreak.Current, attendance.WeeklyStreak.Longest)
+	if len(attendance.CommonTimes) > 0 {
+		times := make([]string, len(attendance.CommonTimes))
+		for i, t := range attendance.CommonTimes {
+			times[i] = fmt.Sprintf("%02d:00 (%.0f%%)", t.Hour, t.Share)
+		}
+		fmt.Printf("Usual start times: %s\n", strings.Join(times, ", "))
+	}
+
+	fmt.Println("\nSessions per week")
+	for _, w := range attendance.Weeks {
+		fmt.Printf("%s  %s %d\n", w.WeekStart, strings.Repeat("■", w.Sessions), w.Sessions)
+	}
+
+	fmt.Println("Press Enter to continue...")
+	reader.ReadString('\n')
+}
+
+// printCalendar draws a year as a heatmap: one row per weekday, one column
+// per week starting on Monday.
+func printCalendar(calendar models.Calendar) {
+	first, _ := time.Parse(models.DateLayout, calendar.Days[0].Date)
+	offset := (int(first.Weekday()) + 6) % 7 // Mondays before 1 January
+	columns := (offset + len(calendar.Days) + 6) / 7
+
+	months := []rune(strings.Repeat(" ", columns+3))
+	for i, d := range calendar.Days {
+		if day, _ := time.Parse(models.DateLayout, d.Date); day.Day() == 1 {
+			col := (offset + i) / 7
+			for j, r := range day.Month().String()[:3] {
+				if col+j < columns {
+					months[col+j] = r
+				}
+			}
+		}
+	}
+	fmt.Printf("%d\n    %s\n", calendar.Year, strings.TrimRight(string(months), " "))
+
+	for weekday := 0; weekday < 7; weekday++ {
+		var row strings.Builder
+		row.WriteString([]string{"Mon", "   ", "Wed", "   ", "Fri", "   ", "Sun"}[weekday] + " ")
+		for col := 0; col < columns; col++ {
+			i := col*7 + weekday - offset
+			if i < 0 || i >= len(calendar.Days) {
+				row.WriteString(" ")
+				continue
+			}
+			row.WriteString(calendarShades[calendar.Days[i].Level])
+		}
+		fmt.Println(row.String())
+	}
+	fmt.Printf("    less %s more\n", strings.Join(calendarShades, ""))
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+func plateCalculator(db *sql.DB) {
+	fmt.Scanln()
+	for {
+		fmt.Println("Plate Calculator")
+		fmt.Println("1 - Load a Bar")
+		fmt.Println("2 - Warm-up Sets")
+		fmt.Println("3 - Show Equipment")
+		fmt.Println("4 - Edit Equipment")
+		fmt.Println("5 - Back")
+		fmt.Print("Please enter a number to continue: ")
+
+		var userinput int
+		fmt.Scan(&userinput)
+
+		switch userinput {
+		case 1:
+			calculatePlates(db)
+		case 2:
+			showWarmUp(db)
+		case 3:
+			showEquipment(db)
+		case 4:
+			editEquipment(db)
+		case 5:
+			return
+		default:
+			fmt.Println("Invalid option. Please try again.")
+		}
+	}
+}
+
+// cliEquipment returns the CLI user's equipment profile, or nil after
+// reporting why it could not be read.
+func cliEquipment(db *sql.DB) *models.Equipment {
+	eq, err := backend.GetEquipment(db, cliActor.Name)
+	if err != nil {
+		fmt.Printf("Failed to read equipment: %v\n", err)
+		return nil
+	}
+	return &eq
+}
+
+func formatLoading(loading models.PlateLoading) string {
+	plates := make([]string, len(loading.PerSide))
+	for i, p := range loading.PerSide {
+		plates[i] = strconv.FormatFloat(p, 'f', -1, 64)
+	}
+	side := strings.Join(plates, " + ")
+	if side == "" {
+		side = "no plates"
+	}
+	s := fmt.Sprintf("%g%s %s, per side: %s", loading.Bar.Weight, loading.Units, loading.Bar.Name, side)
+	if !loading.Exact {
+		s += fmt.Sprintf(" (makes %g%s)", loading.Total, loading.Units)
+	}
+	return s
+}
+
+func calculatePlates(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	eq := cliEquipment(db)
+	if eq == nil {
+		return
+	}
+	target := promptFloat(reader, fmt.Sprintf("Target weight (%s)", eq.Units), 0)
+	bar := promptDefault(reader, "Bar", eq.Bars[0].Name)
+
+	loading, err := backend.LoadPlates(*eq, bar, target)
+	if err != nil {
+		fmt.Printf("Failed to load the bar: %v\n", err)
+		return
+	}
+	fmt.Println(formatLoading(loading))
+}
+
+func showWarmUp(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	eq := cliEquipment(db)
+	if eq == nil {
+		return
+	}
+	settings := cliSettings(db)
+	req := models.WarmUpRequest{}
+	req.Weight = promptFloat(reader, fmt.Sprintf("Working weight (%s)", settings.Units), 0)
+	req.Implement = promptDefault(reader, "Implement (barbell or dumbbell)", models.ImplementBarbell)
+	if req.Implement == models.ImplementBarbell {
+		req.Bar = promptDefault(reader, "Bar", eq.Bars[0].Name)
+	}
+
+	ramp, err := backend.WarmUpRamp(*eq, req, settings.Units)
+	if err != nil {
+		fmt.Printf("Failed to build warm-ups: %v\n", err)
+		return
+	}
+	printWarmUp(ramp)
+}
+
+func printWarmUp(ramp models.WarmUp) {
+	if len(ramp.Sets) == 0 {
+		fmt.Println("No warm-up sets needed.")
+		return
+	}
+	for _, set := range ramp.Sets {
+		fmt.Printf("%d x %d @ %g%s", set.Sets, set.Reps, set.Weight, ramp.Units)
+		if set.Loading != nil {
+			fmt.Printf("  (%s)", formatLoading(*set.Loading))
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Working weight: %g%s\n", ramp.Working, ramp.Units)
+}
+
+func showEquipment(db *sql.DB) {
+	eq := cliEquipment(db)
+	if eq == nil {
+		return
+	}
+	fmt.Printf("Units: %s\n", eq.Units)
+	fmt.Printf("Bars: %s\n", formatBars(eq.Bars))
+	fmt.Printf("Plates: %s\n", formatPlates(eq.Plates))
+	fmt.Printf("Dumbbell increment: %g%s\n", eq.DumbbellIncrement, eq.Units)
+}
+
+// formatBars and parseBars use the form "Barbell=20, EZ bar=10".
+func formatBars(bars []models.Bar) string {
+	parts := make([]string, len(bars))
+	for i, b := range bars {
+		parts[i] = fmt.Sprintf("%s=%g", b.Name, b.Weight)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func parseBars(s string) ([]models.Bar, error) {
+	var bars []models.Bar
+	for _, part := range strings.Split(s, ",") {
+		name, weight, ok := strings.Cut(part, "=")
+		w, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("bar %q should look like Barbell=20", strings.TrimSpace(part))
+		}
+		bars = append(bars, models.Bar{Name: strings.TrimSpace(name), Weight: w})
+	}
+	return bars, nil
+}
+
+// formatPlates and parsePlates use the form "25x4, 10x2": plate weight times
+// the number of pairs.
+func formatPlates(plates []models.PlatePair) string {
+	parts := make([]string, len(plates))
+	for i, p := range plates {
+		parts[i] = fmt.Sprintf("%gx%d", p.Weight, p.Pairs)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func parsePlates(s string) ([]models.PlatePair, error) {
+	var plates []models.PlatePair
+	for _, part := range strings.Split(s, ",") {
+		weight, pairs, ok := strings.Cut(strings.TrimSpace(part), "x")
+		w, werr := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		n, nerr := strconv.Atoi(strings.TrimSpace(pairs))
+		if !ok || werr != nil || nerr != nil {
+			return nil, fmt.Errorf("plate %q should look like 25x4", strings.TrimSpace(part))
+		}
+		plates = append(plates, models.PlatePair{Weight: w, Pairs: n})
+	}
+	return plates, nil
+}
+
+func editEquipment(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	current := cliEquipment(db)
+	if current == nil {
+		return
+	}
+	eq := *current
+	eq.Units = backend.NormalizeUnits(promptDefault(reader, "Units the equipment is marked in (kg or lb)", current.Units))
+
+	var err error
+	if eq.Bars, err = parseBars(promptDefault(reader, "Bars", formatBars(current.Bars))); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if eq.Plates, err = parsePlates(promptDefault(reader, "Plates (weight x pairs)", formatPlates(current.Plates))); err != nil {
+		fmt.Println(err)
+		return
+	}
+	eq.DumbbellIncrement = promptFloat(reader, "Dumbbell increment (0 for none)", current.DumbbellIncrement)
+
+	if err := backend.SaveEquipment(db, eq, cliActor); err != nil {
+		fmt.Printf("Failed to save equipment: %v\n", err)
+		return
+	}
+	fmt.Println("Equipment saved.")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"fitness-dev/backend"
+	"fitness-dev/render"
+)
+
+// exportWorkouts writes the workouts in a date range to a Markdown or
+// plain-text file.
+func exportWorkouts(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	start := promptDefault(reader, "Start date (DD/MM/YYYY)", "")
+	end := promptDefault(reader, "End date (DD/MM/YYYY)", start)
+	format := strings.ToLower(promptDefault(reader, "Format (md, text)", "md"))
+	write, ext := render.Markdown, ".md"
+	switch format {
+	case "md":
+	case "text":
+		write, ext = render.Text, ".txt"
+	default:
+		fmt.Println("Format must be md or text.")
+		return
+	}
+	path := promptDefault(reader, "File", "workouts"+ext)
+
+	workouts, err := backend.GetWorkoutsByDateRange(db, start, end)
+	if err != nil {
+		fmt.Printf("Failed to fetch workouts: %v\n", err)
+		return
+	}
+	if len(workouts) == 0 {
+		fmt.Println("No workouts in that range.")
+		return
+	}
+	records, err := backend.RecordLifts(db, workouts)
+	if err != nil {
+		fmt.Printf("Failed to find personal records: %v\n", err)
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Failed to create file: %v\n", err)
+		return
+	}
+	err = write(f, workouts, render.Options{Units: cliSettings(db).Units, Records: records})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Printf("Failed to write %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("Exported %d workouts to %s\n", len(workouts), path)
+}
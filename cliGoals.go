@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+func manageGoals(db *sql.DB) {
+	fmt.Scanln()
+	for {
+		fmt.Println("Goals")
+		fmt.Println("1 - Goal Status")
+		fmt.Println("2 - Add Goal")
+		fmt.Println("3 - Delete Goal")
+		fmt.Println("4 - Back")
+		fmt.Print("Please enter a number to continue: ")
+
+		var userinput int
+		fmt.Scan(&userinput)
+
+		switch userinput {
+		case 1:
+			goalStatus(db)
+		case 2:
+			addGoal(db)
+		case 3:
+			deleteGoal(db)
+		case 4:
+			return
+		default:
+			fmt.Println("Invalid option. Please try again.")
+		}
+	}
+}
+
+// formatGoalValue shows a goal's measure: sessions per week, or a weight in
+// the user's unit.
+func formatGoalValue(kind string, value float64, settings models.Settings) string {
+	if kind == models.GoalKindFrequency {
+		return fmt.Sprintf("%g/week", value)
+	}
+	return formatWeight(value, settings)
+}
+
+func describeGoal(goal models.Goal, settings models.Settings) string {
+	target := formatGoalValue(goal.Kind, goal.Target, settings)
+	var s string
+	switch goal.Kind {
+	case models.GoalKindE1RM:
+		s = fmt.Sprintf("%s e1RM %s", goal.Exercise, target)
+	case models.GoalKindVolume:
+		exercise := goal.Exercise
+		if exercise == "" {
+			exercise = "All lifts"
+		}
+		s = fmt.Sprintf("%s %s/week", exercise, target)
+	case models.GoalKindBodyweight:
+		s = fmt.Sprintf("Bodyweight %s", target)
+	default:
+		s = fmt.Sprintf("Train %s", target)
+	}
+	if goal.Deadline != "" {
+		s += " by " + goal.Deadline
+	}
+	return s
+}
+
+func goalStatus(db *sql.DB) {
+	settings := cliSettings(db)
+	progress, err := backend.GetGoalsProgress(db, cliActor.Name)
+	if err != nil {
+		fmt.Printf("Failed to fetch goals: %v\n", err)
+		return
+	}
+
+	if len(progress) == 0 {
+		fmt.Println("No goals yet.")
+		return
+	}
+	for _, p := range progress {
+		fmt.Printf("%d: %s\n", p.Goal.ID, describeGoal(p.Goal, settings))
+		status := strings.ReplaceAll(p.Status, "_", " ")
+		if p.Status == models.GoalNoData {
+			fmt.Printf("   %s\n", status)
+			continue
+		}
+		fmt.Printf("   %s, %.0f%% (%s -> %s)", status, p.Progress,
+			formatGoalValue(p.Goal.Kind, p.Baseline, settings), formatGoalValue(p.Goal.Kind, p.Current, settings))
+		if p.ProjectedDate != "" && p.Status != models.GoalAchieved {
+			fmt.Printf(", projected %s", p.ProjectedDate)
+		}
+		fmt.Println()
+	}
+}
+
+func addGoal(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	settings := cliSettings(db)
+	goal := models.Goal{Athlete: cliActor.Name}
+	goal.Kind = promptDefault(reader, "Kind ("+strings.Join(models.GoalKinds, ", ")+")", models.GoalKindE1RM)
+	switch goal.Kind {
+	case models.GoalKindE1RM:
+		goal.Exercise = promptDefault(reader, "Exercise", "")
+	case models.GoalKindVolume:
+		goal.Exercise = promptDefault(reader, "Exercise (blank for all lifts)", "")
+	}
+
+	if goal.Kind == models.GoalKindFrequency {
+		goal.Target = promptFloat(reader, "Sessions per week", 3)
+	} else {
+		target := promptFloat(reader, fmt.Sprintf("Target (%s)", settings.Units), 0)
+		goal.Target = backend.ConvertWeight(target, settings.Units, models.UnitKilogram)
+	}
+	goal.StartDate = promptDefault(reader, "Start date (DD/MM/YYYY)", time.Now().Format(models.DateLayout))
+	goal.Deadline = promptDefault(reader, "Deadline (DD/MM/YYYY, blank for none)", "")
+
+	id, err := backend.CreateGoal(db, goal, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to create goal: %v\n", err)
+		return
+	}
+
+	fmt.Println("Goal created.")
+	recordUndo(fmt.Sprintf("add goal %d", id), func(db *sql.DB) error {
+		return backend.DeleteGoal(db, id, cliActor)
+	})
+}
+
+func deleteGoal(db *sql.DB) {
+	fmt.Print("Enter goal ID to delete: ")
+	var id int
+	fmt.Scan(&id)
+
+	if err := backend.DeleteGoal(db, id, cliActor); err != nil {
+		fmt.Printf("Failed to delete goal: %v\n", err)
+		return
+	}
+	fmt.Println("Goal deleted.")
+}
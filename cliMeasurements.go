@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+func manageMeasurements(db *sql.DB) {
+	fmt.Scanln()
+	for {
+		fmt.Println("Measurements")
+		fmt.Println("1 - List Measurements")
+		fmt.Println("2 - Log Measurement")
+		fmt.Println("3 - Delete Measurement")
+		fmt.Println("4 - Bodyweight Trend")
+		fmt.Println("5 - Back")
+		fmt.Print("Please enter a number to continue: ")
+
+		var userinput int
+		fmt.Scan(&userinput)
+
+		switch userinput {
+		case 1:
+			listMeasurements(db)
+		case 2:
+			logMeasurement(db)
+		case 3:
+			deleteMeasurement(db)
+		case 4:
+			bodyweightTrend(db)
+		case 5:
+			return
+		default:
+			fmt.Println("Invalid option. Please try again.")
+		}
+	}
+}
+
+func listMeasurements(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	fmt.Print("Metric (blank for all): ")
+	metric := readLine(reader)
+
+	measurements, err := backend.GetMeasurements(db, cliActor.Name, metric, "", "")
+	if err != nil {
+		fmt.Printf("Failed to fetch measurements: %v\n", err)
+		return
+	}
+
+	if len(measurements) == 0 {
+		fmt.Println("No measurements yet.")
+		return
+	}
+	for _, m := range measurements {
+		fmt.Printf("%d: %s  %-10s %8.2f %s\n", m.ID, m.Date, m.Metric, m.Value, m.Unit)
+	}
+}
+
+func logMeasurement(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	m := models.Measurement{Athlete: cliActor.Name}
+	m.Date = promptDefault(reader, "Date (DD/MM/YYYY)", time.Now().Format(models.DateLayout))
+	m.Metric = promptDefault(reader, "Metric", models.MetricBodyweight)
+	m = backend.NormalizeMeasurement(m)
+	m.Unit = promptDefault(reader, "Unit", m.Unit)
+	m.Value = promptFloat(reader, "Value", 0)
+
+	id, err := backend.LogMeasurement(db, m, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to log measurement: %v\n", err)
+		return
+	}
+
+	fmt.Println("Measurement logged.")
+	recordUndo(fmt.Sprintf("log %s on %s", m.Metric, m.Date), func(db *sql.DB) error {
+		return backend.DeleteMeasurement(db, id, cliActor)
+	})
+}
+
+func deleteMeasurement(db *sql.DB) {
+	fmt.Print("Enter measurement ID to delete: ")
+	var id int
+	fmt.Scan(&id)
+
+	if err := backend.DeleteMeasurement(db, id, cliActor); err != nil {
+		fmt.Printf("Failed to delete measurement: %v\n", err)
+		return
+	}
+	fmt.Println("Measurement deleted.")
+}
+
+func bodyweightTrend(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	alpha := promptFloat(reader, "Smoothing (0-1)", backend.DefaultTrendAlpha)
+	series, err := backend.MeasurementTrend(db, cliActor.Name, models.MetricBodyweight, alpha)
+	if err != nil {
+		fmt.Printf("Failed to compute the trend: %v\n", err)
+		return
+	}
+
+	if len(series) == 0 {
+		fmt.Println("No bodyweight logged yet.")
+		return
+	}
+	fmt.Println("Date          Weight     Trend")
+	for _, m := range series {
+		fmt.Printf("%-12s  %6.2f%-2s  %6.2f%s\n", m.Date, m.Value, m.Unit, m.Trend, m.Unit)
+	}
+	first, last := series[0], series[len(series)-1]
+	fmt.Printf("Trend change: %s%s\n", strconv.FormatFloat(last.Trend-first.Trend, 'f', 2, 64), last.Unit)
+}
@@ -0,0 +1,435 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/ical"
+	"fitness-dev/models"
+	"fitness-dev/render"
+)
+
+func managePlanned(db *sql.DB) {
+	fmt.Scanln()
+	for {
+		fmt.Println("Planned Sessions")
+		fmt.Println("1 - List Upcoming Sessions")
+		fmt.Println("2 - Plan Next Program Workout")
+		fmt.Println("3 - Start or Resume Session")
+		fmt.Println("4 - Skip Session")
+		fmt.Println("5 - Mark Session Completed")
+		fmt.Println("6 - Import Calendar File")
+		fmt.Println("7 - Export Calendar File")
+		fmt.Println("8 - Adherence")
+		fmt.Println("9 - Back")
+		fmt.Print("Please enter a number to continue: ")
+
+		var userinput int
+		fmt.Scan(&userinput)
+
+		switch userinput {
+		case 1:
+			listPlanned(db)
+		case 2:
+			planProgramWorkout(db)
+		case 3:
+			startSession(db)
+		case 4:
+			skipSession(db)
+		case 5:
+			completePlanned(db)
+		case 6:
+			importCalendar(db)
+		case 7:
+			exportCalendar(db)
+		case 8:
+			showAdherence(db)
+		case 9:
+			return
+		default:
+			fmt.Println("Invalid option. Please try again.")
+		}
+	}
+}
+
+// pendingSession is a planned or started workout, or a planned session
+// with no workout yet.
+type pendingSession struct {
+	workout models.Workout
+	planned models.PlannedSession // set when there is no workout yet
+}
+
+func (s pendingSession) date() string {
+	if s.planned.ID != 0 {
+		return s.planned.Date
+	}
+	return s.workout.Date
+}
+
+func (s pendingSession) String() string {
+	date, timeIn, timeOut := s.workout.Date, s.workout.TimeIn, s.workout.TimeOut
+	title := fmt.Sprintf("%s (%s workout %d)", render.Truncate(strings.Join(s.workout.Lifts, ", "), 50), s.workout.Status, s.workout.ID)
+	if s.planned.ID != 0 {
+		date, timeIn, timeOut = s.planned.Date, s.planned.TimeIn, s.planned.TimeOut
+		title = fmt.Sprintf("%s (planned session %d)", s.planned.Title, s.planned.ID)
+	}
+	when := "all day"
+	if timeIn != "" {
+		when = timeIn + "-" + timeOut
+	}
+	return fmt.Sprintf("%s %-11s  %s", date, when, title)
+}
+
+// pendingSessions returns the CLI user's sessions still to do, soonest first.
+func pendingSessions(db *sql.DB) ([]pendingSession, error) {
+	workouts, err := backend.GetAthleteWorkouts(db, cliActor.Name)
+	if err != nil {
+		return nil, err
+	}
+	planned, err := backend.GetPlannedSessions(db, cliActor.Name, "", "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []pendingSession
+	linked := map[int]bool{}
+	for _, p := range planned {
+		sessions = append(sessions, pendingSession{planned: p})
+		linked[p.WorkoutID] = true
+	}
+	for _, w := range workouts {
+		if (w.Status == models.StatusPlanned || w.Status == models.StatusInProgress) && !linked[w.ID] {
+			sessions = append(sessions, pendingSession{workout: w})
+		}
+	}
+	slices.SortStableFunc(sessions, func(a, b pendingSession) int {
+		x, _ := time.Parse(models.DateLayout, a.date())
+		y, _ := time.Parse(models.DateLayout, b.date())
+		return x.Compare(y)
+	})
+	return sessions, nil
+}
+
+func listPlanned(db *sql.DB) {
+	sessions, err := pendingSessions(db)
+	if err != nil {
+		fmt.Printf("Failed to fetch planned sessions: %v\n", err)
+		return
+	}
+	if len(sessions) == 0 {
+		fmt.Println("Nothing planned.")
+		return
+	}
+	for _, s := range sessions {
+		fmt.Println(s)
+		if s.planned.Notes != "" {
+			fmt.Println("    " + render.Truncate(s.planned.Notes, 70))
+		}
+	}
+}
+
+// choosePending lists the pending sessions and asks for one. ok is false if
+// there are none or the choice is invalid.
+func choosePending(db *sql.DB, reader *bufio.Reader) (pendingSession, bool) {
+	sessions, err := pendingSessions(db)
+	if err != nil {
+		fmt.Printf("Failed to fetch planned sessions: %v\n", err)
+		return pendingSession{}, false
+	}
+	if len(sessions) == 0 {
+		fmt.Println("Nothing planned.")
+		return pendingSession{}, false
+	}
+	for i, s := range sessions {
+		fmt.Printf("%d - %s\n", i+1, s)
+	}
+	fmt.Print("Choose a session: ")
+	n, err := strconv.Atoi(readLine(reader))
+	if err != nil || n < 1 || n > len(sessions) {
+		fmt.Println("Invalid choice.")
+		return pendingSession{}, false
+	}
+	return sessions[n-1], true
+}
+
+// startSession starts a planned session or workout, or resumes one already
+// started, and lets the user fill in the results.
+func startSession(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	s, ok := choosePending(db, reader)
+	if !ok {
+		return
+	}
+	workout := s.workout
+	var err error
+	switch {
+	case s.planned.ID != 0:
+		workout, err = backend.StartPlannedSession(db, s.planned.ID, cliActor)
+	case workout.Status == models.StatusPlanned:
+		workout, err = backend.StartWorkout(db, workout.ID, workout.Version, cliActor)
+	}
+	if err != nil {
+		fmt.Printf("Failed to start session: %v\n", err)
+		return
+	}
+	fmt.Printf("Workout %d started at %s.\n", workout.ID, workout.TimeIn)
+	workout = addWarmUps(db, reader, workout)
+
+	fmt.Print("Fill in the results now? (Y/n): ")
+	if readLine(reader) == "n" {
+		fmt.Println("Resume it from this menu when you are done.")
+		return
+	}
+	fillResults(db, reader, workout)
+}
+
+// addWarmUps offers to insert warm-up ramps before exercises of a started
+// workout, using the CLI user's equipment and their default bar. It returns
+// the workout as it now is.
+func addWarmUps(db *sql.DB, reader *bufio.Reader, workout models.Workout) models.Workout {
+	for {
+		exercise := promptDefault(reader, "Add warm-ups before which exercise? (Enter for none)", "")
+		if exercise == "" {
+			return workout
+		}
+		eq := cliEquipment(db)
+		if eq == nil {
+			return workout
+		}
+		settings := cliSettings(db)
+		req := models.WarmUpRequest{Exercise: exercise}
+		req.Implement = promptDefault(reader, "Implement (barbell or dumbbell)", models.ImplementBarbell)
+		req.Weight = promptFloat(reader, fmt.Sprintf("Working weight (%s, 0 for its first set)", settings.Units), 0)
+
+		updated, err := backend.AddWarmUp(db, workout.ID, workout.Version, *eq, req, settings.Units, cliActor)
+		if err != nil {
+			fmt.Printf("Skipping warm-ups: %v\n", err)
+			continue
+		}
+		workout = updated
+		fmt.Printf("Warm-ups added before %s.\n", exercise)
+	}
+}
+
+// fillResults walks through the lifts of a started workout, offering what
+// was done so far or else the target as each default, then completes it.
+func fillResults(db *sql.DB, reader *bufio.Reader, workout models.Workout) {
+	workout = backend.DraftInUnits(workout, cliSettings(db))
+	workout.TimeOut = promptDefault(reader, "Time out (HH:MM)", time.Now().Format(models.TimeLayout))
+	workout.MoodIn = promptDefault(reader, "Mood in", workout.MoodIn)
+	workout.MoodOut = promptDefault(reader, "Mood out", workout.MoodOut)
+
+	var lifts []models.Lift
+	for i := range workout.Lifts {
+		lift := backend.GetLift(workout, i)
+		if lift.Sets == 0 {
+			lift.Weight, lift.Reps, lift.Sets = lift.TargetWeight, lift.TargetReps, lift.TargetSets
+		}
+		if lift.WarmUp {
+			fmt.Printf("%s (warm-up):\n", lift.Name)
+		} else {
+			fmt.Printf("%s:\n", lift.Name)
+		}
+		lift.Weight = promptFloat(reader, fmt.Sprintf("  Weight (%s)", workout.Units), lift.Weight)
+		lift.Reps = promptInt(reader, "  Reps", lift.Reps)
+		lift.Sets = promptInt(reader, "  Sets (0 if not done)", lift.Sets)
+		if lift.Sets == 0 {
+			lift.Reps = 0
+		}
+		lift.RPE = promptFloat(reader, "  RPE (0 for none)", lift.RPE)
+		lifts = append(lifts, lift)
+	}
+	for {
+		lift := models.Lift{Name: promptDefault(reader, "Another exercise (empty to finish)", "")}
+		if lift.Name == "" {
+			break
+		}
+		lift.Weight = promptFloat(reader, fmt.Sprintf("  Weight (%s)", workout.Units), 0)
+		lift.Reps = promptInt(reader, "  Reps", 0)
+		lift.Sets = promptInt(reader, "  Sets", 0)
+		lifts = append(lifts, lift)
+	}
+	workout.Lifts, workout.Weight, workout.Reps, workout.Sets = nil, nil, nil, nil
+	workout.RPE, workout.RIR, workout.Tempo, workout.Rest = nil, nil, nil, nil
+	workout.TargetWeight, workout.TargetReps, workout.TargetSets, workout.WarmUp = nil, nil, nil, nil
+	for _, lift := range lifts {
+		backend.AppendLift(&workout, lift)
+	}
+
+	completed, err := backend.CompleteWorkout(db, workout.ID, workout.Version, workout, workout.Units, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to complete workout: %v\n", err)
+		return
+	}
+	fmt.Println("Workout completed!")
+	printEarnedBadges(db, completed.ID)
+}
+
+func skipSession(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	s, ok := choosePending(db, reader)
+	if !ok {
+		return
+	}
+	var err error
+	if s.planned.ID != 0 {
+		_, err = backend.SkipPlannedSession(db, s.planned.ID, cliActor)
+	} else {
+		_, err = backend.SkipWorkout(db, s.workout.ID, s.workout.Version, cliActor)
+	}
+	if err != nil {
+		fmt.Printf("Failed to skip session: %v\n", err)
+		return
+	}
+	fmt.Println("Session skipped.")
+}
+
+// showAdherence compares the CLI user's plans over the last four weeks with
+// what they did.
+func showAdherence(db *sql.DB) {
+	a, err := backend.GetAdherence(db, cliActor.Name, "", "")
+	if err != nil {
+		fmt.Printf("Failed to compute adherence: %v\n", err)
+		return
+	}
+	settings := cliSettings(db)
+
+	fmt.Printf("%s to %s: %d planned, %d completed, %d skipped, %d missed (%.0f%%)\n",
+		a.From, a.To, a.Planned, a.Completed, a.Skipped, a.Missed, a.SessionAdherence)
+	if a.InProgress+a.Upcoming > 0 {
+		fmt.Printf("Still to do: %d in progress, %d upcoming\n", a.InProgress, a.Upcoming)
+	}
+	if a.Unplanned > 0 {
+		fmt.Printf("Unplanned sessions: %d\n", a.Unplanned)
+	}
+	if a.TargetSets > 0 {
+		fmt.Printf("Sets: %d of %d (%.0f%%)   Volume: %s of %s (%.0f%%)\n", a.ActualSets, a.TargetSets, a.SetAdherence,
+			formatWeight(a.ActualTonnage, settings), formatWeight(a.TargetTonnage, settings), a.VolumeAdherence)
+	}
+	for _, e := range a.Exercises {
+		fmt.Printf("    %-20s %d/%d sets  %s of %s\n", e.Exercise, e.ActualSets, e.TargetSets,
+			formatWeight(e.ActualTonnage, settings), formatWeight(e.TargetTonnage, settings))
+	}
+}
+
+func planProgramWorkout(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	program, err := backend.GetActiveProgram(db)
+	if err != nil {
+		fmt.Printf("Failed to fetch the active program: %v\n", err)
+		return
+	}
+	p := models.PlannedSession{Athlete: cliActor.Name, ProgramID: program.ID}
+	p.Date = promptDefault(reader, "Date (DD/MM/YYYY)", time.Now().AddDate(0, 0, 1).Format(models.DateLayout))
+	p.TimeIn = promptDefault(reader, "Time in (HH:MM, empty for all day)", "")
+	if p.TimeIn != "" {
+		p.TimeOut = promptDefault(reader, "Time out (HH:MM)", "")
+	}
+
+	id, err := backend.CreatePlannedSession(db, p, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to plan session: %v\n", err)
+		return
+	}
+	fmt.Printf("Planned session %d from %s.\n", id, program.Name)
+	recordUndo(fmt.Sprintf("plan session %d", id), func(db *sql.DB) error {
+		return backend.DeletePlannedSession(db, id, cliActor)
+	})
+}
+
+func completePlanned(db *sql.DB) {
+	fmt.Print("Enter planned session ID: ")
+	var id int
+	fmt.Scan(&id)
+	fmt.Print("Enter the ID of the workout that completed it: ")
+	var workoutID int
+	fmt.Scan(&workoutID)
+
+	p, err := backend.CompletePlannedSession(db, id, workoutID, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to complete planned session: %v\n", err)
+		return
+	}
+	fmt.Printf("%s on %s completed.\n", p.Title, p.Date)
+}
+
+// importCalendar plans the events of an iCalendar file for the CLI user.
+func importCalendar(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	fmt.Print("Path to iCalendar (.ics) file: ")
+	path := readLine(reader)
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Printf("Failed to open file: %v\n", err)
+		return
+	}
+	events, err := ical.Parse(f)
+	f.Close()
+	if err != nil {
+		fmt.Printf("Failed to read calendar: %v\n", err)
+		return
+	}
+
+	sessions, skipped := ical.PlannedSessions(events, cliActor.Name)
+	result, err := backend.ImportPlannedSessions(db, sessions, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to import calendar: %v\n", err)
+		return
+	}
+	fmt.Printf("Planned %d new sessions, updated %d and skipped %d.\n", result.Created, result.Updated, result.Skipped+skipped)
+}
+
+// exportCalendar writes the CLI user's workouts and planned sessions to an
+// iCalendar file.
+func exportCalendar(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+	path := promptDefault(reader, "File", "training.ics")
+
+	workouts, err := backend.GetAthleteWorkouts(db, cliActor.Name)
+	if err != nil {
+		fmt.Printf("Failed to fetch workouts: %v\n", err)
+		return
+	}
+	planned, err := backend.GetPlannedSessions(db, cliActor.Name, "", "", true)
+	if err != nil {
+		fmt.Printf("Failed to fetch planned sessions: %v\n", err)
+		return
+	}
+	records, err := backend.RecordLifts(db, workouts)
+	if err != nil {
+		fmt.Printf("Failed to find personal records: %v\n", err)
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Failed to create file: %v\n", err)
+		return
+	}
+	cal := ical.Feed(cliActor.Name, workouts, planned, render.Options{Units: cliSettings(db).Units, Records: records})
+	err = ical.Write(f, cal)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Printf("Failed to write %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("Exported %d events to %s\n", len(cal.Events), path)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"fitness-dev/backend"
+)
+
+func managePrograms(db *sql.DB) {
+	fmt.Scanln()
+	for {
+		fmt.Println("Programs")
+		fmt.Println("1 - List Programs")
+		fmt.Println("2 - Show Next Workout")
+		fmt.Println("3 - Start Next Workout")
+		fmt.Println("4 - Activate Program")
+		fmt.Println("5 - Back")
+		fmt.Print("Please enter a number to continue: ")
+
+		var userinput int
+		fmt.Scan(&userinput)
+
+		switch userinput {
+		case 1:
+			listPrograms(db)
+		case 2:
+			showNextWorkout(db)
+		case 3:
+			startNextWorkout(db)
+		case 4:
+			activateProgram(db)
+		case 5:
+			return
+		default:
+			fmt.Println("Invalid option. Please try again.")
+		}
+	}
+}
+
+func listPrograms(db *sql.DB) {
+	programs, err := backend.GetPrograms(db)
+	if err != nil {
+		fmt.Printf("Failed to fetch programs: %v\n", err)
+		return
+	}
+
+	if len(programs) == 0 {
+		fmt.Println("No programs yet.")
+		return
+	}
+	for _, p := range programs {
+		active := ""
+		if p.Active {
+			active = " (active)"
+		}
+		fmt.Printf("%d: %s%s, started %s, templates %v\n", p.ID, p.Name, active, p.StartDate, p.TemplateIDs)
+		for _, r := range p.Rules {
+			fmt.Printf("    %s: %s\n", r.Exercise, r.Kind)
+		}
+	}
+}
+
+func showNextWorkout(db *sql.DB) {
+	settings := cliSettings(db)
+	prescription, err := backend.NextActiveWorkout(db, cliActor.Name, settings)
+	if err != nil {
+		fmt.Printf("Failed to work out the next workout: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Next: %s\n", prescription.Template)
+	w := backend.DraftInUnits(prescription.Workout, settings)
+	for i, lift := range w.Lifts {
+		fmt.Printf("    %s: %.2f%s x %d x %d\n", lift, w.Weight[i], w.Units, w.Reps[i], w.Sets[i])
+	}
+	for _, note := range prescription.Notes {
+		fmt.Printf("  - %s\n", note)
+	}
+}
+
+func startNextWorkout(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	prescription, err := backend.NextActiveWorkout(db, cliActor.Name, cliSettings(db))
+	if err != nil {
+		fmt.Printf("Failed to work out the next workout: %v\n", err)
+		return
+	}
+	fmt.Printf("Starting %s\n", prescription.Template)
+	completeDraft(db, reader, prescription.Workout)
+}
+
+func activateProgram(db *sql.DB) {
+	fmt.Print("Enter program ID to activate: ")
+	var id int
+	fmt.Scan(&id)
+
+	if err := backend.ActivateProgram(db, id, cliActor); err != nil {
+		fmt.Printf("Failed to activate program: %v\n", err)
+		return
+	}
+	fmt.Println("Program activated.")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+	"fitness-dev/render"
+)
+
+// monthlyReport writes the CLI user's report for a month to an HTML or PDF
+// file.
+func monthlyReport(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	athlete := promptDefault(reader, "Athlete", cliActor.Name)
+	month := promptDefault(reader, "Month (MM/YYYY)", time.Now().Format(models.MonthLayout))
+	format := strings.ToLower(promptDefault(reader, "Format (html, pdf)", "html"))
+	write := render.ReportHTML
+	switch format {
+	case "html":
+	case "pdf":
+		write = render.ReportPDF
+	default:
+		fmt.Println("Format must be html or pdf.")
+		return
+	}
+
+	report, err := backend.GetMonthlyReport(db, athlete, month)
+	if err != nil {
+		fmt.Printf("Failed to build report: %v\n", err)
+		return
+	}
+	path := promptDefault(reader, "File", render.ReportFilename(report, format))
+
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Failed to create file: %v\n", err)
+		return
+	}
+	err = write(f, report, render.Options{Units: cliSettings(db).Units})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Printf("Failed to write %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("Wrote the %s report for %s to %s\n", render.MonthTitle(report.Month), report.Athlete, path)
+}
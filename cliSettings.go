@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+// cliSettings returns the CLI user's preferences, falling back to the
+// defaults if they cannot be read.
+func cliSettings(db *sql.DB) models.Settings {
+	settings, err := backend.GetSettings(db, cliActor.Name)
+	if err != nil {
+		fmt.Printf("Failed to read settings, using kg: %v\n", err)
+		return models.Settings{User: cliActor.Name, Units: models.UnitKilogram, SmallestPlate: backend.DefaultSmallestPlate[models.UnitKilogram]}
+	}
+	return settings
+}
+
+// formatWeight shows a stored weight (kg) in the user's unit.
+func formatWeight(kg float64, settings models.Settings) string {
+	return fmt.Sprintf("%.2f%s", backend.ConvertWeight(kg, models.UnitKilogram, settings.Units), settings.Units)
+}
+
+func editSettings(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	current := cliSettings(db)
+	settings := current
+	settings.Units = backend.NormalizeUnits(promptDefault(reader, "Units (kg or lb)", current.Units))
+	if settings.Units != models.UnitKilogram && settings.Units != models.UnitPound {
+		fmt.Println("Units must be kg or lb.")
+		return
+	}
+	plate := backend.DefaultSmallestPlate[settings.Units]
+	if settings.Units == current.Units {
+		plate = current.SmallestPlate
+	}
+	settings.SmallestPlate = promptFloat(reader, fmt.Sprintf("Smallest plate (%s)", settings.Units), plate)
+	optOut := "n"
+	if current.LeaderboardOptOut {
+		optOut = "y"
+	}
+	settings.LeaderboardOptOut = strings.HasPrefix(strings.ToLower(promptDefault(reader, "Hide me from group leaderboards? (y/n)", optOut)), "y")
+
+	if err := backend.SaveSettings(db, settings, cliActor); err != nil {
+		fmt.Printf("Failed to save settings: %v\n", err)
+		return
+	}
+	fmt.Printf("Weights are now shown in %s and rounded to %g%s steps.\n", settings.Units, backend.LoadableIncrement(settings), settings.Units)
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+	"fitness-dev/render"
+
+	"golang.org/x/term"
+)
+
+// Sizes of the CLI stats, in terminal cells unless noted.
+const (
+	defaultTerminalWidth = 80
+	chartHeight          = 12
+	statsWeeks           = 12 // weeks of tonnage shown
+	statsMonths          = 6  // months of e1RM history shown
+	exerciseColumn       = 20
+)
+
+var (
+	sparkTicks = []rune("▁▂▃▄▅▆▇█")
+	barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+)
+
+// terminalWidth returns the width of the terminal, or $COLUMNS, or 80 when
+// the output is not a terminal.
+func terminalWidth() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return defaultTerminalWidth
+}
+
+func showStats(db *sql.DB) {
+	fmt.Scanln()
+	for {
+		fmt.Println("Stats")
+		fmt.Println("1 - Exercise Summary")
+		fmt.Println("2 - e1RM Chart")
+		fmt.Println("3 - Weekly Tonnage")
+		fmt.Println("4 - Monthly Report")
+		fmt.Println("5 - Back")
+		fmt.Print("Please enter a number to continue: ")
+
+		var userinput int
+		fmt.Scan(&userinput)
+
+		switch userinput {
+		case 1:
+			exerciseSummary(db)
+		case 2:
+			e1rmChart(db)
+		case 3:
+			tonnageChart(db)
+		case 4:
+			monthlyReport(db)
+		case 5:
+			return
+		default:
+			fmt.Println("Invalid option. Please try again.")
+		}
+	}
+}
+
+// statsSince is the first day of the e1RM history shown.
+func statsSince() time.Time {
+	return time.Now().AddDate(0, -statsMonths, 0)
+}
+
+// exerciseSummary lists every exercise logged recently with a sparkline of
+// its e1RM per session, as many sessions as the terminal has room for.
+func exerciseSummary(db *sql.DB) {
+	settings := cliSettings(db)
+	trends, err := backend.ExerciseTrends(db, cliActor.Name, statsSince())
+	if err != nil {
+		fmt.Printf("Failed to fetch exercises: %v\n", err)
+		return
+	}
+	if len(trends) == 0 {
+		fmt.Printf("No lifts logged in the last %d months.\n", statsMonths)
+		return
+	}
+
+	header := fmt.Sprintf("%-*s %8s %11s %11s  ", exerciseColumn, "Exercise", "Sessions", "Best e1RM", "Latest")
+	room := max(terminalWidth()-utf8.RuneCountInString(header), 1)
+	fmt.Println(header + "Trend")
+	for _, t := range trends {
+		values := make([]float64, len(t.Points))
+		for i, p := range t.Points {
+			values[i] = p.Value
+		}
+		if len(values) > room {
+			values = values[len(values)-room:]
+		}
+		fmt.Printf("%-*s %8d %11s %11s  %s\n", exerciseColumn, render.Truncate(t.Exercise, exerciseColumn), t.Sessions,
+			formatWeight(t.Best, settings), formatWeight(t.Latest, settings), sparkline(values))
+	}
+}
+
+func e1rmChart(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	exercise := promptDefault(reader, "Exercise", "")
+	settings := cliSettings(db)
+	points, err := backend.E1RMHistory(db, cliActor.Name, exercise, statsSince())
+	if err != nil {
+		fmt.Printf("Failed to fetch e1RM history: %v\n", err)
+		return
+	}
+	if len(points) == 0 {
+		fmt.Printf("No %s logged in the last %d months.\n", exercise, statsMonths)
+		return
+	}
+
+	for i := range points {
+		points[i].Value = backend.ConvertWeight(points[i].Value, models.UnitKilogram, settings.Units)
+	}
+	fmt.Printf("%s estimated 1RM (%s)\n", exercise, settings.Units)
+	for _, line := range lineChart(points, terminalWidth(), chartHeight) {
+		fmt.Println(line)
+	}
+}
+
+func tonnageChart(db *sql.DB) {
+	settings := cliSettings(db)
+	weeks, err := backend.WeeklyTonnage(db, cliActor.Name, statsWeeks)
+	if err != nil {
+		fmt.Printf("Failed to fetch weekly tonnage: %v\n", err)
+		return
+	}
+
+	for i := range weeks {
+		weeks[i].Value = backend.ConvertWeight(weeks[i].Value, models.UnitKilogram, settings.Units)
+	}
+	fmt.Printf("Tonnage per week (%s), weeks starting on Monday\n", settings.Units)
+	for _, line := range barChart(weeks, terminalWidth()) {
+		fmt.Println(line)
+	}
+}
+
+// sparkline draws values as a row of block heights, scaled between their
+// lowest and highest value.
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := len(sparkTicks) / 2
+		if hi > lo {
+			i = int(math.Round((v - lo) / (hi - lo) * float64(len(sparkTicks)-1)))
+		}
+		b.WriteRune(sparkTicks[i])
+	}
+	return b.String()
+}
+
+// lineChart plots dated values in width columns, y axis labels included,
+// and height rows plus two for the x axis. Points are placed by date and the
+// columns between them are interpolated.
+func lineChart(points []models.SeriesPoint, width, height int) []string {
+	values := make([]float64, len(points))
+	days := make([]float64, len(points))
+	first, _ := time.Parse(models.DateLayout, points[0].Date)
+	for i, p := range points {
+		values[i] = p.Value
+		day, _ := time.Parse(models.DateLayout, p.Date)
+		days[i] = day.Sub(first).Hours() / 24
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	if hi == lo {
+		lo, hi = lo-1, hi+1
+	}
+
+	labels := []string{fmt.Sprintf("%.1f", hi), fmt.Sprintf("%.1f", (lo+hi)/2), fmt.Sprintf("%.1f", lo)}
+	labelWidth := max(len(labels[0]), len(labels[2]))
+	plotWidth := max(width-labelWidth-2, 2)
+
+	// Each column's value, and whether a point falls on it
+	columns := make([]float64, plotWidth)
+	exact := make([]bool, plotWidth)
+	span := days[len(days)-1]
+	for i, v := range values {
+		c := 0
+		if span > 0 {
+			c = int(math.Round(days[i] / span * float64(plotWidth-1)))
+		}
+		if !exact[c] || v > columns[c] {
+			columns[c] = v
+		}
+		exact[c] = true
+	}
+	last := -1
+	for c := range columns {
+		if !exact[c] {
+			continue
+		}
+		for between := last + 1; last >= 0 && between < c; between++ {
+			columns[between] = columns[last] + (columns[c]-columns[last])*float64(between-last)/float64(c-last)
+		}
+		last = c
+	}
+
+	grid := make([][]rune, height)
+	for r := range grid {
+		grid[r] = []rune(strings.Repeat(" ", plotWidth))
+	}
+	for c := 0; c <= last; c++ {
+		row := height - 1 - int(math.Round((columns[c]-lo)/(hi-lo)*float64(height-1)))
+		grid[row][c] = '·'
+		if exact[c] {
+			grid[row][c] = '●'
+		}
+	}
+
+	lines := make([]string, 0, height+2)
+	for r, row := range grid {
+		label, tick := "", '│'
+		switch r {
+		case 0:
+			label, tick = labels[0], '┤'
+		case (height - 1) / 2:
+			label, tick = labels[1], '┤'
+		case height - 1:
+			label, tick = labels[2], '┤'
+		}
+		lines = append(lines, fmt.Sprintf("%*s %c%s", labelWidth, label, tick, strings.TrimRight(string(row), " ")))
+	}
+	lines = append(lines, strings.Repeat(" ", labelWidth+1)+"└"+strings.Repeat("─", plotWidth))
+	end := points[len(points)-1].Date
+	gap := max(plotWidth-len(points[0].Date)-len(end), 1)
+	if len(points) == 1 {
+		end, gap = "", 0
+	}
+	lines = append(lines, strings.Repeat(" ", labelWidth+2)+points[0].Date+strings.Repeat(" ", gap)+end)
+	return lines
+}
+
+// barChart draws one horizontal bar per dated value, scaled so the largest
+// fills the width left after the date and value, in eighths of a cell.
+func barChart(points []models.SeriesPoint, width int) []string {
+	var hi float64
+	valueWidth := 1
+	for _, p := range points {
+		hi = max(hi, p.Value)
+		valueWidth = max(valueWidth, len(fmt.Sprintf("%.0f", p.Value)))
+	}
+	barWidth := max(width-len(models.DateLayout)-valueWidth-4, 1)
+
+	lines := make([]string, len(points))
+	for i, p := range points {
+		var bar string
+		if hi > 0 {
+			eighths := int(math.Round(p.Value / hi * float64(barWidth*8)))
+			bar = strings.Repeat("█", eighths/8) + barEighths[eighths%8]
+		}
+		lines[i] = fmt.Sprintf("%s │%-*s %*.0f", p.Date, barWidth, bar, valueWidth, p.Value)
+	}
+	return lines
+}
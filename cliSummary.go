@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+// summaryWeeks is how many weeks the CLI weekly summary shows.
+const summaryWeeks = 4
+
+func weeklySummary(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	weeks, err := backend.WeeklyStats(db, cliActor.Name, summaryWeeks)
+	if err != nil {
+		fmt.Printf("Failed to fetch weekly stats: %v\n", err)
+		return
+	}
+
+	settings := cliSettings(db)
+	fmt.Println("Week of       Sessions   Tonnage  Intensity  Mood  Cardio")
+	for _, w := range weeks {
+		tonnage := backend.ConvertWeight(w.Tonnage, models.UnitKilogram, settings.Units)
+		fmt.Printf("%-12s  %8d  %7.0f%s  %8.0f%%  %4.1f", w.WeekStart, w.Sessions, tonnage, settings.Units, w.Intensity, w.Mood)
+		if w.CardioSessions > 0 {
+			fmt.Printf("  %d x, %.1fkm, %.0fmin", w.CardioSessions, w.CardioDistance, w.CardioMinutes)
+		}
+		fmt.Println()
+	}
+
+	analysis, err := backend.AnalyzeCurrentBlock(db, cliActor.Name)
+	var notFound *backend.NotFoundError
+	switch {
+	case errors.As(err, &notFound):
+		fmt.Println("No training block covers today.")
+	case err != nil:
+		fmt.Printf("Failed to analyse the current block: %v\n", err)
+	default:
+		b := analysis.Block
+		fmt.Printf("\nBlock: %s (%s, %s - %s)\n", b.Name, b.Goal, b.StartDate, b.EndDate)
+		for _, r := range analysis.Recommendations {
+			fmt.Printf("  - %s: %s\n", r.Kind, r.Reason)
+		}
+	}
+
+	fmt.Println("Press Enter to continue...")
+	reader.ReadString('\n')
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+func manageTemplates(db *sql.DB) {
+	fmt.Scanln()
+	for {
+		fmt.Println("Templates")
+		fmt.Println("1 - List Templates")
+		fmt.Println("2 - Create Template From Workout")
+		fmt.Println("3 - Start Workout From Template")
+		fmt.Println("4 - Repeat Last Session With Exercise")
+		fmt.Println("5 - Delete Template")
+		fmt.Println("6 - Back")
+		fmt.Print("Please enter a number to continue: ")
+
+		var userinput int
+		fmt.Scan(&userinput)
+
+		switch userinput {
+		case 1:
+			listTemplates(db)
+		case 2:
+			createTemplateFromWorkout(db)
+		case 3:
+			startFromTemplate(db)
+		case 4:
+			repeatLastSession(db)
+		case 5:
+			deleteTemplate(db)
+		case 6:
+			return
+		default:
+			fmt.Println("Invalid option. Please try again.")
+		}
+	}
+}
+
+func listTemplates(db *sql.DB) {
+	templates, err := backend.GetTemplates(db)
+	if err != nil {
+		fmt.Printf("Failed to fetch templates: %v\n", err)
+		return
+	}
+
+	if len(templates) == 0 {
+		fmt.Println("No templates yet.")
+		return
+	}
+	settings := cliSettings(db)
+	for _, t := range templates {
+		fmt.Printf("%d: %s\n", t.ID, t.Name)
+		for _, e := range t.Exercises {
+			fmt.Printf("    %s: %s x %d x %d\n", e.Name, formatWeight(e.Weight, settings), e.Reps, e.Sets)
+		}
+	}
+}
+
+func createTemplateFromWorkout(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	fmt.Print("Enter workout ID: ")
+	workoutID, err := strconv.Atoi(readLine(reader))
+	if err != nil {
+		fmt.Println("Invalid workout ID.")
+		return
+	}
+	fmt.Print("Enter template name: ")
+	name := readLine(reader)
+
+	id, err := backend.CreateTemplateFromWorkout(db, workoutID, name, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to create template: %v\n", err)
+		return
+	}
+	fmt.Printf("Template %d created.\n", id)
+}
+
+func startFromTemplate(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	fmt.Print("Enter template ID: ")
+	id, err := strconv.Atoi(readLine(reader))
+	if err != nil {
+		fmt.Println("Invalid template ID.")
+		return
+	}
+	fmt.Print("Pre-fill weights from last performance? (Y/n): ")
+	prefill := readLine(reader) != "n"
+
+	draft, err := backend.WorkoutFromTemplate(db, id, prefill)
+	if err != nil {
+		fmt.Printf("Failed to start workout: %v\n", err)
+		return
+	}
+	completeDraft(db, reader, draft)
+}
+
+func repeatLastSession(db *sql.DB) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+
+	fmt.Print("Enter exercise name: ")
+	draft, err := backend.RepeatLastSession(db, readLine(reader))
+	if err != nil {
+		fmt.Printf("Failed to find last session: %v\n", err)
+		return
+	}
+	completeDraft(db, reader, draft)
+}
+
+func deleteTemplate(db *sql.DB) {
+	fmt.Print("Enter template ID to delete: ")
+	var id int
+	fmt.Scan(&id)
+
+	if err := backend.DeleteTemplate(db, id, cliActor); err != nil {
+		fmt.Printf("Failed to delete template: %v\n", err)
+		return
+	}
+	fmt.Println("Template deleted.")
+}
+
+// completeDraft walks through a pre-filled workout, letting the user accept
+// each value with Enter or type a new one, then saves it.
+func completeDraft(db *sql.DB, reader *bufio.Reader, draft models.Workout) {
+	draft = backend.DraftInUnits(draft, cliSettings(db))
+	draft.Date = promptDefault(reader, "Date (DD/MM/YYYY)", draft.Date)
+	draft.TimeIn = promptDefault(reader, "Time in (HH:MM)", draft.TimeIn)
+	draft.TimeOut = promptDefault(reader, "Time out (HH:MM)", draft.TimeOut)
+	draft.MoodIn = promptDefault(reader, "Mood in", draft.MoodIn)
+	draft.MoodOut = promptDefault(reader, "Mood out", draft.MoodOut)
+
+	var lifts []models.Lift
+	for i := range draft.Lifts {
+		lift := backend.GetLift(draft, i)
+		if lift.WarmUp {
+			fmt.Printf("%s (warm-up):\n", lift.Name)
+		} else {
+			fmt.Printf("%s:\n", lift.Name)
+		}
+		lift.Weight = promptFloat(reader, fmt.Sprintf("  Weight (%s)", draft.Units), lift.Weight)
+		lift.Reps = promptInt(reader, "  Reps", lift.Reps)
+		lift.Sets = promptInt(reader, "  Sets", lift.Sets)
+		lift.RPE = promptFloat(reader, "  RPE (0 for none)", lift.RPE)
+		lifts = append(lifts, lift)
+	}
+	draft.Lifts, draft.Weight, draft.Reps, draft.Sets = nil, nil, nil, nil
+	draft.RPE, draft.RIR, draft.Tempo, draft.Rest, draft.WarmUp = nil, nil, nil, nil, nil
+	for _, lift := range lifts {
+		backend.AppendLift(&draft, lift)
+	}
+
+	id, err := backend.InsertWorkout(db, draft, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to create workout: %v\n", err)
+		return
+	}
+
+	fmt.Println("Workout created successfully!")
+	printEarnedBadges(db, id)
+	recordUndo(fmt.Sprintf("add workout on %s", draft.Date), func(db *sql.DB) error {
+		return backend.DeleteWorkout(db, id, cliActor)
+	})
+}
+
+func promptDefault(reader *bufio.Reader, label, def string) string {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", label, def)
+	} else {
+		fmt.Printf("%s: ", label)
+	}
+	if s := readLine(reader); s != "" {
+		return s
+	}
+	return def
+}
+
+func promptFloat(reader *bufio.Reader, label string, def float64) float64 {
+	s := promptDefault(reader, label, strconv.FormatFloat(def, 'f', -1, 64))
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v
+	}
+	return def
+}
+
+func promptInt(reader *bufio.Reader, label string, def int) int {
+	s := promptDefault(reader, label, strconv.Itoa(def))
+	if v, err := strconv.Atoi(s); err == nil {
+		return v
+	}
+	return def
+}
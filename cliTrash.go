@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"fitness-dev/backend"
+)
+
+func deleteWorkout(db *sql.DB) {
+	fmt.Print("Enter workout ID to delete: ")
+	var id int
+	fmt.Scan(&id)
+
+	if err := backend.DeleteWorkout(db, id, cliActor); err != nil {
+		fmt.Printf("Failed to delete workout: %v\n", err)
+		return
+	}
+
+	fmt.Println("Workout moved to trash.")
+	recordUndo(fmt.Sprintf("delete workout %d", id), func(db *sql.DB) error {
+		return backend.RestoreWorkout(db, id, cliActor)
+	})
+}
+
+func manageTrash(db *sql.DB) {
+	fmt.Scanln()
+	for {
+		fmt.Println("Trash")
+		fmt.Printf("Deleted workouts are purged after %v\n", backend.TrashRetention())
+		fmt.Println("1 - List Trash")
+		fmt.Println("2 - Restore Workout")
+		fmt.Println("3 - Permanently Delete Workout")
+		fmt.Println("4 - Empty Trash")
+		fmt.Println("5 - Back")
+		fmt.Print("Please enter a number to continue: ")
+
+		var userinput int
+		fmt.Scan(&userinput)
+
+		switch userinput {
+		case 1:
+			listTrash(db)
+		case 2:
+			restoreWorkout(db)
+		case 3:
+			purgeWorkout(db)
+		case 4:
+			emptyTrash(db)
+		case 5:
+			return
+		default:
+			fmt.Println("Invalid option. Please try again.")
+		}
+	}
+}
+
+func listTrash(db *sql.DB) {
+	workouts, err := backend.ListTrash(db)
+	if err != nil {
+		fmt.Printf("Failed to fetch trash: %v\n", err)
+		return
+	}
+
+	if len(workouts) == 0 {
+		fmt.Println("Trash is empty.")
+		return
+	}
+	for _, workout := range workouts {
+		fmt.Printf("%d: %s %s-%s (%d lifts), deleted %s\n", workout.ID, workout.Date, workout.TimeIn, workout.TimeOut, len(workout.Lifts), workout.DeletedAt)
+	}
+}
+
+func restoreWorkout(db *sql.DB) {
+	fmt.Print("Enter workout ID to restore: ")
+	var id int
+	fmt.Scan(&id)
+
+	if err := backend.RestoreWorkout(db, id, cliActor); err != nil {
+		fmt.Printf("Failed to restore workout: %v\n", err)
+		return
+	}
+
+	fmt.Println("Workout restored.")
+	recordUndo(fmt.Sprintf("restore workout %d", id), func(db *sql.DB) error {
+		return backend.DeleteWorkout(db, id, cliActor)
+	})
+}
+
+func purgeWorkout(db *sql.DB) {
+	fmt.Print("Enter workout ID to permanently delete: ")
+	var id int
+	fmt.Scan(&id)
+
+	if err := backend.PurgeWorkout(db, id, cliActor); err != nil {
+		fmt.Printf("Failed to delete workout: %v\n", err)
+		return
+	}
+
+	fmt.Println("Workout permanently deleted.")
+	clearUndo()
+}
+
+func emptyTrash(db *sql.DB) {
+	fmt.Print("Permanently delete everything in the trash? (y/n): ")
+	var confirm string
+	fmt.Scan(&confirm)
+	if confirm != "y" {
+		return
+	}
+
+	n, err := backend.EmptyTrash(db, cliActor)
+	if err != nil {
+		fmt.Printf("Failed to empty trash: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Permanently deleted %d workout(s).\n", n)
+	clearUndo()
+}
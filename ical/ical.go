@@ -0,0 +1,284 @@
+// Package ical reads and writes iCalendar files (RFC 5545): the events of a
+// calendar, which is all that is needed to exchange training sessions with
+// calendar apps.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts of DATE-TIME and DATE values.
+const (
+	dateTimeLayout = "20060102T150405"
+	dateLayout     = "20060102"
+)
+
+// Event is a VEVENT. Start and End are in the zone they were given in; times
+// without a zone ("floating" times) are read and written in time.Local.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time // exclusive; the day after Start for one-day all-day events
+	AllDay      bool      // Start and End are dates
+	Floating    bool      // written without a zone, so shown at the same clock time everywhere
+	Status      string    // TENTATIVE, CONFIRMED or CANCELLED, optional
+}
+
+// Calendar is a VCALENDAR of events.
+type Calendar struct {
+	Name   string // shown by calendar apps that subscribe to it
+	Events []Event
+}
+
+// Write writes cal as an iCalendar file, with CRLF line endings and lines
+// folded at 75 octets.
+func Write(w io.Writer, cal Calendar) error {
+	out := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(out, name+":"+value)
+	}
+	stamp := time.Now().UTC().Format(dateTimeLayout) + "Z"
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//fitness-dev//Training Calendar//EN")
+	line("CALSCALE", "GREGORIAN")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escape(cal.Name))
+	}
+	for _, e := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(e.UID))
+		line("DTSTAMP", stamp)
+		switch {
+		case e.AllDay:
+			line("DTSTART;VALUE=DATE", e.Start.Format(dateLayout))
+			line("DTEND;VALUE=DATE", e.End.Format(dateLayout))
+		case e.Floating:
+			line("DTSTART", e.Start.Format(dateTimeLayout))
+			line("DTEND", e.End.Format(dateTimeLayout))
+		default:
+			line("DTSTART", e.Start.UTC().Format(dateTimeLayout)+"Z")
+			line("DTEND", e.End.UTC().Format(dateTimeLayout)+"Z")
+		}
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Status != "" {
+			line("STATUS", e.Status)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return out.Flush()
+}
+
+// writeFolded writes a content line, breaking it into 75 octet lines that
+// continue with a space, without splitting a UTF-8 character.
+func writeFolded(w *bufio.Writer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = 74 // the leading space counts
+	}
+	w.WriteString(s + "\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+var unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
+
+// property is one unfolded content line: NAME;PARAM=VALUE:value.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+func parseProperty(line string) (property, bool) {
+	// The value starts at the first colon outside a quoted parameter value
+	quoted, colon := false, -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	p := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return p, true
+}
+
+// Parse reads the events of an iCalendar file. Events without a start are
+// skipped; an event without an end lasts an hour, or a day if it is all-day.
+func Parse(r io.Reader) ([]Event, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %v", err)
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.Contains(strings.ToUpper(text), "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("not an iCalendar file")
+	}
+	// Unfold continuation lines
+	text = strings.NewReplacer("\n ", "", "\n\t", "").Replace(text)
+
+	var events []Event
+	var current *Event
+	var duration time.Duration
+	depth := 0 // nesting inside the current event, e.g. VALARM
+	for _, line := range strings.Split(text, "\n") {
+		p, ok := parseProperty(strings.TrimRight(line, "\r"))
+		if !ok {
+			continue
+		}
+		value := strings.ToUpper(p.value)
+		switch {
+		case p.name == "BEGIN" && value == "VEVENT":
+			current, duration, depth = &Event{}, 0, 0
+			continue
+		case current == nil:
+			continue
+		case p.name == "BEGIN":
+			depth++
+			continue
+		case p.name == "END" && depth > 0:
+			depth--
+			continue
+		case p.name == "END" && value == "VEVENT":
+			if !current.Start.IsZero() {
+				finishEvent(current, duration)
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		case depth > 0:
+			continue
+		}
+
+		switch p.name {
+		case "UID":
+			current.UID = strings.TrimSpace(p.value)
+		case "SUMMARY":
+			current.Summary = strings.TrimSpace(unescape(p.value))
+		case "DESCRIPTION":
+			current.Description = strings.TrimSpace(unescape(p.value))
+		case "STATUS":
+			current.Status = value
+		case "DTSTART":
+			t, allDay, floating, err := parseTime(p)
+			if err != nil {
+				return nil, err
+			}
+			current.Start, current.AllDay, current.Floating = t, allDay, floating
+		case "DTEND":
+			t, _, _, err := parseTime(p)
+			if err != nil {
+				return nil, err
+			}
+			current.End = t
+		case "DURATION":
+			d, err := parseDuration(p.value)
+			if err != nil {
+				return nil, err
+			}
+			duration = d
+		}
+	}
+	return events, nil
+}
+
+// finishEvent fills in the end of an event from its duration, or the
+// default length.
+func finishEvent(e *Event, duration time.Duration) {
+	switch {
+	case !e.End.IsZero() && e.End.After(e.Start):
+	case duration > 0:
+		e.End = e.Start.Add(duration)
+	case e.AllDay:
+		e.End = e.Start.AddDate(0, 0, 1)
+	default:
+		e.End = e.Start.Add(time.Hour)
+	}
+}
+
+// parseTime reads a DATE or DATE-TIME value: in UTC with a trailing Z, in
+// the zone named by TZID, or floating.
+func parseTime(p property) (t time.Time, allDay, floating bool, err error) {
+	value := strings.TrimSpace(p.value)
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err = time.ParseInLocation(dateLayout, value, time.Local)
+		if err != nil {
+			return time.Time{}, false, false, fmt.Errorf("invalid %s date %q", p.name, value)
+		}
+		return t, true, false, nil
+	}
+
+	loc, floating := time.Local, true
+	if strings.HasSuffix(value, "Z") {
+		value, loc, floating = strings.TrimSuffix(value, "Z"), time.UTC, false
+	} else if tzid := p.params["TZID"]; tzid != "" {
+		// Unknown zones, such as Windows names, are read as local time
+		if zone, zoneErr := time.LoadLocation(tzid); zoneErr == nil {
+			loc, floating = zone, false
+		}
+	}
+	t, err = time.ParseInLocation(dateTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, false, false, fmt.Errorf("invalid %s time %q", p.name, p.value)
+	}
+	return t, false, floating, nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads a DURATION value such as PT1H30M or P1D.
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(value)))
+	if m == nil {
+		return 0, fmt.Errorf("invalid DURATION %q", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if n, err := strconv.Atoi(m[i+2]); err == nil {
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,181 @@
+package ical
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+	"unicode/utf8"
+)
+
+// calendar wraps VEVENT lines in a VCALENDAR with CRLF line endings.
+func calendar(lines ...string) string {
+	return strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR"), "\r\n") + "\r\n"
+}
+
+func TestParse(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  Event
+	}{
+		{
+			name:  "UTC with DTEND",
+			lines: []string{"BEGIN:VEVENT", "UID:a", "DTSTART:20261012T070000Z", "DTEND:20261012T083000Z", "SUMMARY:Squats", "STATUS:confirmed", "END:VEVENT"},
+			want: Event{UID: "a", Summary: "Squats", Status: "CONFIRMED",
+				Start: time.Date(2026, 10, 12, 7, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 12, 8, 30, 0, 0, time.UTC)},
+		},
+		{
+			name:  "TZID",
+			lines: []string{"BEGIN:VEVENT", "DTSTART;TZID=America/New_York:20261012T070000", "DTEND;TZID=\"America/New_York\":20261012T080000", "END:VEVENT"},
+			want:  Event{Start: time.Date(2026, 10, 12, 7, 0, 0, 0, newYork), End: time.Date(2026, 10, 12, 8, 0, 0, 0, newYork)},
+		},
+		{
+			name:  "unknown TZID is floating",
+			lines: []string{"BEGIN:VEVENT", "DTSTART;TZID=W. Europe Standard Time:20261012T070000", "END:VEVENT"},
+			want: Event{Floating: true,
+				Start: time.Date(2026, 10, 12, 7, 0, 0, 0, time.Local), End: time.Date(2026, 10, 12, 8, 0, 0, 0, time.Local)},
+		},
+		{
+			name:  "all-day date",
+			lines: []string{"BEGIN:VEVENT", "DTSTART;VALUE=DATE:20261012", "END:VEVENT"},
+			want: Event{AllDay: true,
+				Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local), End: time.Date(2026, 10, 13, 0, 0, 0, 0, time.Local)},
+		},
+		{
+			name:  "DURATION",
+			lines: []string{"BEGIN:VEVENT", "DTSTART:20261012T070000Z", "DURATION:PT1H30M", "END:VEVENT"},
+			want:  Event{Start: time.Date(2026, 10, 12, 7, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 12, 8, 30, 0, 0, time.UTC)},
+		},
+		{
+			name:  "DTEND wins over DURATION",
+			lines: []string{"BEGIN:VEVENT", "DTSTART:20261012T070000Z", "DURATION:P1D", "DTEND:20261012T074500Z", "END:VEVENT"},
+			want:  Event{Start: time.Date(2026, 10, 12, 7, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 12, 7, 45, 0, 0, time.UTC)},
+		},
+		{
+			name: "folded and escaped text",
+			lines: []string{"BEGIN:VEVENT", "DTSTART:20261012T070000Z",
+				`SUMMARY:Squat\, bench\; a long title that a calendar app fol`, " ded onto a second", "\tand third line",
+				`DESCRIPTION:Squat 3 x 5\nBench 3 x 8\NPath C:\\gym`, "END:VEVENT"},
+			want: Event{Summary: "Squat, bench; a long title that a calendar app folded onto a secondand third line",
+				Description: "Squat 3 x 5\nBench 3 x 8\nPath C:\\gym",
+				Start:       time.Date(2026, 10, 12, 7, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "alarms are skipped",
+			lines: []string{"BEGIN:VEVENT", "DTSTART:20261012T070000Z", "SUMMARY:Squats",
+				"BEGIN:VALARM", "ACTION:DISPLAY", "DESCRIPTION:Reminder", "END:VALARM", "END:VEVENT"},
+			want: Event{Summary: "Squats", Start: time.Date(2026, 10, 12, 7, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Parse(strings.NewReader(calendar(tt.lines...)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(events) != 1 {
+				t.Fatalf("events = %+v, want one", events)
+			}
+			assertEvent(t, events[0], tt.want)
+		})
+	}
+}
+
+func TestParseSkipsAndFails(t *testing.T) {
+	events, err := Parse(strings.NewReader(calendar("BEGIN:VEVENT", "SUMMARY:No start", "END:VEVENT")))
+	if err != nil || len(events) != 0 {
+		t.Errorf("event without a start: %+v, %v, want it skipped", events, err)
+	}
+
+	for name, data := range map[string]string{
+		"not a calendar": "hello",
+		"bad time":       calendar("BEGIN:VEVENT", "DTSTART:tomorrow", "END:VEVENT"),
+		"bad duration":   calendar("BEGIN:VEVENT", "DTSTART:20261012T070000Z", "DURATION:1 hour", "END:VEVENT"),
+	} {
+		if _, err := Parse(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: Parse() succeeded", name)
+		}
+	}
+}
+
+func TestWriteFolds(t *testing.T) {
+	var buf bytes.Buffer
+	long := strings.Repeat("Kniebeuge über 100 kg, ", 10)
+	cal := Calendar{Events: []Event{{UID: "a", Summary: long,
+		Start: time.Date(2026, 10, 12, 7, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC)}}}
+	if err := Write(&buf, cal); err != nil {
+		t.Fatal(err)
+	}
+
+	text := buf.String()
+	if !strings.HasSuffix(text, "END:VCALENDAR\r\n") || strings.Contains(strings.ReplaceAll(text, "\r\n", ""), "\n") {
+		t.Error("lines do not all end in CRLF")
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\r\n"), "\r\n")
+	continued := 0
+	for _, line := range lines {
+		if len(line) > 75 {
+			t.Errorf("line of %d octets: %q", len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line splits a character: %q", line)
+		}
+		if strings.HasPrefix(line, " ") {
+			continued++
+		}
+	}
+	if continued == 0 {
+		t.Error("long summary was not folded")
+	}
+	if !strings.Contains(text, `Kniebeuge über 100 kg\, `) {
+		t.Error("commas were not escaped")
+	}
+}
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	cal := Calendar{Name: "Training: sam", Events: []Event{
+		{UID: "utc", Summary: "Squat, bench; rows", Description: "Squat 3 x 5\nBench 3 x 8\nC:\\gym", Status: "CONFIRMED",
+			Start: time.Date(2026, 10, 12, 7, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 12, 8, 30, 0, 0, time.UTC)},
+		{UID: "floating", Summary: "Run", Floating: true, Status: "TENTATIVE",
+			Start: time.Date(2026, 10, 13, 18, 0, 0, 0, time.Local), End: time.Date(2026, 10, 13, 18, 45, 0, 0, time.Local)},
+		{UID: "all-day", Summary: "Rest", AllDay: true,
+			Start: time.Date(2026, 10, 14, 0, 0, 0, 0, time.Local), End: time.Date(2026, 10, 15, 0, 0, 0, 0, time.Local)},
+		{UID: "long", Summary: strings.Repeat("A very long summary, ", 8),
+			Start: time.Date(2026, 10, 15, 7, 0, 0, 0, time.UTC), End: time.Date(2026, 10, 15, 8, 0, 0, 0, time.UTC)},
+	}}
+	var buf bytes.Buffer
+	if err := Write(&buf, cal); err != nil {
+		t.Fatal(err)
+	}
+	events, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != len(cal.Events) {
+		t.Fatalf("parsed %d events, want %d", len(events), len(cal.Events))
+	}
+	for i, want := range cal.Events {
+		want.Summary = strings.TrimSpace(want.Summary)
+		assertEvent(t, events[i], want)
+	}
+}
+
+// assertEvent compares events, with times compared as instants.
+func assertEvent(t *testing.T, got, want Event) {
+	t.Helper()
+	if !got.Start.Equal(want.Start) || !got.End.Equal(want.End) {
+		t.Errorf("times = %v to %v, want %v to %v", got.Start, got.End, want.Start, want.End)
+	}
+	got.Start, got.End, want.Start, want.End = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("event = %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package ical
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+	"fitness-dev/render"
+)
+
+// uidDomain ends the UID of every exported event, keeping them unique
+// across calendars.
+const uidDomain = "@fitness-dev"
+
+// Feed builds an athlete's training calendar: completed workouts as
+// confirmed events, and workouts and sessions still planned as tentative ones.
+// A session still planned stands in for its workout.
+// Times are floating, so sessions show at the clock time they were logged at.
+func Feed(athlete string, workouts []models.Workout, planned []models.PlannedSession, opts render.Options) Calendar {
+	cal := Calendar{Name: "Training: " + athlete, Events: []Event{}}
+	linked := map[int]bool{}
+	for _, p := range planned {
+		if p.Status == models.StatusPlanned {
+			linked[p.WorkoutID] = true
+		}
+	}
+	for _, w := range workouts {
+		if !linked[w.ID] {
+			cal.Events = append(cal.Events, WorkoutEvent(w, opts))
+		}
+	}
+	for _, p := range planned {
+		if p.Status == models.StatusPlanned {
+			cal.Events = append(cal.Events, PlannedEvent(p))
+		}
+	}
+	return cal
+}
+
+// WorkoutEvent describes a workout as an event from its time in to its time
+// out, with its lifts in the description. Workouts not done yet are
+// tentative, and skipped ones cancelled.
+func WorkoutEvent(w models.Workout, opts render.Options) Event {
+	e := Event{
+		UID:         fmt.Sprintf("workout-%d%s", w.ID, uidDomain),
+		Summary:     workoutSummary(w),
+		Description: strings.Join(render.Lines(w, opts), "\n"),
+		Status:      "CONFIRMED",
+	}
+	switch w.Status {
+	case models.StatusPlanned, models.StatusInProgress:
+		e.Status = "TENTATIVE"
+	case models.StatusSkipped:
+		e.Status = "CANCELLED"
+	}
+	setTimes(&e, w.Date, w.TimeIn, w.TimeOut)
+	return e
+}
+
+// PlannedEvent describes a planned session as a tentative event.
+func PlannedEvent(p models.PlannedSession) Event {
+	e := Event{
+		UID:         fmt.Sprintf("planned-%d%s", p.ID, uidDomain),
+		Summary:     p.Title,
+		Description: p.Notes,
+		Status:      "TENTATIVE",
+	}
+	setTimes(&e, p.Date, p.TimeIn, p.TimeOut)
+	return e
+}
+
+// workoutSummary names a workout after its exercises, e.g. "Squat, Bench".
+func workoutSummary(w models.Workout) string {
+	var names []string
+	for _, name := range w.Lifts {
+		if name = strings.TrimSpace(name); !slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, name) }) {
+			names = append(names, name)
+		}
+	}
+	for _, c := range w.Cardio {
+		names = append(names, c.Activity)
+	}
+	if len(names) == 0 {
+		return "Workout"
+	}
+	return render.Truncate("Workout: "+strings.Join(names, ", "), 80)
+}
+
+// setTimes sets floating start and end times from a day and clock times. A
+// time out before the time in runs past midnight; without readable times the
+// event lasts the whole day.
+func setTimes(e *Event, date, timeIn, timeOut string) {
+	day, _ := time.ParseInLocation(models.DateLayout, date, time.Local)
+	in, inErr := time.Parse(models.TimeLayout, timeIn)
+	out, outErr := time.Parse(models.TimeLayout, timeOut)
+	if inErr != nil || outErr != nil {
+		e.Start, e.End, e.AllDay = day, day.AddDate(0, 0, 1), true
+		return
+	}
+	e.Start = day.Add(time.Duration(in.Hour())*time.Hour + time.Duration(in.Minute())*time.Minute)
+	e.End = day.Add(time.Duration(out.Hour())*time.Hour + time.Duration(out.Minute())*time.Minute)
+	if !e.End.After(e.Start) {
+		e.End = e.End.AddDate(0, 0, 1)
+	}
+	e.Floating = true
+}
+
+// PlannedSessions turns calendar events into planned sessions for an
+// athlete, in local time. Cancelled events, and events exported from here
+// (which are already workouts or planned sessions), are left out and counted.
+func PlannedSessions(events []Event, athlete string) (sessions []models.PlannedSession, skipped int) {
+	sessions = []models.PlannedSession{}
+	for _, e := range events {
+		if e.Status == "CANCELLED" || strings.HasSuffix(e.UID, uidDomain) {
+			skipped++
+			continue
+		}
+		p := models.PlannedSession{Athlete: athlete, Title: e.Summary, Notes: e.Description, UID: e.UID}
+		if p.Title == "" {
+			p.Title = "Training"
+		}
+		start := e.Start.In(time.Local)
+		p.Date = start.Format(models.DateLayout)
+		if !e.AllDay {
+			p.TimeIn = start.Format(models.TimeLayout)
+			p.TimeOut = e.End.In(time.Local).Format(models.TimeLayout)
+		}
+		sessions = append(sessions, p)
+	}
+	return sessions, skipped
+}
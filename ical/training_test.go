@@ -0,0 +1,82 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-dev/models"
+	"fitness-dev/render"
+)
+
+func TestWorkoutEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		workout    models.Workout
+		status     string
+		start, end time.Time
+		allDay     bool
+	}{
+		{"completed", models.Workout{ID: 1, Date: "12/10/2026", TimeIn: "07:00", TimeOut: "08:15", Status: models.StatusCompleted},
+			"CONFIRMED", time.Date(2026, 10, 12, 7, 0, 0, 0, time.Local), time.Date(2026, 10, 12, 8, 15, 0, 0, time.Local), false},
+		{"past midnight", models.Workout{ID: 2, Date: "12/10/2026", TimeIn: "23:30", TimeOut: "00:30", Status: models.StatusCompleted},
+			"CONFIRMED", time.Date(2026, 10, 12, 23, 30, 0, 0, time.Local), time.Date(2026, 10, 13, 0, 30, 0, 0, time.Local), false},
+		{"planned without times", models.Workout{ID: 3, Date: "12/10/2026", Status: models.StatusPlanned},
+			"TENTATIVE", time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local), time.Date(2026, 10, 13, 0, 0, 0, 0, time.Local), true},
+		{"skipped", models.Workout{ID: 4, Date: "12/10/2026", TimeIn: "07:00", TimeOut: "08:00", Status: models.StatusSkipped},
+			"CANCELLED", time.Date(2026, 10, 12, 7, 0, 0, 0, time.Local), time.Date(2026, 10, 12, 8, 0, 0, 0, time.Local), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := WorkoutEvent(tt.workout, render.Options{})
+			if e.Status != tt.status || e.AllDay != tt.allDay || e.Floating == tt.allDay {
+				t.Errorf("status %s, all day %v, floating %v; want %s, %v", e.Status, e.AllDay, e.Floating, tt.status, tt.allDay)
+			}
+			if !e.Start.Equal(tt.start) || !e.End.Equal(tt.end) {
+				t.Errorf("times = %v to %v, want %v to %v", e.Start, e.End, tt.start, tt.end)
+			}
+			if !strings.HasSuffix(e.UID, uidDomain) {
+				t.Errorf("UID %q does not end in %s", e.UID, uidDomain)
+			}
+		})
+	}
+
+	w := models.Workout{Lifts: []string{"Squat", "squat", "Bench"}, Cardio: []models.Cardio{{Activity: models.ActivityRun}}}
+	if got := workoutSummary(w); got != "Workout: Squat, Bench, run" {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestFeed(t *testing.T) {
+	workouts := []models.Workout{{ID: 1, Date: "12/10/2026", TimeIn: "07:00", TimeOut: "08:00", Status: models.StatusCompleted}}
+	planned := []models.PlannedSession{
+		{ID: 1, Date: "13/10/2026", Title: "Squats", Notes: "Squat 3 x 5 @ 100kg", Status: models.StatusPlanned},
+		{ID: 2, Date: "14/10/2026", Title: "Done", Status: models.StatusCompleted, WorkoutID: 1},
+	}
+	cal := Feed("sam", workouts, planned, render.Options{})
+	if cal.Name != "Training: sam" || len(cal.Events) != 2 {
+		t.Fatalf("feed = %+v, want the workout and the session still planned", cal)
+	}
+	if e := cal.Events[1]; e.UID != "planned-1"+uidDomain || e.Description != planned[0].Notes || !e.AllDay {
+		t.Errorf("planned event = %+v", e)
+	}
+}
+
+func TestPlannedSessions(t *testing.T) {
+	events := []Event{
+		{UID: "gym", Summary: "Legs", Start: time.Date(2026, 10, 12, 7, 0, 0, 0, time.Local), End: time.Date(2026, 10, 12, 8, 0, 0, 0, time.Local)},
+		{UID: "rest", AllDay: true, Start: time.Date(2026, 10, 13, 0, 0, 0, 0, time.Local), End: time.Date(2026, 10, 14, 0, 0, 0, 0, time.Local)},
+		{UID: "off", Status: "CANCELLED", Start: time.Date(2026, 10, 14, 7, 0, 0, 0, time.Local)},
+		{UID: "workout-1" + uidDomain, Start: time.Date(2026, 10, 15, 7, 0, 0, 0, time.Local)},
+	}
+	sessions, skipped := PlannedSessions(events, "sam")
+	if skipped != 2 || len(sessions) != 2 {
+		t.Fatalf("sessions %+v, skipped %d; want two of each", sessions, skipped)
+	}
+	if s := sessions[0]; s.Athlete != "sam" || s.Date != "12/10/2026" || s.TimeIn != "07:00" || s.TimeOut != "08:00" || s.Title != "Legs" {
+		t.Errorf("timed session = %+v", s)
+	}
+	if s := sessions[1]; s.Date != "13/10/2026" || s.TimeIn != "" || s.Title != "Training" {
+		t.Errorf("all-day session = %+v", s)
+	}
+}
@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// FIT global message numbers and field numbers used for tracks.
+const (
+	fitMesgSport   = 12
+	fitMesgSession = 18
+	fitMesgRecord  = 20
+
+	fitFieldTimestamp        = 253
+	fitFieldLat              = 0
+	fitFieldLon              = 1
+	fitFieldAltitude         = 2
+	fitFieldHeartRate        = 3
+	fitFieldDistance         = 5
+	fitFieldEnhancedAltitude = 78
+	fitFieldSessionSport     = 5
+	fitFieldSportSport       = 0
+)
+
+// fitEpoch is the zero of FIT timestamps, 1989-12-31 00:00:00 UTC.
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// fitSports names the FIT sport enum values that map onto an activity.
+var fitSports = map[int64]string{1: "running", 2: "cycling", 5: "swimming", 15: "rowing"}
+
+type fitField struct {
+	num, size, baseType byte
+}
+
+type fitDefinition struct {
+	global    uint16
+	bigEndian bool
+	fields    []fitField
+	devSize   int // total size of developer fields, skipped
+}
+
+// parseFIT decodes the record messages of a FIT activity file. Only the
+// fields needed for a track are read; everything else is skipped.
+func parseFIT(data []byte) (Track, error) {
+	if len(data) < 12 || string(data[8:12]) != ".FIT" {
+		return Track{}, fmt.Errorf("invalid FIT: missing header")
+	}
+	headerSize := int(data[0])
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	if headerSize < 12 || headerSize+dataSize > len(data) {
+		return Track{}, fmt.Errorf("invalid FIT: truncated file")
+	}
+
+	var track Track
+	definitions := map[byte]*fitDefinition{}
+	var lastTimestamp uint32
+	pos, end := headerSize, headerSize+dataSize
+
+	for pos < end {
+		header := data[pos]
+		pos++
+
+		var local byte
+		compressed := header&0x80 != 0
+		switch {
+		case compressed:
+			local = (header >> 5) & 0x03
+			offset := uint32(header & 0x1F)
+			timestamp := lastTimestamp&^0x1F | offset
+			if offset < lastTimestamp&0x1F {
+				timestamp += 0x20
+			}
+			lastTimestamp = timestamp
+		case header&0x40 != 0:
+			def, n, err := parseFITDefinition(data[pos:end], header&0x20 != 0)
+			if err != nil {
+				return Track{}, err
+			}
+			definitions[header&0x0F] = def
+			pos += n
+			continue
+		default:
+			local = header & 0x0F
+		}
+
+		def, ok := definitions[local]
+		if !ok {
+			return Track{}, fmt.Errorf("invalid FIT: data message for undefined local type %d", local)
+		}
+		values := map[byte]int64{}
+		for _, f := range def.fields {
+			if pos+int(f.size) > end {
+				return Track{}, fmt.Errorf("invalid FIT: truncated data message")
+			}
+			if v, ok := fitValue(data[pos:pos+int(f.size)], f.baseType, def.bigEndian); ok {
+				values[f.num] = v
+			}
+			pos += int(f.size)
+		}
+		pos += def.devSize
+		if pos > end {
+			return Track{}, fmt.Errorf("invalid FIT: truncated data message")
+		}
+
+		if ts, ok := values[fitFieldTimestamp]; ok && !compressed {
+			lastTimestamp = uint32(ts)
+		}
+
+		switch def.global {
+		case fitMesgRecord:
+			track.Points = append(track.Points, fitPoint(values, lastTimestamp))
+		case fitMesgSession:
+			if track.Activity == "" {
+				track.Activity = activityFor(fitSports[values[fitFieldSessionSport]])
+			}
+		case fitMesgSport:
+			if track.Activity == "" {
+				track.Activity = activityFor(fitSports[values[fitFieldSportSport]])
+			}
+		}
+	}
+	return track, nil
+}
+
+func parseFITDefinition(data []byte, developer bool) (*fitDefinition, int, error) {
+	if len(data) < 5 {
+		return nil, 0, fmt.Errorf("invalid FIT: truncated definition message")
+	}
+	def := &fitDefinition{bigEndian: data[1] == 1}
+	if def.bigEndian {
+		def.global = binary.BigEndian.Uint16(data[2:4])
+	} else {
+		def.global = binary.LittleEndian.Uint16(data[2:4])
+	}
+	count := int(data[4])
+	n := 5 + 3*count
+	if len(data) < n {
+		return nil, 0, fmt.Errorf("invalid FIT: truncated definition message")
+	}
+	for i := 0; i < count; i++ {
+		f := data[5+3*i : 8+3*i]
+		def.fields = append(def.fields, fitField{num: f[0], size: f[1], baseType: f[2]})
+	}
+
+	if developer {
+		if len(data) < n+1 {
+			return nil, 0, fmt.Errorf("invalid FIT: truncated definition message")
+		}
+		devCount := int(data[n])
+		n++
+		if len(data) < n+3*devCount {
+			return nil, 0, fmt.Errorf("invalid FIT: truncated definition message")
+		}
+		for i := 0; i < devCount; i++ {
+			def.devSize += int(data[n+3*i+1])
+		}
+		n += 3 * devCount
+	}
+	return def, n, nil
+}
+
+// fitValue decodes an integer field, reporting false for the base type's
+// invalid marker and for types this reader does not need.
+func fitValue(b []byte, baseType byte, bigEndian bool) (int64, bool) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	if len(b) == 0 {
+		return 0, false
+	}
+	switch baseType {
+	case 0x00, 0x02: // enum, uint8
+		return int64(b[0]), len(b) == 1 && b[0] != 0xFF
+	case 0x01: // sint8
+		return int64(int8(b[0])), len(b) == 1 && b[0] != 0x7F
+	case 0x0A: // uint8z
+		return int64(b[0]), len(b) == 1 && b[0] != 0
+	}
+	if len(b) == 2 {
+		v := order.Uint16(b)
+		switch baseType {
+		case 0x83:
+			return int64(int16(v)), v != 0x7FFF
+		case 0x84:
+			return int64(v), v != 0xFFFF
+		case 0x8B:
+			return int64(v), v != 0
+		}
+	}
+	if len(b) == 4 {
+		v := order.Uint32(b)
+		switch baseType {
+		case 0x85:
+			return int64(int32(v)), v != 0x7FFFFFFF
+		case 0x86:
+			return int64(v), v != 0xFFFFFFFF
+		case 0x8C:
+			return int64(v), v != 0
+		}
+	}
+	return 0, false
+}
+
+func fitPoint(values map[byte]int64, timestamp uint32) Point {
+	p := Point{Time: fitEpoch.Add(time.Duration(timestamp) * time.Second)}
+	lat, hasLat := values[fitFieldLat]
+	lon, hasLon := values[fitFieldLon]
+	if hasLat && hasLon {
+		p.Lat, p.Lon, p.HasPosition = semicircles(lat), semicircles(lon), true
+	}
+	if alt, ok := values[fitFieldEnhancedAltitude]; ok {
+		p.Elevation, p.HasElevation = float64(alt)/5-500, true
+	} else if alt, ok := values[fitFieldAltitude]; ok {
+		p.Elevation, p.HasElevation = float64(alt)/5-500, true
+	}
+	if d, ok := values[fitFieldDistance]; ok {
+		p.Distance = float64(d) / 100
+	}
+	if hr, ok := values[fitFieldHeartRate]; ok {
+		p.HeartRate = int(hr)
+	}
+	return p
+}
+
+// semicircles converts a FIT position to degrees.
+func semicircles(v int64) float64 {
+	return float64(v) * 180 / math.Pow(2, 31)
+}
@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// gpxFile is the part of a GPX 1.1 document needed for tracks. Heart rate is
+// read from the Garmin TrackPointExtension, whatever its namespace prefix.
+type gpxFile struct {
+	Tracks []struct {
+		Type     string `xml:"type"`
+		Segments []struct {
+			Points []struct {
+				Lat       float64  `xml:"lat,attr"`
+				Lon       float64  `xml:"lon,attr"`
+				Elevation *float64 `xml:"ele"`
+				Time      string   `xml:"time"`
+				HeartRate int      `xml:"extensions>TrackPointExtension>hr"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+func parseGPX(data []byte) (Track, error) {
+	var doc gpxFile
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return Track{}, fmt.Errorf("invalid GPX: %v", err)
+	}
+
+	var track Track
+	for _, trk := range doc.Tracks {
+		if track.Activity == "" {
+			track.Activity = activityFor(trk.Type)
+		}
+		for _, seg := range trk.Segments {
+			for _, pt := range seg.Points {
+				t, err := time.Parse(time.RFC3339, pt.Time)
+				if err != nil {
+					continue // untimed points cannot contribute to pace
+				}
+				p := Point{Time: t, Lat: pt.Lat, Lon: pt.Lon, HasPosition: true, HeartRate: pt.HeartRate}
+				if pt.Elevation != nil {
+					p.Elevation, p.HasElevation = *pt.Elevation, true
+				}
+				track.Points = append(track.Points, p)
+			}
+		}
+	}
+	return track, nil
+}
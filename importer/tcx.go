@@ -0,0 +1,56 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// tcxFile is the part of a Garmin Training Center (TCX v2) document needed
+// for tracks.
+type tcxFile struct {
+	Activities []struct {
+		Sport string `xml:"Sport,attr"`
+		Laps  []struct {
+			Points []struct {
+				Time      string   `xml:"Time"`
+				Lat       *float64 `xml:"Position>LatitudeDegrees"`
+				Lon       *float64 `xml:"Position>LongitudeDegrees"`
+				Altitude  *float64 `xml:"AltitudeMeters"`
+				Distance  float64  `xml:"DistanceMeters"`
+				HeartRate int      `xml:"HeartRateBpm>Value"`
+			} `xml:"Track>Trackpoint"`
+		} `xml:"Lap"`
+	} `xml:"Activities>Activity"`
+}
+
+func parseTCX(data []byte) (Track, error) {
+	var doc tcxFile
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return Track{}, fmt.Errorf("invalid TCX: %v", err)
+	}
+
+	var track Track
+	for _, activity := range doc.Activities {
+		if track.Activity == "" {
+			track.Activity = activityFor(activity.Sport)
+		}
+		for _, lap := range activity.Laps {
+			for _, pt := range lap.Points {
+				t, err := time.Parse(time.RFC3339, pt.Time)
+				if err != nil {
+					continue
+				}
+				p := Point{Time: t, Distance: pt.Distance, HeartRate: pt.HeartRate}
+				if pt.Lat != nil && pt.Lon != nil {
+					p.Lat, p.Lon, p.HasPosition = *pt.Lat, *pt.Lon, true
+				}
+				if pt.Altitude != nil {
+					p.Elevation, p.HasElevation = *pt.Altitude, true
+				}
+				track.Points = append(track.Points, p)
+			}
+		}
+	}
+	return track, nil
+}
@@ -0,0 +1,256 @@
+// Package importer reads activities recorded by GPS watches and bike
+// computers (GPX, TCX and FIT files) and turns them into cardio sessions.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fitness-dev/models"
+)
+
+// Point is one sample of a recorded track.
+type Point struct {
+	Time         time.Time
+	Lat, Lon     float64 // degrees
+	HasPosition  bool
+	Elevation    float64 // metres
+	HasElevation bool
+	Distance     float64 // metres from the start, filled in from positions if the file has none
+	HeartRate    int     // beats per minute, 0 if not recorded
+}
+
+// Track is a recorded activity, its points in time order.
+type Track struct {
+	Activity string // one of models.Activities, empty if the file does not say
+	Points   []Point
+}
+
+// Parse reads a GPX, TCX or FIT file. The format is taken from the file name's
+// extension, falling back to sniffing the content.
+func Parse(name string, r io.Reader) (Track, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Track{}, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+
+	var track Track
+	switch format(name, data) {
+	case "gpx":
+		track, err = parseGPX(data)
+	case "tcx":
+		track, err = parseTCX(data)
+	case "fit":
+		track, err = parseFIT(data)
+	default:
+		return Track{}, fmt.Errorf("%s is not a GPX, TCX or FIT file", name)
+	}
+	if err != nil {
+		return Track{}, err
+	}
+	if len(track.Points) < 2 {
+		return Track{}, fmt.Errorf("%s has fewer than two timed track points", name)
+	}
+
+	fillDistances(track.Points)
+	return track, nil
+}
+
+func format(name string, data []byte) string {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")); ext {
+	case "gpx", "tcx", "fit":
+		return ext
+	}
+	switch {
+	case len(data) >= 12 && string(data[8:12]) == ".FIT":
+		return "fit"
+	case bytes.Contains(data, []byte("<gpx")):
+		return "gpx"
+	case bytes.Contains(data, []byte("<TrainingCenterDatabase")):
+		return "tcx"
+	}
+	return ""
+}
+
+// activityFor maps the sport names used by the different formats onto
+// models.Activities.
+func activityFor(sport string) string {
+	switch strings.ToLower(strings.TrimSpace(sport)) {
+	case "run", "running", "trail_running", "treadmill_running":
+		return models.ActivityRun
+	case "bike", "biking", "cycling", "ride", "road_biking", "mountain_biking":
+		return models.ActivityBike
+	case "swim", "swimming", "open_water_swimming", "lap_swimming":
+		return models.ActivitySwim
+	case "row", "rowing", "indoor_rowing":
+		return models.ActivityRow
+	}
+	return ""
+}
+
+// fillDistances computes cumulative distance from positions when the file
+// recorded none, and makes it non-decreasing either way.
+func fillDistances(points []Point) {
+	recorded := false
+	for _, p := range points {
+		if p.Distance > 0 {
+			recorded = true
+			break
+		}
+	}
+
+	for i := 1; i < len(points); i++ {
+		if !recorded {
+			points[i].Distance = points[i-1].Distance
+			if points[i].HasPosition && points[i-1].HasPosition {
+				points[i].Distance += haversine(points[i-1], points[i])
+			}
+		}
+		if points[i].Distance < points[i-1].Distance {
+			points[i].Distance = points[i-1].Distance
+		}
+	}
+}
+
+const earthRadius = 6371000.0 // metres
+
+// haversine returns the great-circle distance between two points in metres.
+func haversine(a, b Point) float64 {
+	rad := math.Pi / 180
+	dLat := (b.Lat - a.Lat) * rad
+	dLon := (b.Lon - a.Lon) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(a.Lat*rad)*math.Cos(b.Lat*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// Start returns the time of the first point.
+func (t Track) Start() time.Time { return t.Points[0].Time }
+
+// End returns the time of the last point.
+func (t Track) End() time.Time { return t.Points[len(t.Points)-1].Time }
+
+// Cardio summarises the track as a cardio session with per km splits.
+// activity overrides the sport recorded in the file; if neither is known the
+// session is treated as a run.
+func (t Track) Cardio(activity string) models.Cardio {
+	if activity == "" {
+		activity = t.Activity
+	}
+	if activity == "" {
+		activity = models.ActivityRun
+	}
+
+	points := t.Points
+	c := models.Cardio{
+		Activity:  activity,
+		Distance:  round(points[len(points)-1].Distance/1000, 3),
+		Duration:  int(t.End().Sub(t.Start()).Seconds()),
+		AvgHR:     averageHR(points),
+		Elevation: round(climb(points), 1),
+		Splits:    splits(points),
+	}
+	if c.Distance > 0 {
+		c.Pace = round(float64(c.Duration)/c.Distance, 1)
+	}
+	return c
+}
+
+// Workout builds a workout holding the track as its only activity, timed in
+// the server's local time zone. Moods and athlete are left for the caller.
+func (t Track) Workout(activity string) models.Workout {
+	start, end := t.Start().Local(), t.End().Local()
+	return models.Workout{
+		Date:    start.Format(models.DateLayout),
+		TimeIn:  start.Format(models.TimeLayout),
+		TimeOut: end.Format(models.TimeLayout),
+		Cardio:  []models.Cardio{t.Cardio(activity)},
+	}
+}
+
+func averageHR(points []Point) int {
+	var sum, n int
+	for _, p := range points {
+		if p.HeartRate > 0 {
+			sum += p.HeartRate
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return int(math.Round(float64(sum) / float64(n)))
+}
+
+// climb sums the elevation gained between consecutive points.
+func climb(points []Point) float64 {
+	var gain float64
+	for i := 1; i < len(points); i++ {
+		if points[i].HasElevation && points[i-1].HasElevation && points[i].Elevation > points[i-1].Elevation {
+			gain += points[i].Elevation - points[i-1].Elevation
+		}
+	}
+	return gain
+}
+
+// minSplit is the shortest trailing split reported, in metres.
+const minSplit = 10.0
+
+// splits cuts the track into kilometres, interpolating the time each
+// kilometre mark was passed.
+func splits(points []Point) []models.Split {
+	var result []models.Split
+	splitStart := points[0].Time
+	startDistance := 0.0
+	var hrSum, hrCount int
+	var gain float64
+
+	closeSplit := func(at time.Time, distance float64) {
+		s := models.Split{
+			Distance:  round((distance-startDistance)/1000, 3),
+			Duration:  int(math.Round(at.Sub(splitStart).Seconds())),
+			Elevation: round(gain, 1),
+		}
+		if s.Distance > 0 {
+			s.Pace = round(float64(s.Duration)/s.Distance, 1)
+		}
+		if hrCount > 0 {
+			s.AvgHR = int(math.Round(float64(hrSum) / float64(hrCount)))
+		}
+		result = append(result, s)
+		splitStart, startDistance = at, distance
+		hrSum, hrCount, gain = 0, 0, 0
+	}
+
+	for i := 1; i < len(points); i++ {
+		prev, p := points[i-1], points[i]
+		for next := startDistance + 1000; p.Distance >= next; next = startDistance + 1000 {
+			fraction := (next - prev.Distance) / (p.Distance - prev.Distance)
+			at := prev.Time.Add(time.Duration(fraction * float64(p.Time.Sub(prev.Time))))
+			closeSplit(at, next)
+		}
+		if p.HeartRate > 0 {
+			hrSum += p.HeartRate
+			hrCount++
+		}
+		if p.HasElevation && prev.HasElevation && p.Elevation > prev.Elevation {
+			gain += p.Elevation - prev.Elevation
+		}
+	}
+
+	last := points[len(points)-1]
+	if last.Distance-startDistance >= minSplit {
+		closeSplit(last.Time, last.Distance)
+	}
+	return result
+}
+
+func round(x float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(x*scale) / scale
+}
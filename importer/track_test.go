@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-dev/models"
+)
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		file      string
+		activity  string
+		points    int
+		hrSamples int
+		start     time.Time
+		want      models.Cardio
+	}{
+		{
+			file: "run.gpx", activity: models.ActivityRun, points: 77, hrSamples: 77,
+			start: time.Date(2025, 9, 12, 6, 30, 0, 0, time.UTC),
+			want: models.Cardio{
+				Activity: models.ActivityRun, Distance: 2.432, Duration: 760, Pace: 312.5, AvgHR: 149, Elevation: 23.7,
+				Splits: []models.Split{
+					{Distance: 1, Duration: 313, Pace: 313, AvgHR: 136, Elevation: 8},
+					{Distance: 1, Duration: 312, Pace: 312, AvgHR: 159, Elevation: 7.3},
+					{Distance: 0.432, Duration: 135, Pace: 312.5, AvgHR: 160, Elevation: 8.4},
+				},
+			},
+		},
+		{
+			file: "ride.tcx", activity: models.ActivityBike, points: 31, hrSamples: 31,
+			start: time.Date(2025, 9, 13, 17, 0, 0, 0, time.UTC),
+			want: models.Cardio{
+				Activity: models.ActivityBike, Distance: 3.6, Duration: 450, Pace: 125, AvgHR: 145, Elevation: 15,
+				Splits: []models.Split{
+					{Distance: 1, Duration: 125, Pace: 125, AvgHR: 135, Elevation: 4},
+					{Distance: 1, Duration: 125, Pace: 125, AvgHR: 143, Elevation: 4},
+					{Distance: 1, Duration: 125, Pace: 125, AvgHR: 151, Elevation: 4},
+					{Distance: 0.6, Duration: 75, Pace: 125, AvgHR: 158, Elevation: 3},
+				},
+			},
+		},
+		{
+			file: "row.fit", activity: models.ActivityRow, points: 109, hrSamples: 109,
+			start: time.Date(2025, 9, 14, 8, 0, 0, 0, time.UTC),
+			want: models.Cardio{
+				Activity: models.ActivityRow, Distance: 2.268, Duration: 540, Pace: 238.1, AvgHR: 148,
+				Splits: []models.Split{
+					{Distance: 1, Duration: 238, Pace: 238, AvgHR: 134},
+					{Distance: 1, Duration: 238, Pace: 238, AvgHR: 160},
+					{Distance: 0.268, Duration: 64, Pace: 238.8, AvgHR: 160},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			track := parseFixture(t, tt.file, tt.file)
+			if track.Activity != tt.activity {
+				t.Errorf("activity = %q, want %q", track.Activity, tt.activity)
+			}
+			if len(track.Points) != tt.points {
+				t.Errorf("track points = %d, want %d", len(track.Points), tt.points)
+			}
+			hr := 0
+			for _, p := range track.Points {
+				if p.HeartRate > 0 {
+					hr++
+				}
+			}
+			if hr != tt.hrSamples {
+				t.Errorf("heart rate samples = %d, want %d", hr, tt.hrSamples)
+			}
+			if !track.Start().Equal(tt.start) {
+				t.Errorf("start = %v, want %v", track.Start(), tt.start)
+			}
+
+			got := track.Cardio("")
+			got.Intervals = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cardio() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSniffsFormat(t *testing.T) {
+	for _, file := range []string{"run.gpx", "ride.tcx", "row.fit"} {
+		t.Run(file, func(t *testing.T) {
+			named := parseFixture(t, file, file)
+			sniffed := parseFixture(t, file, "upload.bin")
+			if len(sniffed.Points) != len(named.Points) {
+				t.Errorf("sniffed %d points, want %d", len(sniffed.Points), len(named.Points))
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{"notes.txt", "hello", "is not a GPX, TCX or FIT file"},
+		{"empty.gpx", `<gpx><trk><trkseg><trkpt lat="1" lon="1"><time>2025-09-12T06:30:00Z</time></trkpt></trkseg></trk></gpx>`, "fewer than two timed track points"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.name, strings.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCardioActivityOverride(t *testing.T) {
+	track := parseFixture(t, "run.gpx", "run.gpx")
+	if got := track.Cardio(models.ActivitySwim).Activity; got != models.ActivitySwim {
+		t.Errorf("Cardio(swim).Activity = %q, want swim", got)
+	}
+	track.Activity = ""
+	if got := track.Cardio("").Activity; got != models.ActivityRun {
+		t.Errorf("Cardio(\"\").Activity = %q, want run when the file has no sport", got)
+	}
+}
+
+func TestFillDistances(t *testing.T) {
+	points := []Point{
+		{Lat: 0, Lon: 0, HasPosition: true},
+		{Lat: 0, Lon: 0.01, HasPosition: true},
+		{},
+		{Lat: 0, Lon: 0.02, HasPosition: true},
+	}
+	fillDistances(points)
+	// Nothing is added across a point without a position
+	want := []float64{0, 1111.9, 1111.9, 1111.9}
+	for i, p := range points {
+		if round(p.Distance, 1) != want[i] {
+			t.Errorf("point %d distance = %.1f, want %.1f", i, p.Distance, want[i])
+		}
+	}
+
+	// Recorded distances are kept but never go backwards
+	recorded := []Point{{Distance: 0}, {Distance: 500}, {Distance: 400}, {Distance: 900}}
+	fillDistances(recorded)
+	for i, want := range []float64{0, 500, 500, 900} {
+		if recorded[i].Distance != want {
+			t.Errorf("recorded point %d distance = %v, want %v", i, recorded[i].Distance, want)
+		}
+	}
+}
+
+func parseFixture(t *testing.T, file, name string) Track {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	track, err := Parse(name, f)
+	if err != nil {
+		t.Fatalf("Parse(%s) failed: %v", file, err)
+	}
+	return track
+}
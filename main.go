@@ -1,15 +1,19 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"database/sql"
 	"net/http"
+	"os"
+	"time"
 
 	"fitness-dev/api"
 	"fitness-dev/backend"
 	"fitness-dev/models"
 	"fitness-dev/mock"
+	"fitness-dev/render"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/mattn/go-sqlite3"
@@ -24,6 +28,9 @@ func main() {
 	}
 	defer db.Close()
 
+	// Permanently remove workouts that have sat in the trash too long
+	backend.StartTrashPurger(db, backend.TrashRetention(), time.Hour)
+
 	startCLI(db)
 }
 
@@ -33,7 +40,16 @@ func startCLI(db *sql.DB) {
 		fmt.Println("Welcome to the Fitness App CLI")
 		fmt.Println("1 - Start Server")
 		fmt.Println("2 - View/Edit Workouts")
-		fmt.Println("3 - Exit")
+		fmt.Println("3 - Audit Log")
+		fmt.Println("4 - Weekly Summary")
+		fmt.Println("5 - Measurements")
+		fmt.Println("6 - Settings")
+		fmt.Println("7 - Plate Calculator")
+		fmt.Println("8 - Goals")
+		fmt.Println("9 - Consistency")
+		fmt.Println("10 - Achievements")
+		fmt.Println("11 - Stats")
+		fmt.Println("12 - Exit")
 		fmt.Print("Please enter a number to continue: ")
 
 		var userinput int
@@ -46,6 +62,28 @@ func startCLI(db *sql.DB) {
 			screen.Clear()
 			manageWorkouts(db)
 		case 3:
+			viewAuditLog(db)
+		case 4:
+			weeklySummary(db)
+		case 5:
+			screen.Clear()
+			manageMeasurements(db)
+		case 6:
+			editSettings(db)
+		case 7:
+			screen.Clear()
+			plateCalculator(db)
+		case 8:
+			screen.Clear()
+			manageGoals(db)
+		case 9:
+			showConsistency(db)
+		case 10:
+			showAchievements(db)
+		case 11:
+			screen.Clear()
+			showStats(db)
+		case 12:
 			return
 		default:
 			fmt.Println("Invalid option. Please try again.")
@@ -54,6 +92,15 @@ func startCLI(db *sql.DB) {
 }
 
 func startServer(db *sql.DB) {
+	router := setupRouter(db)
+
+	log.Println("🚀 Server is running on http://localhost:8080")
+	if err := router.Run(":8080"); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+func setupRouter(db *sql.DB) *gin.Engine {
 	// Cors & GIN
 	router := gin.Default()
 	router.Use(cors.Default())
@@ -73,11 +120,138 @@ func startServer(db *sql.DB) {
 	*/
 
 	// API routes
-	router.POST("/workouts", api.CreateWorkoutHandler(db))         // Create a new workout
-	router.GET("/workouts/:day", api.GetWorkoutByDayHandler(db))   // Fetch workout by day
-	router.GET("/workouts", api.GetWorkoutsByDateRangeHandler(db)) // Fetch workouts by date range
-	router.PUT("/workouts/:id", api.UpdateWorkoutHandler(db))      // Update a workout by ID
-	router.DELETE("/workouts/:id", api.DeleteWorkoutHandler(db))   // Delete a workout by ID
+	router.POST("/workouts", api.CreateWorkoutHandler(db))           // Create a new workout
+	router.GET("/workouts/:day", api.GetWorkoutByDayHandler(db))     // Fetch workout by day, or by ID; ?format=md or text renders it
+	router.GET("/workouts", api.GetWorkoutsByDateRangeHandler(db))   // Fetch workouts by date range; ?format=md or text exports them
+	router.GET("/workouts/repeat", api.RepeatLastSessionHandler(db)) // Draft copying the last session with ?exercise=
+	router.PUT("/workouts/:id", api.UpdateWorkoutHandler(db))        // Update a workout by ID
+	router.PATCH("/workouts/:id", api.PatchWorkoutHandler(db))       // Merge-patch a workout by ID
+	router.DELETE("/workouts/:id", api.DeleteWorkoutHandler(db))     // Move a workout to the trash
+	router.POST("/workouts/:id/start", api.StartWorkoutHandler(db))  // Start a planned workout now
+	router.POST("/workouts/:id/skip", api.SkipWorkoutHandler(db))    // Skip a planned or started workout
+
+	// Individual lifts, addressed by position within the workout
+	router.POST("/workouts/:id/lifts", api.AddLiftHandler(db))             // Append a lift
+	router.PUT("/workouts/:id/lifts/:index", api.ReplaceLiftHandler(db))   // Replace a lift
+	router.PATCH("/workouts/:id/lifts/:index", api.PatchLiftHandler(db))   // Merge-patch a lift
+	router.DELETE("/workouts/:id/lifts/:index", api.DeleteLiftHandler(db)) // Remove a lift
+
+	// Cardio, entered directly or imported from GPX, TCX and FIT files
+	router.POST("/workouts/import", api.ImportWorkoutHandler(db))           // New workout from an activity file
+	router.POST("/workouts/:id/cardio", api.AddCardioHandler(db))           // Append a cardio activity
+	router.POST("/workouts/:id/cardio/import", api.ImportCardioHandler(db)) // Append an activity file
+
+	// Templates
+	router.GET("/templates", api.ListTemplatesHandler(db))                          // List templates
+	router.POST("/templates", api.CreateTemplateHandler(db))                        // Create a template
+	router.GET("/templates/:id", api.GetTemplateHandler(db))                        // Fetch a template
+	router.DELETE("/templates/:id", api.DeleteTemplateHandler(db))                  // Delete a template
+	router.POST("/templates/:id/start", api.StartFromTemplateHandler(db))           // Draft workout from a template
+	router.POST("/workouts/:id/template", api.CreateTemplateFromWorkoutHandler(db)) // Save a workout as a template
+
+	// Programs
+	router.GET("/programs", api.ListProgramsHandler(db))                  // List programs
+	router.POST("/programs", api.CreateProgramHandler(db))                // Create a program
+	router.GET("/programs/:id", api.GetProgramHandler(db))                // Fetch a program
+	router.DELETE("/programs/:id", api.DeleteProgramHandler(db))          // Delete a program
+	router.POST("/programs/:id/activate", api.ActivateProgramHandler(db)) // Make a program the active one
+	router.GET("/program/next", api.NextWorkoutHandler(db))               // Next prescribed workout, optionally ?program_id=
+
+	// Training blocks
+	router.GET("/blocks", api.ListBlocksHandler(db))                            // List training blocks
+	router.POST("/blocks", api.CreateBlockHandler(db))                          // Create a training block
+	router.GET("/blocks/:id", api.GetBlockHandler(db))                          // Fetch a training block
+	router.PUT("/blocks/:id", api.UpdateBlockHandler(db))                       // Update a training block
+	router.DELETE("/blocks/:id", api.DeleteBlockHandler(db))                    // Delete a training block
+	router.GET("/blocks/:id/workouts", api.GetBlockWorkoutsHandler(db))         // Workouts within a block
+	router.GET("/blocks/:id/analysis", api.BlockAnalysisHandler(db))            // Weekly stats and recommendations
+	router.GET("/blocks/current/analysis", api.CurrentBlockAnalysisHandler(db)) // Analysis of the block covering today
+	router.GET("/stats/weekly", api.WeeklyStatsHandler(db))                     // Weekly tonnage, intensity and mood, ?weeks=
+
+	// Training load
+	router.GET("/load", api.GetTrainingLoadHandler(db)) // Daily load, ACWR, monotony and strain per athlete
+
+	// Exercises and records
+	router.GET("/exercises", api.ListExercisesHandler(db))     // Exercise loading types
+	router.PUT("/exercises/:name", api.SetExerciseHandler(db)) // Set an exercise's loading type
+	router.GET("/records", api.GetRecordsHandler(db))          // Personal records by effective load, ?athlete= and ?sex= for Wilks/DOTS
+
+	// Measurements, with bodyweight shortcuts
+	router.GET("/measurements", api.ListMeasurementsHandler(db))         // List measurements, ?athlete=, metric, from, to
+	router.POST("/measurements", api.LogMeasurementHandler(db))          // Log a measurement
+	router.GET("/measurements/trend", api.MeasurementTrendHandler(db))   // Moving average, ?metric= and ?alpha=
+	router.GET("/measurements/:id", api.GetMeasurementHandler(db))       // Fetch a measurement
+	router.PUT("/measurements/:id", api.UpdateMeasurementHandler(db))    // Update a measurement
+	router.DELETE("/measurements/:id", api.DeleteMeasurementHandler(db)) // Delete a measurement
+	router.GET("/bodyweight", api.ListBodyweightHandler(db))             // Bodyweight history, ?athlete=
+	router.POST("/bodyweight", api.LogBodyweightHandler(db))             // Log a bodyweight
+	router.DELETE("/bodyweight/:id", api.DeleteBodyweightHandler(db))    // Delete a bodyweight entry
+
+	// Attendance
+	router.GET("/attendance", api.AttendanceHandler(db))        // Streaks, durations, common times and sessions per week, ?athlete= and ?weeks=
+	router.GET("/attendance/calendar", api.CalendarHandler(db)) // Year heatmap data, ?athlete= and ?year=
+
+	// Achievements
+	router.GET("/achievements", api.AchievementsHandler(db)) // Earned and locked badges, ?athlete=
+
+	// Groups, challenges and leaderboards
+	router.GET("/groups", api.ListGroupsHandler(db))                               // Every group, or those of ?member=
+	router.POST("/groups", api.CreateGroupHandler(db))                             // Create a group
+	router.GET("/groups/:id", api.GetGroupHandler(db))                             // Get a group and its members
+	router.PUT("/groups/:id", api.UpdateGroupHandler(db))                          // Rename a group and replace its members
+	router.DELETE("/groups/:id", api.DeleteGroupHandler(db))                       // Delete a group and its challenges
+	router.POST("/groups/:id/members", api.JoinGroupHandler(db))                   // Join a group as the caller
+	router.DELETE("/groups/:id/members", api.LeaveGroupHandler(db))                // Leave a group as the caller
+	router.GET("/groups/:id/leaderboard", api.GroupLeaderboardHandler(db))         // Rank members on ?metric= from ?from= to ?to=, this month by default
+	router.GET("/challenges", api.ListChallengesHandler(db))                       // Every challenge, or those of ?group=
+	router.POST("/challenges", api.CreateChallengeHandler(db))                     // Create a challenge
+	router.GET("/challenges/:id", api.GetChallengeHandler(db))                     // Get a challenge
+	router.PUT("/challenges/:id", api.UpdateChallengeHandler(db))                  // Update a challenge
+	router.DELETE("/challenges/:id", api.DeleteChallengeHandler(db))               // Delete a challenge
+	router.GET("/challenges/:id/leaderboard", api.ChallengeLeaderboardHandler(db)) // Rank the group's members in the challenge
+
+	// Planned sessions and calendars
+	router.GET("/planned", api.ListPlannedHandler(db))                   // Planned sessions of ?athlete=, ?from= and ?to=, ?pending=true for those not completed
+	router.POST("/planned", api.CreatePlannedHandler(db))                // Plan a session, or a program's next workout with program_id
+	router.POST("/planned/import", api.ImportCalendarHandler(db))        // Plan the events of an uploaded iCalendar file
+	router.GET("/planned/:id", api.GetPlannedHandler(db))                // Get a planned session
+	router.DELETE("/planned/:id", api.DeletePlannedHandler(db))          // Delete a planned session
+	router.POST("/planned/:id/complete", api.CompletePlannedHandler(db)) // Link the workout that completed it
+	router.POST("/planned/:id/start", api.StartPlannedHandler(db))       // Start it now as an in-progress workout
+	router.POST("/planned/:id/skip", api.SkipPlannedHandler(db))         // Record it as a skipped workout
+	router.GET("/adherence", api.AdherenceHandler(db))                   // Planned versus completed sessions of ?athlete= from ?from= to ?to=
+	router.GET("/calendar/:user/feed.ics", api.CalendarFeedHandler(db))  // iCalendar feed of a user's workouts and planned sessions
+
+	// Reports
+	router.GET("/reports/monthly", api.MonthlyReportHandler(db)) // Monthly report of ?athlete= for ?month= (MM/YYYY), HTML or ?format=pdf
+
+	// Goals
+	router.GET("/goals", api.ListGoalsHandler(db))         // Progress and projection of every goal, ?athlete=
+	router.POST("/goals", api.CreateGoalHandler(db))       // Create a goal
+	router.GET("/goals/:id", api.GetGoalHandler(db))       // Progress and projection of a goal
+	router.PUT("/goals/:id", api.UpdateGoalHandler(db))    // Update a goal
+	router.DELETE("/goals/:id", api.DeleteGoalHandler(db)) // Delete a goal
+
+	// User settings
+	router.GET("/settings", api.GetSettingsHandler(db))  // Caller's unit and plate preferences
+	router.PUT("/settings", api.SaveSettingsHandler(db)) // Save the caller's preferences
+
+	// Equipment, plate loading and warm-ups
+	router.GET("/equipment", api.GetEquipmentHandler(db))         // Caller's bars, plates and dumbbell increment
+	router.PUT("/equipment", api.SaveEquipmentHandler(db))        // Save the caller's equipment
+	router.GET("/equipment/plates", api.PlateLoadingHandler(db))  // Plates per side for ?weight=, optionally ?bar=
+	router.GET("/equipment/warmup", api.WarmUpHandler(db))        // Warm-up ramp for ?weight=, ?implement= and ?bar=
+	router.POST("/workouts/:id/warmup", api.AddWarmUpHandler(db)) // Insert warm-ups before an exercise
+
+	// Trash
+	router.GET("/trash", api.ListTrashHandler(db))                   // List deleted workouts
+	router.POST("/trash/:id/restore", api.RestoreWorkoutHandler(db)) // Restore a deleted workout
+	router.DELETE("/trash/:id", api.PurgeWorkoutHandler(db))         // Permanently delete a workout
+	router.DELETE("/trash", api.EmptyTrashHandler(db))               // Permanently delete all trash
+
+	// Admin
+	admin := router.Group("/admin", api.RequireAdmin())
+	admin.GET("/audit", api.GetAuditLogHandler(db)) // Audit log, filter by workout_id, from, to
 
 	// Default landing page
 	router.GET("/", func(c *gin.Context) {
@@ -87,12 +261,8 @@ func startServer(db *sql.DB) {
 	router.GET("/favicon.ico", func(c *gin.Context) {
 		c.Status(http.StatusNoContent)
 	})
-	
 
-	log.Println("🚀 Server is running on http://localhost:8080")
-	if err := router.Run(":8080"); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
-	}
+	return router
 }
 
 func manageWorkouts(db *sql.DB) {
@@ -101,8 +271,16 @@ func manageWorkouts(db *sql.DB) {
 		fmt.Println("View/Edit Workouts")
 		fmt.Println("1 - View Workouts")
 		fmt.Println("2 - Add Workout")
-		fmt.Println("3 - Insert Mock Data")
-		fmt.Println("4 - Back")
+		fmt.Println("3 - Delete Workout")
+		fmt.Println("4 - Trash")
+		fmt.Println("5 - Undo Last Action")
+		fmt.Println("6 - Templates")
+		fmt.Println("7 - Programs")
+		fmt.Println("8 - Import Activity File")
+		fmt.Println("9 - Insert Mock Data")
+		fmt.Println("10 - Export Workouts")
+		fmt.Println("11 - Planned Sessions")
+		fmt.Println("12 - Back")
 		fmt.Print("Please enter a number to continue: ")
 
 		var userinput int
@@ -114,10 +292,30 @@ func manageWorkouts(db *sql.DB) {
 		case 2:
 			addWorkout(db)
 		case 3:
+			deleteWorkout(db)
+		case 4:
+			screen.Clear()
+			manageTrash(db)
+		case 5:
+			undoLastAction(db)
+		case 6:
+			screen.Clear()
+			manageTemplates(db)
+		case 7:
+			screen.Clear()
+			managePrograms(db)
+		case 8:
+			importActivity(db)
+		case 9:
 			mock.InsertMockData(db)
 			fmt.Println("Press Enter to continue...")
 			fmt.Scanln() 
-		case 4:
+		case 10:
+			exportWorkouts(db)
+		case 11:
+			screen.Clear()
+			managePlanned(db)
+		case 12:
 			return
 		default:
 			fmt.Println("Invalid option. Please try again.")
@@ -136,13 +334,19 @@ func viewWorkouts(db *sql.DB) {
 		return
 	}
 
-	fmt.Println("Workout: ", workout)
+	workouts := []models.Workout{workout}
+	records, err := backend.RecordLifts(db, workouts)
+	if err != nil {
+		fmt.Printf("Failed to find personal records: %v\n", err)
+	}
+	render.Text(os.Stdout, workouts, render.Options{Units: cliSettings(db).Units, Records: records})
 	fmt.Println("Press Enter to continue...")
 	fmt.Scanln() 
 }
 
 func addWorkout(db *sql.DB) {
-	var workout models.Workout
+	settings := cliSettings(db)
+	workout := models.Workout{Units: settings.Units}
 
 	fmt.Print("Enter date (DD-MM-YYYY): ")
 	fmt.Scan(&workout.Date)
@@ -167,7 +371,7 @@ func addWorkout(db *sql.DB) {
 			break
 		}
 
-		fmt.Print("Enter weight (kg): ")
+		fmt.Printf("Enter weight (%s): ", settings.Units)
 		fmt.Scan(&lift.Weight)
 
 		fmt.Print("Enter reps: ")
@@ -176,16 +380,50 @@ func addWorkout(db *sql.DB) {
 		fmt.Print("Enter sets: ")
 		fmt.Scan(&lift.Sets)
 
-		workout.Lifts = append(workout.Lifts, lift.Name)
-		workout.Weight = append(workout.Weight, lift.Weight)
-		workout.Reps = append(workout.Reps, lift.Reps)
-		workout.Sets = append(workout.Sets, lift.Sets)
+		var details string
+		fmt.Print("Add effort details (RPE, RIR, tempo, rest)? (y/n): ")
+		fmt.Scan(&details)
+		if details == "y" {
+			fmt.Print("Enter RPE (0 to skip): ")
+			fmt.Scan(&lift.RPE)
+
+			rir := -1
+			fmt.Print("Enter reps in reserve (-1 to skip): ")
+			fmt.Scan(&rir)
+			if rir >= 0 {
+				lift.RIR = &rir
+			}
+
+			fmt.Print("Enter tempo, e.g. 3-1-X-0 ('-' to skip): ")
+			fmt.Scan(&lift.Tempo)
+			if lift.Tempo == "-" {
+				lift.Tempo = ""
+			}
+
+			fmt.Print("Enter rest between sets in seconds (0 to skip): ")
+			fmt.Scan(&lift.Rest)
+		}
+
+		backend.AppendLift(&workout, lift)
 	}
 
-	if err := backend.InsertWorkout(db, workout); err != nil {
-		fmt.Printf("Failed to create workout: %v\n", err)
+	id, err := backend.InsertWorkout(db, workout, cliActor)
+	if err != nil {
+		var verr *backend.ValidationError
+		if errors.As(err, &verr) {
+			fmt.Println("Workout is invalid:")
+			for _, f := range verr.Fields {
+				fmt.Printf("  - %s: %s\n", f.Field, f.Message)
+			}
+		} else {
+			fmt.Printf("Failed to create workout: %v\n", err)
+		}
 	} else {
 		fmt.Println("Workout created successfully!")
+		printEarnedBadges(db, id)
+		recordUndo(fmt.Sprintf("add workout on %s", workout.Date), func(db *sql.DB) error {
+			return backend.DeleteWorkout(db, id, cliActor)
+		})
 	}
 
 	fmt.Println("Press Enter to continue...")
@@ -3,29 +3,20 @@ package mock
 import (
 	"fmt"
 	"math/rand"
+	"os"
 	"time"
 	"database/sql"
 
 	"fitness-dev/backend"
 	"fitness-dev/models"
+	"fitness-dev/render"
 )
 
 func DevDisplayGeneratedData(workouts []models.Workout) {
-	for _, workout := range workouts {
-		fmt.Printf("Date: %s\n", workout.Date)
-		fmt.Printf("Time In: %s\n", workout.TimeIn)
-		fmt.Printf("Time Out: %s\n", workout.TimeOut)
-		fmt.Printf("Mood In: %s\n", workout.MoodIn)
-		fmt.Printf("Mood Out: %s\n", workout.MoodOut)
-		fmt.Println("Lifts:")
-		for i := 0; i < len(workout.Lifts); i++ {
-			fmt.Printf("  %s: %.2fkg x %d x %d\n", workout.Lifts[i], workout.Weight[i], workout.Reps[i], workout.Sets[i])
-		}
-		fmt.Println()
-	}
+	render.Text(os.Stdout, workouts, render.Options{})
+	fmt.Println()
 }
 
-var moods = [...]string{"Exhausted", "Tired", "Meh", "Good", "Great", "Energetic"}
 var lifts = [...]string{"Squat", "Bench", "Deadlift", "Press", "Curls", "Lat Pulldown", "Leg Press", "Leg Curl", "Leg Extension", "Tricep Extension", "Tricep Pushdown", "Tricep Dip", "Bicep Curl", "Bicep Hammer Curl", "Bicep Concentration Curl", "Bicep Preacher Curl", "Bicep Reverse Curl", "Bicep Cable Curl", "Bicep Barbell Curl", "Bicep Dumbbell Curl", "Bicep EZ Curl", "Bicep Incline"}
 
 func InsertMockData(db *sql.DB) {
@@ -39,8 +30,8 @@ func InsertMockData(db *sql.DB) {
 			Date:    time.Now().AddDate(0, 0, i).Format("02/01/2006"),
 			TimeIn:  time.Now().Format("15:04"),
 			TimeOut: time.Now().Add(time.Hour).Format("15:04"),
-			MoodIn:  moods[rand.Intn(len(moods))],
-			MoodOut: moods[rand.Intn(len(moods))],
+			MoodIn:  models.Moods[rand.Intn(len(models.Moods))],
+			MoodOut: models.Moods[rand.Intn(len(models.Moods))],
 			Lifts:   make([]string, 5),
 			Weight:  make([]float64, 5),
 			Reps:    make([]int, 5),
@@ -57,7 +48,7 @@ func InsertMockData(db *sql.DB) {
 		workouts = append(workouts, workout)
 
 		// Insert into the database
-		if err := backend.InsertWorkout(db, workout); err != nil {
+		if _, err := backend.InsertWorkout(db, workout, backend.Actor{Name: "mock", Source: backend.SourceCLI}); err != nil {
 			fmt.Printf("Failed to insert workout: %v\n", err)
 		}
 	}
@@ -0,0 +1,47 @@
+package models
+
+// Achievement events, produced when a workout is logged.
+const (
+	EventSessions       = "sessions"        // live sessions the athlete has logged
+	EventSessionTonnage = "session_tonnage" // effective tonnage of the workout, kg
+	EventPersonalRecord = "personal_record" // new best e1RM of an exercise already logged before, kg
+	EventE1RM           = "e1rm"            // best e1RM of an exercise in the workout, kg, including its first session
+	EventDailyStreak    = "daily_streak"    // longest run of consecutive training days
+	EventWeeklyStreak   = "weekly_streak"   // longest run of consecutive training weeks
+)
+
+// AchievementEvents lists the accepted values for AchievementRule.Event.
+var AchievementEvents = []string{EventSessions, EventSessionTonnage, EventPersonalRecord, EventE1RM, EventDailyStreak, EventWeeklyStreak}
+
+// AchievementEvent is something a workout achieved, checked against the
+// achievement rules.
+type AchievementEvent struct {
+	Type     string  `json:"type"`
+	Exercise string  `json:"exercise,omitempty"` // personal records and e1RMs only
+	Value    float64 `json:"value"`
+}
+
+// AchievementRule awards a badge the first time an event reaches a threshold.
+type AchievementRule struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"` // {threshold} is replaced by the threshold, with its unit for weights
+	Event       string  `json:"event"`
+	Exercise    string  `json:"exercise,omitempty"` // limits personal_record and e1rm rules to one exercise
+	Threshold   float64 `json:"threshold"`
+}
+
+// Badge is an achievement rule and, once earned, when and by which workout.
+type Badge struct {
+	AchievementRule
+	Earned    bool   `json:"earned"`
+	EarnedAt  string `json:"earned_at,omitempty"` // RFC 3339
+	WorkoutID int    `json:"workout_id,omitempty"`
+}
+
+// Achievements lists the badges an athlete has earned and those still locked.
+type Achievements struct {
+	Athlete string  `json:"athlete"`
+	Earned  []Badge `json:"earned"` // most recent first
+	Locked  []Badge `json:"locked"` // in rule file order
+}
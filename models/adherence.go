@@ -0,0 +1,50 @@
+package models
+
+// AdherenceMissed is the status of a session planned for a day gone by that
+// was neither done nor skipped.
+const AdherenceMissed = "missed"
+
+// Adherence compares what an athlete planned over a range of days with what
+// they did. Tonnages are in kg.
+type Adherence struct {
+	Athlete          string              `json:"athlete"`
+	From             string              `json:"from"` // DD/MM/YYYY
+	To               string              `json:"to"`   // DD/MM/YYYY
+	Planned          int                 `json:"planned"`
+	Completed        int                 `json:"completed"`
+	Skipped          int                 `json:"skipped"`
+	Missed           int                 `json:"missed"`
+	InProgress       int                 `json:"in_progress"`
+	Upcoming         int                 `json:"upcoming"`          // planned for today or later
+	Unplanned        int                 `json:"unplanned"`         // completed without having been planned
+	SessionAdherence float64             `json:"session_adherence"` // percent of the sessions due that were completed
+	TargetSets       int                 `json:"target_sets"`
+	ActualSets       int                 `json:"actual_sets"`
+	SetAdherence     float64             `json:"set_adherence"` // percent
+	TargetTonnage    float64             `json:"target_tonnage"`
+	ActualTonnage    float64             `json:"actual_tonnage"`
+	VolumeAdherence  float64             `json:"volume_adherence"` // percent
+	Exercises        []ExerciseAdherence `json:"exercises"`
+	Sessions         []AdherenceSession  `json:"sessions"`
+}
+
+// ExerciseAdherence compares the targets of one exercise with what was lifted.
+type ExerciseAdherence struct {
+	Exercise        string  `json:"exercise"`
+	TargetSets      int     `json:"target_sets"`
+	ActualSets      int     `json:"actual_sets"`
+	TargetTonnage   float64 `json:"target_tonnage"`
+	ActualTonnage   float64 `json:"actual_tonnage"`
+	VolumeAdherence float64 `json:"volume_adherence"` // percent, 0 without a target tonnage
+}
+
+// AdherenceSession is one planned session and what became of it.
+type AdherenceSession struct {
+	Date          string  `json:"date"` // DD/MM/YYYY
+	Status        string  `json:"status"`
+	WorkoutID     int     `json:"workout_id,omitempty"`
+	PlannedID     int     `json:"planned_id,omitempty"` // planned session it came from
+	Title         string  `json:"title,omitempty"`
+	TargetTonnage float64 `json:"target_tonnage"`
+	ActualTonnage float64 `json:"actual_tonnage"`
+}
@@ -0,0 +1,48 @@
+package models
+
+// Streak is a run of consecutive days or weeks with at least one session.
+type Streak struct {
+	Current      int    `json:"current"` // runs to today or this week, or to the one before if none yet
+	Longest      int    `json:"longest"`
+	LongestStart string `json:"longest_start,omitempty"` // DD/MM/YYYY
+	LongestEnd   string `json:"longest_end,omitempty"`
+}
+
+// WeekCount is the number of sessions in a week starting on Monday.
+type WeekCount struct {
+	WeekStart string `json:"week_start"` // DD/MM/YYYY
+	Sessions  int    `json:"sessions"`
+}
+
+// TimeSlot is an hour of the day sessions started in.
+type TimeSlot struct {
+	Hour     int     `json:"hour"` // 0-23
+	Sessions int     `json:"sessions"`
+	Share    float64 `json:"share"` // percent of all sessions
+}
+
+// Attendance summarises how consistently an athlete trains.
+type Attendance struct {
+	Athlete         string      `json:"athlete"`
+	Sessions        int         `json:"sessions"`
+	DailyStreak     Streak      `json:"daily_streak"`
+	WeeklyStreak    Streak      `json:"weekly_streak"`
+	AverageDuration float64     `json:"average_duration"` // minutes
+	CommonTimes     []TimeSlot  `json:"common_times"`     // most common first
+	Weeks           []WeekCount `json:"weeks"`            // oldest first, ending this week
+}
+
+// CalendarDay is one day of a training calendar.
+type CalendarDay struct {
+	Date     string  `json:"date"` // DD/MM/YYYY
+	Sessions int     `json:"sessions"`
+	Minutes  float64 `json:"minutes"`
+	Level    int     `json:"level"` // 0 (rest) to 4 (the year's longest days)
+}
+
+// Calendar is a year of training days, for a heatmap.
+type Calendar struct {
+	Athlete string        `json:"athlete"`
+	Year    int           `json:"year"`
+	Days    []CalendarDay `json:"days"`
+}
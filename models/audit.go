@@ -0,0 +1,17 @@
+package models
+
+import "encoding/json"
+
+// AuditEntry records a single change made to a workout or another record.
+type AuditEntry struct {
+	ID        int             `json:"id"`
+	WorkoutID int             `json:"workout_id,omitempty"` // only set for workouts
+	Entity    string          `json:"entity"`               // workout, template, program, ...
+	Key       string          `json:"key"`                  // ID, or user or name for records keyed by one
+	Action    string          `json:"action"`               // create, update, delete, restore or purge
+	Actor     string          `json:"actor"`
+	Source    string          `json:"source"` // api, cli, sync or system
+	CreatedAt string          `json:"created_at"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+}
@@ -0,0 +1,61 @@
+package models
+
+// Training block goals.
+const (
+	GoalHypertrophy = "hypertrophy"
+	GoalStrength    = "strength"
+	GoalPeaking     = "peaking"
+	GoalDeload      = "deload"
+)
+
+// Goals lists the accepted values for Block.Goal.
+var Goals = []string{GoalHypertrophy, GoalStrength, GoalPeaking, GoalDeload}
+
+// Block is a training block (mesocycle) of one athlete. The athlete's
+// workouts belong to the block whose dates cover them; an athlete's blocks
+// never overlap.
+type Block struct {
+	ID              int     `json:"id"`
+	Athlete         string  `json:"athlete"`
+	Name            string  `json:"name"`
+	StartDate       string  `json:"start_date"` // DD/MM/YYYY
+	EndDate         string  `json:"end_date"`   // DD/MM/YYYY, inclusive
+	Goal            string  `json:"goal"`
+	TargetIntensity float64 `json:"target_intensity"` // average % of estimated 1RM
+	TargetTonnage   float64 `json:"target_tonnage"`   // kg per week
+}
+
+// WeekStats summarises one week of training.
+type WeekStats struct {
+	WeekStart string  `json:"week_start"` // DD/MM/YYYY, a Monday
+	Sessions  int     `json:"sessions"`
+	Tonnage   float64 `json:"tonnage"`   // sum of weight x reps x sets, kg
+	Intensity float64 `json:"intensity"` // average % of estimated 1RM per set
+	Mood      float64 `json:"mood"`      // average mood, 0 (Exhausted) to 5 (Energetic)
+
+	CardioSessions int     `json:"cardio_sessions"` // cardio activities logged
+	CardioDistance float64 `json:"cardio_distance"` // km
+	CardioMinutes  float64 `json:"cardio_minutes"`
+}
+
+// Recommendation kinds.
+const (
+	RecommendDeload            = "deload"
+	RecommendIncreaseVolume    = "increase_volume"
+	RecommendReduceVolume      = "reduce_volume"
+	RecommendIncreaseIntensity = "increase_intensity"
+	RecommendReduceIntensity   = "reduce_intensity"
+	RecommendOnTrack           = "on_track"
+)
+
+type Recommendation struct {
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+}
+
+// BlockAnalysis compares what was done in a block against its plan.
+type BlockAnalysis struct {
+	Block           Block            `json:"block"`
+	Weeks           []WeekStats      `json:"weeks"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
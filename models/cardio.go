@@ -0,0 +1,42 @@
+package models
+
+// Cardio activity types.
+const (
+	ActivityRun       = "run"
+	ActivityRow       = "row"
+	ActivityBike      = "bike"
+	ActivitySwim      = "swim"
+	ActivityIntervals = "intervals"
+)
+
+// Activities lists the accepted values for Cardio.Activity.
+var Activities = []string{ActivityRun, ActivityRow, ActivityBike, ActivitySwim, ActivityIntervals}
+
+// Cardio is a conditioning activity within a workout.
+type Cardio struct {
+	Activity  string     `json:"activity"`
+	Distance  float64    `json:"distance,omitempty"`  // km
+	Duration  int        `json:"duration"`            // seconds
+	Pace      float64    `json:"pace,omitempty"`      // seconds per km, computed from distance and duration
+	AvgHR     int        `json:"avg_hr,omitempty"`    // beats per minute
+	Elevation float64    `json:"elevation,omitempty"` // metres climbed
+	Intervals []Interval `json:"intervals,omitempty"`
+	Splits    []Split    `json:"splits,omitempty"` // per km, from imported tracks
+}
+
+// Interval is one work bout of an interval session.
+type Interval struct {
+	Duration int     `json:"duration"`           // seconds of work
+	Distance float64 `json:"distance,omitempty"` // km
+	Rest     int     `json:"rest,omitempty"`     // seconds of rest after the bout
+	AvgHR    int     `json:"avg_hr,omitempty"`
+}
+
+// Split is one kilometre of a tracked activity. The last split may be shorter.
+type Split struct {
+	Distance  float64 `json:"distance"` // km
+	Duration  int     `json:"duration"` // seconds
+	Pace      float64 `json:"pace"`     // seconds per km
+	AvgHR     int     `json:"avg_hr,omitempty"`
+	Elevation float64 `json:"elevation,omitempty"` // metres climbed
+}
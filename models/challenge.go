@@ -0,0 +1,63 @@
+package models
+
+// Group is a team of users who take part in challenges together.
+type Group struct {
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Members []string `json:"members"` // user names, in the order they joined
+}
+
+// Challenge metrics.
+const (
+	ChallengeTonnage      = "tonnage"       // effective tonnage over the window, kg
+	ChallengeSessions     = "sessions"      // workouts logged in the window
+	ChallengeRelativeE1RM = "relative_e1rm" // best e1RM of an exercise per kg of bodyweight
+)
+
+// ChallengeMetrics lists the accepted values for Challenge.Metric.
+var ChallengeMetrics = []string{ChallengeTonnage, ChallengeSessions, ChallengeRelativeE1RM}
+
+// Challenge scoring methods.
+const (
+	ScoringTotal       = "total"       // the highest value in the window wins
+	ScoringImprovement = "improvement" // the largest percent gain on the same length of time before the window wins
+)
+
+// ScoringMethods lists the accepted values for Challenge.Scoring.
+var ScoringMethods = []string{ScoringTotal, ScoringImprovement}
+
+// Challenge is a competition between the members of a group over a window
+// of days.
+type Challenge struct {
+	ID        int    `json:"id"`
+	GroupID   int    `json:"group_id"`
+	Name      string `json:"name"`
+	Metric    string `json:"metric"`
+	Exercise  string `json:"exercise,omitempty"` // relative_e1rm only
+	StartDate string `json:"start_date"`         // DD/MM/YYYY, inclusive
+	EndDate   string `json:"end_date"`           // DD/MM/YYYY, inclusive
+	Scoring   string `json:"scoring"`            // defaults to total
+}
+
+// Reasons a member is left unranked in a leaderboard.
+const (
+	UnrankedNoBaseline   = "no_baseline"   // improvement scoring with nothing in the window before
+	UnrankedNoBodyweight = "no_bodyweight" // relative e1RM without a bodyweight logged
+)
+
+// LeaderboardEntry is one member's standing in a challenge.
+type LeaderboardEntry struct {
+	Rank     int     `json:"rank"` // members with equal scores share a rank; 0 if unranked
+	Athlete  string  `json:"athlete"`
+	Value    float64 `json:"value"`              // the metric over the window
+	Baseline float64 `json:"baseline,omitempty"` // improvement scoring: the metric over the window before
+	Score    float64 `json:"score"`              // value, or percent improvement on the baseline
+	Unranked string  `json:"unranked,omitempty"` // why the member has no rank
+}
+
+// Leaderboard ranks the members of a group in a challenge.
+type Leaderboard struct {
+	Challenge Challenge          `json:"challenge"`
+	Entries   []LeaderboardEntry `json:"entries"` // best first, unranked members last
+	Hidden    int                `json:"hidden"`  // members who opted out of leaderboards
+}
@@ -0,0 +1,68 @@
+package models
+
+// Implements a warm-up ramp can be built for.
+const (
+	ImplementBarbell  = "barbell"
+	ImplementDumbbell = "dumbbell"
+)
+
+// Bar is a barbell the user can load.
+type Bar struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+}
+
+// PlatePair is one size of plate and how many pairs of it are available.
+type PlatePair struct {
+	Weight float64 `json:"weight"` // a single plate
+	Pairs  int     `json:"pairs"`
+}
+
+// Equipment is the kit a user trains with. Its weights are in Units, the unit
+// the kit is marked in, whichever unit requests use.
+type Equipment struct {
+	User              string      `json:"user"`
+	Units             string      `json:"units"`
+	Bars              []Bar       `json:"bars"` // the first is used unless a bar is named
+	Plates            []PlatePair `json:"plates"`
+	DumbbellIncrement float64     `json:"dumbbell_increment"` // step between dumbbells, 0 if there are none
+}
+
+// PlateLoading is how to load a bar for a target weight, in the equipment's
+// unit. Plates are taken heaviest first; a target that cannot be made exactly
+// is rounded down.
+type PlateLoading struct {
+	Units   string    `json:"units"`
+	Target  float64   `json:"target"`
+	Bar     Bar       `json:"bar"`
+	PerSide []float64 `json:"per_side"` // heaviest first
+	Total   float64   `json:"total"`    // bar plus plates
+	Exact   bool      `json:"exact"`
+}
+
+// WarmUpRequest asks for a warm-up ramp. Weight is the working weight; when
+// warm-ups are added to a workout it defaults to the exercise's first set,
+// or its target if nothing was lifted yet.
+type WarmUpRequest struct {
+	Exercise  string  `json:"exercise"`
+	Weight    float64 `json:"weight"`
+	Implement string  `json:"implement"` // barbell (default) or dumbbell
+	Bar       string  `json:"bar"`       // defaults to the equipment's first bar
+}
+
+// WarmUp is a ramp of sets leading up to a working weight.
+type WarmUp struct {
+	Exercise  string      `json:"exercise,omitempty"`
+	Implement string      `json:"implement"`
+	Units     string      `json:"units"`
+	Working   float64     `json:"working"`
+	Sets      []WarmUpSet `json:"sets"`
+}
+
+// WarmUpSet is one warm-up set. Barbell sets include their plate loading.
+type WarmUpSet struct {
+	Weight  float64       `json:"weight"`
+	Reps    int           `json:"reps"`
+	Sets    int           `json:"sets"`
+	Loading *PlateLoading `json:"loading,omitempty"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// Exercise loading types.
+const (
+	LoadingExternal       = "external"        // the logged weight is the load
+	LoadingBodyweight     = "bodyweight"      // bodyweight only, the logged weight is ignored
+	LoadingBodyweightPlus = "bodyweight_plus" // bodyweight plus the logged weight (belt, vest)
+	LoadingAssisted       = "assisted"        // bodyweight minus the logged assistance
+)
+
+// LoadingTypes lists the accepted values for Exercise.Loading.
+var LoadingTypes = []string{LoadingExternal, LoadingBodyweight, LoadingBodyweightPlus, LoadingAssisted}
+
+// Exercise describes how an exercise is loaded. Exercises without a stored
+// definition are treated as external load.
+type Exercise struct {
+	Name               string  `json:"name"`
+	Loading            string  `json:"loading"`
+	BodyweightFraction float64 `json:"bodyweight_fraction"` // share of bodyweight moved, e.g. 0.64 for push-ups
+}
+
+// PersonalRecord is an athlete's best performance in one exercise, using
+// effective load.
+type PersonalRecord struct {
+	Exercise string  `json:"exercise"`
+	Loading  string  `json:"loading"`
+	Weight   float64 `json:"weight"` // heaviest effective load, kg
+	Reps     int     `json:"reps"`
+	Date     string  `json:"date"`
+	E1RM     float64 `json:"e1rm"` // best estimated one rep max, kg
+	E1RMDate string  `json:"e1rm_date"`
+
+	Bodyweight float64 `json:"bodyweight,omitempty"` // kg on e1rm_date
+	Relative   float64 `json:"relative,omitempty"`   // e1rm per kg of bodyweight
+	Wilks      float64 `json:"wilks,omitempty"`      // only with a known sex
+	DOTS       float64 `json:"dots,omitempty"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// Goal kinds.
+const (
+	GoalKindE1RM       = "e1rm"       // estimated 1RM of an exercise, kg
+	GoalKindFrequency  = "frequency"  // sessions per week
+	GoalKindBodyweight = "bodyweight" // kg, to gain or lose
+	GoalKindVolume     = "volume"     // weekly tonnage of an exercise, or of all lifts, kg
+)
+
+// GoalKinds lists the accepted values for Goal.Kind.
+var GoalKinds = []string{GoalKindE1RM, GoalKindFrequency, GoalKindBodyweight, GoalKindVolume}
+
+// Goal statuses.
+const (
+	GoalAchieved = "achieved"
+	GoalOnTrack  = "on_track" // projected to reach the target by the deadline
+	GoalBehind   = "behind"   // projected after the deadline, or not heading towards the target
+	GoalMissed   = "missed"   // the deadline has passed
+	GoalNoData   = "no_data"  // nothing logged to measure it by
+)
+
+// Goal is a target an athlete is working towards.
+type Goal struct {
+	ID        int     `json:"id"`
+	Athlete   string  `json:"athlete"`
+	Kind      string  `json:"kind"`
+	Exercise  string  `json:"exercise,omitempty"` // e1rm goals, and optionally volume goals
+	Target    float64 `json:"target"`
+	StartDate string  `json:"start_date"`         // DD/MM/YYYY, defaults to today
+	Deadline  string  `json:"deadline,omitempty"` // DD/MM/YYYY
+}
+
+// GoalProgress reports how far a goal has come and when it should be reached
+// at the current rate.
+type GoalProgress struct {
+	Goal          Goal    `json:"goal"`
+	Status        string  `json:"status"`
+	Baseline      float64 `json:"baseline"` // value when the goal was set
+	Current       float64 `json:"current"`
+	Progress      float64 `json:"progress"` // percent of the way from baseline to target
+	Trend         float64 `json:"trend"`    // change per week, from a linear regression
+	ProjectedDate string  `json:"projected_date,omitempty"`
+	Points        int     `json:"points"` // data points the trend was fitted on
+}
@@ -0,0 +1,29 @@
+package models
+
+// Training load methods.
+const (
+	LoadTonnage = "tonnage" // sum of weight x reps x sets, kg
+	LoadSRPE    = "srpe"    // session RPE x duration in minutes
+)
+
+// ACWR zones.
+const (
+	ZoneUndertraining = "undertraining" // below 0.8
+	ZoneOptimal       = "optimal"       // 0.8 to 1.3
+	ZoneHigh          = "high"          // 1.3 to 1.5
+	ZoneDanger        = "danger"        // above 1.5
+	ZoneInsufficient  = "insufficient"  // less than 28 days of history
+)
+
+// LoadDay is one day of an athlete's training load time series.
+type LoadDay struct {
+	Date     string  `json:"date"` // DD/MM/YYYY
+	Load     float64 `json:"load"`
+	Sessions int     `json:"sessions"`
+	Acute    float64 `json:"acute"`    // total load over the last 7 days
+	Chronic  float64 `json:"chronic"`  // average weekly load over the last 28 days, or the days of history there are
+	ACWR     float64 `json:"acwr"`     // acute / chronic, 0 while there is no chronic load
+	Monotony float64 `json:"monotony"` // mean / standard deviation of the last 7 daily loads
+	Strain   float64 `json:"strain"`   // acute x monotony
+	Zone     string  `json:"zone,omitempty"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// Common measurement metrics. Other metric names are accepted with any unit.
+const (
+	MetricBodyweight = "bodyweight"
+	MetricBodyFat    = "body_fat"
+	MetricLeanMass   = "lean_mass"
+	MetricWaist      = "waist"
+	MetricHips       = "hips"
+	MetricChest      = "chest"
+	MetricNeck       = "neck"
+	MetricArm        = "arm"
+	MetricThigh      = "thigh"
+	MetricCalf       = "calf"
+)
+
+// Measurement units.
+const (
+	UnitKilogram   = "kg"
+	UnitPound      = "lb"
+	UnitCentimetre = "cm"
+	UnitInch       = "in"
+	UnitPercent    = "%"
+)
+
+// MetricUnits lists the accepted units of each common metric, the default first.
+var MetricUnits = map[string][]string{
+	MetricBodyweight: {UnitKilogram, UnitPound},
+	MetricLeanMass:   {UnitKilogram, UnitPound},
+	MetricBodyFat:    {UnitPercent},
+	MetricWaist:      {UnitCentimetre, UnitInch},
+	MetricHips:       {UnitCentimetre, UnitInch},
+	MetricChest:      {UnitCentimetre, UnitInch},
+	MetricNeck:       {UnitCentimetre, UnitInch},
+	MetricArm:        {UnitCentimetre, UnitInch},
+	MetricThigh:      {UnitCentimetre, UnitInch},
+	MetricCalf:       {UnitCentimetre, UnitInch},
+}
+
+// Measurement is one body measurement taken on a day. An athlete has at most
+// one value per metric and day.
+type Measurement struct {
+	ID      int     `json:"id"`
+	Athlete string  `json:"athlete"`
+	Date    string  `json:"date"` // DD/MM/YYYY
+	Metric  string  `json:"metric"`
+	Value   float64 `json:"value"`
+	Unit    string  `json:"unit"`
+	Trend   float64 `json:"trend,omitempty"` // exponential moving average, in trend responses
+}
@@ -0,0 +1,27 @@
+package models
+
+// PlannedSession is a training session scheduled for a day, planned from a
+// program or imported from a calendar. Starting or skipping it creates a
+// workout linked to it, as does linking a workout logged for it.
+type PlannedSession struct {
+	ID         int    `json:"id"`
+	Athlete    string `json:"athlete"`
+	Date       string `json:"date"`               // DD/MM/YYYY
+	TimeIn     string `json:"time_in,omitempty"`  // HH:MM, empty for the whole day
+	TimeOut    string `json:"time_out,omitempty"` // HH:MM
+	Title      string `json:"title"`
+	Notes      string `json:"notes,omitempty"`
+	ProgramID  int    `json:"program_id,omitempty"`  // program the session was planned from
+	TemplateID int    `json:"template_id,omitempty"` // template to perform
+	UID        string `json:"uid,omitempty"`         // UID of the calendar event it was imported from
+	Status     string `json:"status"`                // planned until a workout is linked, then the workout's status
+	WorkoutID  int    `json:"workout_id,omitempty"`  // set once started, skipped or completed
+}
+
+// PlannedImport reports what importing a calendar did.
+type PlannedImport struct {
+	Created  int              `json:"created"`
+	Updated  int              `json:"updated"` // events imported before, matched by UID
+	Skipped  int              `json:"skipped"` // already started, cancelled, or exported from here
+	Sessions []PlannedSession `json:"sessions"`
+}
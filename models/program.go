@@ -0,0 +1,41 @@
+package models
+
+// Progression rule kinds.
+const (
+	RuleLinear     = "linear"     // add weight on success, deload after repeated failures
+	RuleDouble     = "double"     // add reps up to a ceiling, then add weight
+	RulePercentage = "percentage" // 5/3/1 style percentages of a training max
+)
+
+// Program is a rotation of templates plus rules for progressing exercises.
+type Program struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	StartDate   string            `json:"start_date"`   // DD/MM/YYYY, history before this is ignored
+	Active      bool              `json:"active"`       // only one program is active at a time
+	TemplateIDs []int             `json:"template_ids"` // performed in order, then repeated
+	Rules       []ProgressionRule `json:"rules"`
+}
+
+// ProgressionRule describes how one exercise's prescription changes over time.
+type ProgressionRule struct {
+	Exercise      string  `json:"exercise"`
+	Kind          string  `json:"kind"`                     // linear, double or percentage
+	Increment     float64 `json:"increment"`                // kg added on success, or per cycle for percentage
+	DeloadAfter   int     `json:"deload_after,omitempty"`   // consecutive failures before a deload
+	DeloadPercent float64 `json:"deload_percent,omitempty"` // e.g. 10 takes 10% off
+	RepMin        int     `json:"rep_min,omitempty"`        // double progression rep range
+	RepMax        int     `json:"rep_max,omitempty"`
+	TrainingMax   float64 `json:"training_max,omitempty"` // percentage programs
+	StartWeight   float64 `json:"start_weight,omitempty"` // first prescription, defaults to the template target
+	TargetRPE     float64 `json:"target_rpe,omitempty"`   // autoregulates weight increases when sessions log RPE or RIR
+}
+
+// Prescription is the next workout a program calls for.
+type Prescription struct {
+	ProgramID  int      `json:"program_id"`
+	TemplateID int      `json:"template_id"`
+	Template   string   `json:"template"`
+	Workout    Workout  `json:"workout"` // unsaved draft
+	Notes      []string `json:"notes"`   // why each weight was chosen
+}
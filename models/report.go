@@ -0,0 +1,43 @@
+package models
+
+// MonthLayout is the format of report months, MM/YYYY.
+const MonthLayout = "01/2006"
+
+// ReportSession is one workout in a monthly report.
+type ReportSession struct {
+	WorkoutID int      `json:"workout_id"`
+	Date      string   `json:"date"`
+	TimeIn    string   `json:"time_in"`
+	TimeOut   string   `json:"time_out"`
+	Minutes   float64  `json:"minutes"` // 0 if the times cannot be read
+	MoodIn    string   `json:"mood_in"`
+	MoodOut   string   `json:"mood_out"`
+	Exercises []string `json:"exercises"` // in the order logged, without repeats
+	Sets      int      `json:"sets"`
+	Tonnage   float64  `json:"tonnage"` // effective, kg
+	Records   int      `json:"records"` // lifts that set a personal record
+}
+
+// ReportRecord is a personal record set during a report's month.
+type ReportRecord struct {
+	Date     string  `json:"date"`
+	Exercise string  `json:"exercise"`
+	Weight   float64 `json:"weight"` // effective load, kg
+	Reps     int     `json:"reps"`
+	E1RM     float64 `json:"e1rm"` // kg
+}
+
+// MonthlyReport summarises an athlete's training over a calendar month, for
+// coaches.
+type MonthlyReport struct {
+	Athlete   string          `json:"athlete"`
+	Month     string          `json:"month"`      // MM/YYYY
+	StartDate string          `json:"start_date"` // DD/MM/YYYY, the first of the month
+	EndDate   string          `json:"end_date"`   // DD/MM/YYYY, the last of the month
+	Sessions  []ReportSession `json:"sessions"`   // oldest first
+	Minutes   float64         `json:"minutes"`
+	Tonnage   float64         `json:"tonnage"` // effective, kg
+	Weeks     []SeriesPoint   `json:"weeks"`   // tonnage of the month's sessions in each week, dated on its Monday
+	Records   []ReportRecord  `json:"records"`
+	Goals     []GoalProgress  `json:"goals"` // as of the end of the month, or today
+}
@@ -0,0 +1,11 @@
+package models
+
+// Settings are a user's preferences, keyed by the name they log in with
+// (the X-User header or the CLI user).
+type Settings struct {
+	User          string  `json:"user"`
+	Units         string  `json:"units"`          // kg or lb, used when a request does not ask for a unit
+	SmallestPlate float64 `json:"smallest_plate"` // lightest plate owned in Units; loads go up in pairs of it
+
+	LeaderboardOptOut bool `json:"leaderboard_opt_out"` // left out of group leaderboards
+}
@@ -0,0 +1,17 @@
+package models
+
+// SeriesPoint is one value of a time series, for charts.
+type SeriesPoint struct {
+	Date  string  `json:"date"` // DD/MM/YYYY
+	Value float64 `json:"value"`
+}
+
+// ExerciseTrend is an exercise's best estimated 1RM in each session it was
+// logged in, oldest first.
+type ExerciseTrend struct {
+	Exercise string        `json:"exercise"`
+	Sessions int           `json:"sessions"`
+	Best     float64       `json:"best"`   // kg
+	Latest   float64       `json:"latest"` // kg, in the last session
+	Points   []SeriesPoint `json:"points"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// Template is a named, reusable list of exercises, e.g. "Push Day".
+type Template struct {
+	ID        int                `json:"id"`
+	Name      string             `json:"name"`
+	Exercises []TemplateExercise `json:"exercises"` // in the order they are performed
+}
+
+// TemplateExercise is one exercise in a template with its targets.
+type TemplateExercise struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"` // target weight (kg)
+	Reps   int     `json:"reps"`   // target reps
+	Sets   int     `json:"sets"`   // target sets
+}
@@ -1,5 +1,25 @@
 package models
 
+const (
+	DateLayout = "02/01/2006" // DD/MM/YYYY
+	TimeLayout = "15:04"      // HH:MM
+)
+
+// Workout statuses. Only completed workouts count towards records, streaks,
+// load and the other statistics.
+const (
+	StatusPlanned    = "planned"
+	StatusInProgress = "in-progress"
+	StatusCompleted  = "completed"
+	StatusSkipped    = "skipped"
+)
+
+// Statuses lists the accepted values for Workout.Status.
+var Statuses = []string{StatusPlanned, StatusInProgress, StatusCompleted, StatusSkipped}
+
+// Moods lists the accepted values for MoodIn and MoodOut.
+var Moods = []string{"Exhausted", "Tired", "Meh", "Good", "Great", "Energetic"}
+
 type Workout struct {
 	ID      int       `json:"id"`
 	Date    string    `json:"date"`    // (e.g., "01/10/2023")
@@ -11,11 +31,36 @@ type Workout struct {
 	Weight  []float64 `json:"weight"`
 	Reps    []int     `json:"reps"`
 	Sets    []int     `json:"sets"`
+	RPE     []float64 `json:"rpe,omitempty"`   // per lift, 0 if not recorded
+	RIR     []*int    `json:"rir,omitempty"`   // reps in reserve per lift, null if not recorded
+	Tempo   []string  `json:"tempo,omitempty"` // per lift, e.g. "3-1-1-0"
+	Rest    []int     `json:"rest,omitempty"`  // seconds between sets per lift, 0 if not recorded
+	Units      string   `json:"units,omitempty"`       // unit of every weight in the document, kg if empty
+	EntryUnits []string `json:"entry_units,omitempty"` // unit each lift was entered in, kg if empty
+	Cardio  []Cardio  `json:"cardio,omitempty"` // conditioning done alongside (or instead of) lifts
+	Status       string    `json:"status"`                  // planned, in-progress, completed or skipped; completed if empty
+	TargetWeight []float64 `json:"target_weight,omitempty"` // planned weight per lift, 0 if no target
+	TargetReps   []int     `json:"target_reps,omitempty"`   // planned reps per set per lift, 0 if no target
+	TargetSets   []int     `json:"target_sets,omitempty"`   // planned sets per lift, 0 if no target
+	WarmUp       []bool    `json:"warm_up,omitempty"`       // per lift, true for warm-up sets, which count towards no statistics
+	Athlete    string  `json:"athlete"`               // who trained, defaults to the creator
+	SessionRPE float64 `json:"session_rpe,omitempty"` // whole-session effort 1-10, 0 if not recorded
+	Version   int       `json:"version"`    // incremented on every change, used for ETags
+	UpdatedAt string    `json:"updated_at"` // RFC 3339, UTC
+	DeletedAt string    `json:"deleted_at,omitempty"` // set while the workout is in the trash
 }
 
 type Lift struct {
-    Name   string
-    Weight float64
-    Reps   int
-    Sets   int
+    Name   string  `json:"name"`
+    Weight float64 `json:"weight"`
+    Reps   int     `json:"reps"`
+    Sets   int     `json:"sets"`
+    RPE    float64 `json:"rpe,omitempty"`
+    RIR    *int    `json:"rir,omitempty"`
+    Tempo  string  `json:"tempo,omitempty"`
+    Rest   int     `json:"rest,omitempty"` // seconds
+    TargetWeight float64 `json:"target_weight,omitempty"`
+    TargetReps   int     `json:"target_reps,omitempty"`
+    TargetSets   int     `json:"target_sets,omitempty"`
+    WarmUp       bool    `json:"warm_up,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+// Markdown writes each workout as a section with a table of its lifts.
+// Lifts that set a personal record are marked **PR**.
+func Markdown(w io.Writer, workouts []models.Workout, opts Options) error {
+	units := opts.units()
+	for n, workout := range workouts {
+		if n > 0 {
+			fmt.Fprintln(w)
+		}
+		s := Summarize(workout, opts)
+
+		title := workout.Date
+		if day := weekday(workout.Date); day != "" {
+			title = day + " " + title
+		}
+		fmt.Fprintf(w, "## %s\n\n", title)
+		details := []string{fmt.Sprintf("**%s–%s**", workout.TimeIn, workout.TimeOut)}
+		if s.Duration > 0 {
+			details[0] += " (" + Duration(s.Duration) + ")"
+		}
+		if workout.Athlete != "" {
+			details = append(details, "Athlete: "+escape(workout.Athlete))
+		}
+		if m := moods(workout); m != "" {
+			details = append(details, "Mood: "+m)
+		}
+		if workout.SessionRPE > 0 {
+			details = append(details, fmt.Sprintf("Session RPE %g", workout.SessionRPE))
+		}
+		if s := status(workout); s != "" {
+			details = append(details, "Status: "+s)
+		}
+		fmt.Fprintln(w, strings.Join(details, " · "))
+
+		if len(workout.Lifts) > 0 {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "| Exercise | Weight | Sets × Reps | Tonnage | Notes |")
+			fmt.Fprintln(w, "|----------|-------:|-------------|--------:|-------|")
+			for i := range workout.Lifts {
+				lift := backend.GetLift(workout, i)
+				load := weight(workout, lift.Weight, opts)
+				name := escape(lift.Name)
+				if isRecord(workout, i, opts) {
+					name += " **PR**"
+				}
+				fmt.Fprintf(w, "| %s | %s | %d × %d | %s | %s |\n", name, Weight(load, units), lift.Sets, lift.Reps,
+					Weight(load*float64(lift.Reps*lift.Sets), units), escape(liftDetails(workout, lift, opts)))
+			}
+		}
+		if len(workout.Cardio) > 0 {
+			fmt.Fprintln(w)
+			for _, c := range workout.Cardio {
+				fmt.Fprintln(w, "- "+cardioLine(c))
+			}
+		}
+		if _, err := fmt.Fprintf(w, "\n**Total:** %s\n", totalLine(s, units, " · ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escape keeps user text from breaking Markdown tables and emphasis.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,152 @@
+package render
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 in points, and the margin kept around each page.
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	pageMargin = 50
+)
+
+// pdfDocument draws pages of text, lines and filled rectangles and writes
+// them as a PDF. Text uses the standard Helvetica fonts every reader has, so
+// nothing is embedded. Positions are in points from the top left.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64 // where the next block starts on the current page
+}
+
+func newPDF() *pdfDocument {
+	d := &pdfDocument{}
+	d.addPage()
+	return d
+}
+
+func (d *pdfDocument) addPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pageMargin
+}
+
+// need starts a new page unless h more points fit on this one.
+func (d *pdfDocument) need(h float64) {
+	if d.y+h > pageHeight-pageMargin {
+		d.addPage()
+	}
+}
+
+func (d *pdfDocument) color(c [3]float64) {
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f RG %.3f %.3f %.3f rg\n", c[0], c[1], c[2], c[0], c[1], c[2])
+}
+
+// text writes s with its baseline at y.
+func (d *pdfDocument) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT /%s %g Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, pageHeight-y, pdfString(s))
+}
+
+func (d *pdfDocument) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(d.page, "%g w %.2f %.2f m %.2f %.2f l S\n", width, x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+// polyline strokes a line through points.
+func (d *pdfDocument) polyline(points []point, width float64) {
+	if len(points) < 2 {
+		return
+	}
+	fmt.Fprintf(d.page, "%g w %.2f %.2f m", width, points[0].x, pageHeight-points[0].y)
+	for _, p := range points[1:] {
+		fmt.Fprintf(d.page, " %.2f %.2f l", p.x, pageHeight-p.y)
+	}
+	fmt.Fprintln(d.page, " S")
+}
+
+// rect fills a rectangle whose top left corner is at x, y.
+func (d *pdfDocument) rect(x, y, w, h float64) {
+	fmt.Fprintf(d.page, "%.2f %.2f %.2f %.2f re f\n", x, pageHeight-y-h, w, h)
+}
+
+// writeTo writes the document: the catalog, the page tree, the two fonts,
+// then each page and its content stream, and the cross-reference table.
+func (d *pdfDocument) writeTo(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	var offsets []int
+	written := 0
+	write := func(format string, args ...any) {
+		n, _ := fmt.Fprintf(out, format, args...)
+		written += n
+	}
+	object := func(body string) {
+		offsets = append(offsets, written)
+		write("%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	write("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %g %g] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := written
+	write("xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		write("%010d 00000 n \n", offset)
+	}
+	write("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Flush()
+}
+
+// winAnsi maps the punctuation used in reports to WinAnsiEncoding; Latin-1
+// letters map to themselves.
+var winAnsi = map[rune]string{
+	'€': "\x80", '…': "\x85", '‘': "\x91", '’': "\x92", '“': "\x93", '”': "\x94",
+	'•': "\x95", '–': "\x96", '—': "\x97", '→': "->", '★': "*", '●': "\x95",
+}
+
+// pdfString encodes s as the body of a PDF string literal in WinAnsiEncoding.
+// Characters the fonts cannot show become '?'.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		case winAnsi[r] != "":
+			b.WriteString(winAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// textWidth estimates the width of s in Helvetica, for centring short
+// labels: digits and most letters are a little over half the font size.
+func textWidth(s string, size float64) float64 {
+	return float64(len([]rune(s))) * size * 0.55
+}
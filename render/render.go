@@ -0,0 +1,217 @@
+// Package render formats workouts for people: aligned plain-text tables and
+// summaries for the CLI, and Markdown for the API and exports.
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+// Options control how workouts are rendered.
+type Options struct {
+	Units   string         // unit weights are shown in, kg if empty
+	Records map[int][]bool // per workout ID, the lifts that set a personal record, from backend.RecordLifts
+}
+
+func (o Options) units() string {
+	if o.Units == "" {
+		return models.UnitKilogram
+	}
+	return o.Units
+}
+
+// Summary is what a workout adds up to.
+type Summary struct {
+	Duration time.Duration // 0 if the times cannot be read
+	Sets     int
+	Reps     int
+	Tonnage  float64 // logged weight x reps x sets, in Options.Units
+	Records  int     // lifts that set a personal record
+}
+
+// Summarize adds up a workout, leaving out warm-up sets.
+func Summarize(w models.Workout, opts Options) Summary {
+	var s Summary
+	in, inErr := time.Parse(models.TimeLayout, w.TimeIn)
+	out, outErr := time.Parse(models.TimeLayout, w.TimeOut)
+	if inErr == nil && outErr == nil {
+		s.Duration = backend.SessionDuration(in, out)
+	}
+	for i := range w.Lifts {
+		lift := backend.GetLift(w, i)
+		if lift.WarmUp {
+			continue
+		}
+		s.Sets += lift.Sets
+		s.Reps += lift.Reps * lift.Sets
+		s.Tonnage += weight(w, lift.Weight, opts) * float64(lift.Reps*lift.Sets)
+		if isRecord(w, i, opts) {
+			s.Records++
+		}
+	}
+	return s
+}
+
+// weight converts a weight of w into the rendered unit.
+func weight(w models.Workout, value float64, opts Options) float64 {
+	from := w.Units
+	if from == "" {
+		from = models.UnitKilogram
+	}
+	return backend.ConvertWeight(value, from, opts.units())
+}
+
+func isRecord(w models.Workout, i int, opts Options) bool {
+	marks := opts.Records[w.ID]
+	return i < len(marks) && marks[i]
+}
+
+// Weight formats a weight with its unit, without trailing zeros.
+func Weight(value float64, units string) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64) + units
+}
+
+// Seconds formats a duration as M:SS, or H:MM:SS from an hour up.
+func Seconds(s int) string {
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// Duration formats a session length as 1h 05m, or 45m under an hour.
+func Duration(d time.Duration) string {
+	m := int(d.Round(time.Minute).Minutes())
+	if m >= 60 {
+		return fmt.Sprintf("%dh %02dm", m/60, m%60)
+	}
+	return fmt.Sprintf("%dm", m)
+}
+
+// Truncate shortens s to at most n characters, marking the cut with an
+// ellipsis.
+func Truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+// weekday names the day of a workout, or returns "" if the date is invalid.
+func weekday(date string) string {
+	day, err := time.Parse(models.DateLayout, date)
+	if err != nil {
+		return ""
+	}
+	return day.Weekday().String()
+}
+
+// moods describes the mood before and after a workout.
+func moods(w models.Workout) string {
+	return moodRange(w.MoodIn, w.MoodOut)
+}
+
+func moodRange(in, out string) string {
+	switch {
+	case in != "" && out != "":
+		return in + " → " + out
+	case in != "":
+		return in + " →"
+	case out != "":
+		return "→ " + out
+	}
+	return ""
+}
+
+// liftNotes lists the optional details of a lift: effort, tempo and rest.
+func liftNotes(lift models.Lift) string {
+	var notes []string
+	if lift.WarmUp {
+		notes = append(notes, "warm-up")
+	}
+	if lift.RPE > 0 {
+		notes = append(notes, "RPE "+strconv.FormatFloat(lift.RPE, 'f', -1, 64))
+	}
+	if lift.RIR != nil {
+		notes = append(notes, fmt.Sprintf("RIR %d", *lift.RIR))
+	}
+	if lift.Tempo != "" {
+		notes = append(notes, "tempo "+lift.Tempo)
+	}
+	if lift.Rest > 0 {
+		notes = append(notes, "rest "+Seconds(lift.Rest))
+	}
+	return strings.Join(notes, ", ")
+}
+
+// target describes a lift's planned target, e.g. "3 x 5 @ 100kg", or
+// returns "" if it had none.
+func target(w models.Workout, lift models.Lift, opts Options) string {
+	if lift.TargetSets == 0 && lift.TargetReps == 0 && lift.TargetWeight == 0 {
+		return ""
+	}
+	s := fmt.Sprintf("%d x %d", lift.TargetSets, lift.TargetReps)
+	if lift.TargetWeight > 0 {
+		s += " @ " + Weight(weight(w, lift.TargetWeight, opts), opts.units())
+	}
+	return s
+}
+
+// liftDetails is liftNotes followed by the lift's target, if any.
+func liftDetails(w models.Workout, lift models.Lift, opts Options) string {
+	notes := liftNotes(lift)
+	if t := target(w, lift, opts); t != "" {
+		notes = strings.TrimPrefix(notes+", target "+t, ", ")
+	}
+	return notes
+}
+
+// status names the status of a workout not completed, or returns "".
+func status(w models.Workout) string {
+	if w.Status == "" || w.Status == models.StatusCompleted {
+		return ""
+	}
+	return w.Status
+}
+
+// cardioLine describes a cardio activity in one line.
+func cardioLine(c models.Cardio) string {
+	s := c.Activity
+	if c.Distance > 0 {
+		s += fmt.Sprintf(" %.2fkm", c.Distance)
+	}
+	s += " in " + Seconds(c.Duration)
+	if c.Pace > 0 {
+		s += fmt.Sprintf(" (%s/km)", Seconds(int(c.Pace)))
+	}
+	if c.AvgHR > 0 {
+		s += fmt.Sprintf(", %d bpm", c.AvgHR)
+	}
+	if len(c.Intervals) > 0 {
+		s += fmt.Sprintf(", %d intervals", len(c.Intervals))
+	}
+	return s
+}
+
+// totalLine describes a summary in one line, e.g. "7 sets, 55 reps, 5300kg, 1 PR".
+func totalLine(s Summary, units, sep string) string {
+	parts := []string{plural(s.Sets, "set"), plural(s.Reps, "rep"), Weight(s.Tonnage, units)}
+	if s.Records > 0 {
+		parts = append(parts, plural(s.Records, "PR"))
+	}
+	return strings.Join(parts, sep)
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,238 @@
+package render
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+//go:embed templates/report.html
+var reportTemplate string
+
+var reportHTML = template.Must(template.New("report").Funcs(template.FuncMap{
+	"weight":  kg,
+	"minutes": minutes,
+	"moods":   moodRange,
+	"join":    strings.Join,
+	"goal":    goalName,
+	"target":  goalValue,
+	"status":  goalStatus,
+}).Parse(reportTemplate))
+
+// Size of report charts, in SVG units or PDF points, and the room left for
+// their axis labels.
+const (
+	chartLeft   = 70
+	chartTop    = 16
+	chartBottom = 22
+)
+
+// point is a position in a chart, with y growing downwards.
+type point struct{ x, y float64 }
+
+// bar is one bar of a chart and its labels.
+type bar struct {
+	x, y, w, h   float64
+	label, value string
+}
+
+// ReportHTML writes a monthly report as a self-contained HTML page, with its
+// charts drawn as inline SVG.
+func ReportHTML(w io.Writer, report models.MonthlyReport, opts Options) error {
+	units := opts.units()
+	page := struct {
+		models.MonthlyReport
+		Title       string
+		Units       string
+		Summary     string
+		VolumeChart template.HTML
+		MoodChart   template.HTML
+	}{
+		MonthlyReport: report,
+		Title:         MonthTitle(report.Month),
+		Units:         units,
+		Summary:       reportSummary(report, units),
+		VolumeChart:   volumeSVG(report.Weeks, units, 640, 200),
+		MoodChart:     moodSVG(report.Sessions, 640, 200),
+	}
+	return reportHTML.Execute(w, page)
+}
+
+// MonthTitle names a MM/YYYY month, e.g. "October 2026".
+func MonthTitle(month string) string {
+	t, err := time.Parse(models.MonthLayout, month)
+	if err != nil {
+		return month
+	}
+	return t.Format("January 2006")
+}
+
+// reportSummary describes a month in one line.
+func reportSummary(r models.MonthlyReport, units string) string {
+	parts := []string{plural(len(r.Sessions), "session"), minutes(r.Minutes), kg(r.Tonnage, units) + " lifted"}
+	if len(r.Records) > 0 {
+		parts = append(parts, plural(len(r.Records), "PR"))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// ReportFilename names the file of a report, e.g. report-alice-2026-10.pdf.
+func ReportFilename(report models.MonthlyReport, ext string) string {
+	athlete := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '"' || r == ' ' {
+			return '-'
+		}
+		return r
+	}, strings.ToLower(report.Athlete))
+	month := report.Month[3:] + "-" + report.Month[:2]
+	return fmt.Sprintf("report-%s-%s.%s", athlete, month, ext)
+}
+
+// kg formats a weight given in kg in the rendered unit.
+func kg(value float64, units string) string {
+	return Weight(backend.ConvertWeight(value, models.UnitKilogram, units), units)
+}
+
+// minutes formats a number of minutes like Duration.
+func minutes(m float64) string {
+	return Duration(time.Duration(m * float64(time.Minute)))
+}
+
+func goalName(g models.Goal) string {
+	name := map[string]string{
+		models.GoalKindE1RM:       "e1RM",
+		models.GoalKindFrequency:  "Sessions per week",
+		models.GoalKindBodyweight: "Bodyweight",
+		models.GoalKindVolume:     "Weekly volume",
+	}[g.Kind]
+	if g.Exercise != "" {
+		name += " " + g.Exercise
+	}
+	return name
+}
+
+// goalValue formats a target or measure of a goal: sessions for frequency
+// goals, a weight otherwise.
+func goalValue(kind string, value float64, units string) string {
+	if kind == models.GoalKindFrequency {
+		return fmt.Sprintf("%g/week", math.Round(value*100)/100)
+	}
+	return kg(value, units)
+}
+
+func goalStatus(status string) string {
+	return strings.ReplaceAll(status, "_", " ")
+}
+
+// barLayout places one bar per week in a chart of width by height, scaled
+// so the largest fills the plot.
+func barLayout(weeks []models.SeriesPoint, units string, width, height float64) []bar {
+	var hi float64
+	for _, p := range weeks {
+		hi = max(hi, p.Value)
+	}
+	plot := height - chartTop - chartBottom
+	slot := (width - chartLeft) / float64(max(len(weeks), 1))
+	bars := make([]bar, len(weeks))
+	for i, p := range weeks {
+		h := 0.0
+		if hi > 0 {
+			h = p.Value / hi * plot
+		}
+		bars[i] = bar{
+			x: chartLeft + float64(i)*slot + slot*0.2, y: chartTop + plot - h, w: slot * 0.6, h: h,
+			label: p.Date[:5], value: kg(math.Round(p.Value), units),
+		}
+	}
+	return bars
+}
+
+// moodLayout places the mood before and after each session in a chart of
+// width by height, from the lowest mood at the bottom to the best at the
+// top. Sessions without a known mood leave a gap.
+func moodLayout(sessions []models.ReportSession, width, height float64) (in, out []point) {
+	plot := height - chartTop - chartBottom
+	slot := (width - chartLeft) / float64(max(len(sessions), 1))
+	for i, s := range sessions {
+		x := chartLeft + (float64(i)+0.5)*slot
+		if y, ok := moodY(s.MoodIn, plot); ok {
+			in = append(in, point{x, y})
+		}
+		if y, ok := moodY(s.MoodOut, plot); ok {
+			out = append(out, point{x, y})
+		}
+	}
+	return in, out
+}
+
+func moodY(mood string, plot float64) (float64, bool) {
+	i := slices.Index(models.Moods, mood)
+	if i < 0 {
+		return 0, false
+	}
+	return chartTop + plot - float64(i)/float64(len(models.Moods)-1)*plot, true
+}
+
+// Chart colours, as RGB from 0 to 1.
+var (
+	barColor     = [3]float64{0.27, 0.51, 0.71}
+	moodInColor  = [3]float64{0.6, 0.6, 0.6}
+	moodOutColor = [3]float64{0.85, 0.45, 0.1}
+	axisColor    = [3]float64{0.4, 0.4, 0.4}
+)
+
+func svgColor(c [3]float64) string {
+	return fmt.Sprintf("#%02x%02x%02x", int(c[0]*255), int(c[1]*255), int(c[2]*255))
+}
+
+// volumeSVG draws weekly tonnage as a bar chart.
+func volumeSVG(weeks []models.SeriesPoint, units string, width, height float64) template.HTML {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %g %g" role="img" aria-label="Tonnage per week">`, width, height)
+	base := height - chartBottom
+	fmt.Fprintf(&b, `<line x1="%d" y1="%g" x2="%g" y2="%g" stroke="%s"/>`, chartLeft, base, width, base, svgColor(axisColor))
+	fmt.Fprintf(&b, `<text x="0" y="%d" font-size="11">Tonnage</text>`, chartTop)
+	for _, r := range barLayout(weeks, units, width, height) {
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`, r.x, r.y, r.w, r.h, svgColor(barColor))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="10" text-anchor="middle">%s</text>`, r.x+r.w/2, r.y-3, template.HTMLEscapeString(r.value))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-size="10" text-anchor="middle">%s</text>`, r.x+r.w/2, height-6, r.label)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// moodSVG draws the mood before and after each session as two lines.
+func moodSVG(sessions []models.ReportSession, width, height float64) template.HTML {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %g %g" role="img" aria-label="Mood per session">`, width, height)
+	plot := height - chartTop - chartBottom
+	for _, mood := range models.Moods {
+		y, _ := moodY(mood, plot)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%g" y2="%.1f" stroke="#e5e5e5"/>`, chartLeft, y, width, y)
+		fmt.Fprintf(&b, `<text x="0" y="%.1f" font-size="10">%s</text>`, y+3, mood)
+	}
+	in, out := moodLayout(sessions, width, height)
+	for _, line := range []struct {
+		points []point
+		color  [3]float64
+	}{{in, moodInColor}, {out, moodOutColor}} {
+		coords := make([]string, len(line.points))
+		for i, p := range line.points {
+			coords[i] = fmt.Sprintf("%.1f,%.1f", p.x, p.y)
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/>`, p.x, p.y, svgColor(line.color))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(coords, " "), svgColor(line.color))
+	}
+	fmt.Fprintf(&b, `<text x="%d" y="%g" font-size="10" fill="%s">● before</text>`, chartLeft, height-6, svgColor(moodInColor))
+	fmt.Fprintf(&b, `<text x="%d" y="%g" font-size="10" fill="%s">● after</text>`, chartLeft+60, height-6, svgColor(moodOutColor))
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,167 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"fitness-dev/models"
+)
+
+// Layout of the PDF report, in points.
+const (
+	pdfContentWidth = pageWidth - 2*pageMargin
+	pdfChartHeight  = 170
+	pdfTableSize    = 8.5
+)
+
+// ReportPDF writes a monthly report as an A4 PDF with the same sections as
+// ReportHTML.
+func ReportPDF(w io.Writer, report models.MonthlyReport, opts Options) error {
+	units := opts.units()
+	d := newPDF()
+
+	d.text(pageMargin, d.y+18, 18, true, MonthTitle(report.Month))
+	d.y += 36
+	d.text(pageMargin, d.y, 11, false, fmt.Sprintf("%s · %s to %s", report.Athlete, report.StartDate, report.EndDate))
+	d.y += 18
+	d.text(pageMargin, d.y, 11, true, reportSummary(report, units))
+	d.y += 10
+
+	d.heading("Volume")
+	d.need(pdfChartHeight)
+	d.volumeChart(report.Weeks, units)
+
+	d.heading("Mood")
+	if len(report.Sessions) > 0 {
+		d.need(pdfChartHeight)
+		d.moodChart(report.Sessions)
+	} else {
+		d.empty("No sessions this month.")
+	}
+
+	d.heading("Sessions")
+	if len(report.Sessions) > 0 {
+		columns := []float64{0, 55, 112, 158, 312, 340, 400}
+		d.row(columns, true, "Date", "Time", "Duration", "Exercises", "Sets", "Tonnage", "Mood")
+		for _, s := range report.Sessions {
+			duration := ""
+			if s.Minutes > 0 {
+				duration = minutes(s.Minutes)
+			}
+			exercises := strings.Join(s.Exercises, ", ")
+			if s.Records > 0 {
+				exercises = Truncate(exercises, 26) + fmt.Sprintf(" (%d PR)", s.Records)
+			}
+			d.row(columns, false, s.Date, s.TimeIn+"–"+s.TimeOut, duration, Truncate(exercises, 32),
+				fmt.Sprint(s.Sets), kg(s.Tonnage, units), Truncate(moodRange(s.MoodIn, s.MoodOut), 20))
+		}
+	} else {
+		d.empty("No sessions this month.")
+	}
+
+	d.heading("Personal Records")
+	if len(report.Records) > 0 {
+		columns := []float64{0, 70, 260, 360}
+		d.row(columns, true, "Date", "Exercise", "Weight × Reps", "e1RM")
+		for _, r := range report.Records {
+			d.row(columns, false, r.Date, Truncate(r.Exercise, 40), fmt.Sprintf("%s × %d", kg(r.Weight, units), r.Reps), kg(r.E1RM, units))
+		}
+	} else {
+		d.empty("No personal records this month.")
+	}
+
+	d.heading("Goals")
+	if len(report.Goals) > 0 {
+		columns := []float64{0, 170, 240, 310, 365, 425}
+		d.row(columns, true, "Goal", "Target", "Current", "Progress", "Status", "Projected")
+		for _, g := range report.Goals {
+			d.row(columns, false, Truncate(goalName(g.Goal), 34), goalValue(g.Goal.Kind, g.Goal.Target, units),
+				goalValue(g.Goal.Kind, g.Current, units), fmt.Sprintf("%.0f%%", g.Progress), goalStatus(g.Status), g.ProjectedDate)
+		}
+	} else {
+		d.empty("No goals.")
+	}
+
+	return d.writeTo(w)
+}
+
+// heading starts a section, keeping room for its first lines.
+func (d *pdfDocument) heading(title string) {
+	d.y += 14
+	d.need(60)
+	d.y += 14
+	d.text(pageMargin, d.y, 13, true, title)
+	d.color(axisColor)
+	d.line(pageMargin, d.y+4, pageMargin+pdfContentWidth, d.y+4, 0.5)
+	d.color([3]float64{})
+	d.y += 16
+}
+
+func (d *pdfDocument) empty(message string) {
+	d.text(pageMargin, d.y+pdfTableSize, pdfTableSize+1, false, message)
+	d.y += 14
+}
+
+// row writes a line of a table with its cells starting at the given
+// offsets from the margin.
+func (d *pdfDocument) row(columns []float64, bold bool, cells ...string) {
+	d.need(pdfTableSize * 1.6)
+	for i, cell := range cells {
+		d.text(pageMargin+columns[i], d.y+pdfTableSize, pdfTableSize, bold, cell)
+	}
+	d.y += pdfTableSize * 1.6
+}
+
+// volumeChart draws the weekly tonnage bar chart at the current position.
+func (d *pdfDocument) volumeChart(weeks []models.SeriesPoint, units string) {
+	x0, y0 := float64(pageMargin), d.y
+	d.color(axisColor)
+	d.line(x0+chartLeft, y0+pdfChartHeight-chartBottom, x0+pdfContentWidth, y0+pdfChartHeight-chartBottom, 0.5)
+	d.text(x0, y0+chartTop, 9, false, "Tonnage")
+	for _, b := range barLayout(weeks, units, pdfContentWidth, pdfChartHeight) {
+		d.color(barColor)
+		d.rect(x0+b.x, y0+b.y, b.w, b.h)
+		d.color([3]float64{})
+		center := x0 + b.x + b.w/2
+		d.text(center-textWidth(b.value, 8)/2, y0+b.y-3, 8, false, b.value)
+		d.text(center-textWidth(b.label, 8)/2, y0+pdfChartHeight-6, 8, false, b.label)
+	}
+	d.y += pdfChartHeight
+}
+
+// moodChart draws the mood before and after each session at the current
+// position.
+func (d *pdfDocument) moodChart(sessions []models.ReportSession) {
+	x0, y0 := float64(pageMargin), d.y
+	plot := float64(pdfChartHeight - chartTop - chartBottom)
+	for _, mood := range models.Moods {
+		y, _ := moodY(mood, plot)
+		d.color([3]float64{0.9, 0.9, 0.9})
+		d.line(x0+chartLeft, y0+y, x0+pdfContentWidth, y0+y, 0.5)
+		d.color([3]float64{})
+		d.text(x0, y0+y+3, 8, false, mood)
+	}
+
+	in, out := moodLayout(sessions, pdfContentWidth, pdfChartHeight)
+	for _, line := range []struct {
+		points []point
+		color  [3]float64
+	}{{in, moodInColor}, {out, moodOutColor}} {
+		shifted := make([]point, len(line.points))
+		for i, p := range line.points {
+			shifted[i] = point{x0 + p.x, y0 + p.y}
+		}
+		d.color(line.color)
+		d.polyline(shifted, 1.5)
+		for _, p := range shifted {
+			d.rect(p.x-2, p.y-2, 4, 4)
+		}
+	}
+	d.color(moodInColor)
+	d.text(x0+chartLeft, y0+pdfChartHeight-6, 8, false, "● before")
+	d.color(moodOutColor)
+	d.text(x0+chartLeft+60, y0+pdfChartHeight-6, 8, false, "● after")
+	d.color([3]float64{})
+	d.y += pdfChartHeight
+}
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"fitness-dev/backend"
+	"fitness-dev/models"
+)
+
+// recordMarker flags lifts that set a personal record in text output.
+const recordMarker = "★ PR"
+
+// Text writes a plain-text summary of each workout: its times, moods and
+// totals, and an aligned table of its lifts.
+func Text(w io.Writer, workouts []models.Workout, opts Options) error {
+	units := opts.units()
+	for n, workout := range workouts {
+		if n > 0 {
+			fmt.Fprintln(w)
+		}
+		s := Summarize(workout, opts)
+
+		fmt.Fprintf(w, "%s %s", weekday(workout.Date), workout.Date)
+		if workout.TimeIn != "" {
+			fmt.Fprintf(w, "  %s-%s", workout.TimeIn, workout.TimeOut)
+		}
+		if s.Duration > 0 {
+			fmt.Fprintf(w, " (%s)", Duration(s.Duration))
+		}
+		fmt.Fprintln(w)
+		var details []string
+		if workout.Athlete != "" {
+			details = append(details, "Athlete: "+workout.Athlete)
+		}
+		if m := moods(workout); m != "" {
+			details = append(details, "Mood: "+m)
+		}
+		if workout.SessionRPE > 0 {
+			details = append(details, fmt.Sprintf("Session RPE %g", workout.SessionRPE))
+		}
+		if s := status(workout); s != "" {
+			details = append(details, "Status: "+s)
+		}
+		if len(details) > 0 {
+			fmt.Fprintln(w, strings.Join(details, "   "))
+		}
+
+		if len(workout.Lifts) > 0 {
+			fmt.Fprintln(w)
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "Exercise\tWeight\tSets x Reps\tTonnage\tNotes")
+			for i := range workout.Lifts {
+				lift := backend.GetLift(workout, i)
+				load := weight(workout, lift.Weight, opts)
+				notes := liftDetails(workout, lift, opts)
+				if isRecord(workout, i, opts) {
+					notes = strings.TrimPrefix(notes+"  "+recordMarker, "  ")
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%d x %d\t%s\t%s\n", lift.Name, Weight(load, units), lift.Sets, lift.Reps,
+					Weight(load*float64(lift.Reps*lift.Sets), units), notes)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+		}
+		for _, c := range workout.Cardio {
+			fmt.Fprintln(w, "Cardio: "+cardioLine(c))
+		}
+		if _, err := fmt.Fprintln(w, "Total: "+totalLine(s, units, ", ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lines describes a workout in short lines: one per lift and cardio
+// activity, then its moods and totals. It suits places without room for a
+// table, such as calendar events. Lifts not done yet show their target.
+func Lines(workout models.Workout, opts Options) []string {
+	units := opts.units()
+	var lines []string
+	for i := range workout.Lifts {
+		lift := backend.GetLift(workout, i)
+		if t := target(workout, lift, opts); t != "" && lift.Sets == 0 {
+			lines = append(lines, lift.Name+": target "+t)
+			continue
+		}
+		line := fmt.Sprintf("%s: %d x %d @ %s", lift.Name, lift.Sets, lift.Reps, Weight(weight(workout, lift.Weight, opts), units))
+		if notes := liftDetails(workout, lift, opts); notes != "" {
+			line += " (" + notes + ")"
+		}
+		if isRecord(workout, i, opts) {
+			line += " " + recordMarker
+		}
+		lines = append(lines, line)
+	}
+	for _, c := range workout.Cardio {
+		lines = append(lines, cardioLine(c))
+	}
+	if m := moods(workout); m != "" {
+		lines = append(lines, "Mood: "+m)
+	}
+	return append(lines, "Total: "+totalLine(Summarize(workout, opts), units, ", "))
+}
+
+// Table writes one aligned row per workout, for lists and ranges.
+func Table(w io.Writer, workouts []models.Workout, opts Options) error {
+	units := opts.units()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDate\tTime\tDuration\tMood\tExercises\tSets\tTonnage\tPRs")
+	for _, workout := range workouts {
+		s := Summarize(workout, opts)
+		duration := ""
+		if s.Duration > 0 {
+			duration = Duration(s.Duration)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s-%s\t%s\t%s\t%d\t%d\t%s\t%d\n", workout.ID, workout.Date, workout.TimeIn, workout.TimeOut,
+			duration, moods(workout), len(workout.Lifts), s.Sets, Weight(s.Tonnage, units), s.Records)
+	}
+	return tw.Flush()
+}
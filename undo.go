@@ -0,0 +1,39 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// undoAction reverses the most recent change made from the CLI.
+type undoAction struct {
+	description string
+	undo        func(db *sql.DB) error
+}
+
+var lastAction *undoAction
+
+// recordUndo remembers how to reverse the change that was just made.
+func recordUndo(description string, undo func(db *sql.DB) error) {
+	lastAction = &undoAction{description: description, undo: undo}
+}
+
+// clearUndo forgets the last action, for changes that cannot be reversed.
+func clearUndo() {
+	lastAction = nil
+}
+
+func undoLastAction(db *sql.DB) {
+	if lastAction == nil {
+		fmt.Println("Nothing to undo.")
+		return
+	}
+
+	if err := lastAction.undo(db); err != nil {
+		fmt.Printf("Failed to undo %s: %v\n", lastAction.description, err)
+		return
+	}
+
+	fmt.Printf("Undid %s.\n", lastAction.description)
+	lastAction = nil
+}